@@ -0,0 +1,248 @@
+package migrator
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// A Warning describes a statement of a pending migration that may lock
+// or rewrite a table for a long time.
+type Warning struct {
+	Version   string // version timestamp of the migration
+	Statement string // statement that was analyzed
+	Table     string // table affected by the statement, when known
+	Lock      string // lock taken on the table, such as ACCESS EXCLUSIVE
+	Rewrite   bool   // whether or not the table is rewritten or scanned
+	Destroy   bool   // whether or not data is destroyed
+	Size      int64  // total size of the table in bytes, when known
+	Message   string // explanation of the impact
+}
+
+// String returns a single line description of the warning.
+func (w Warning) String() string {
+	s := w.Message
+	if w.Table != "" {
+		s = w.Table + ": " + s
+	}
+
+	if w.Size > 0 {
+		s += fmt.Sprintf(" (%s)", bytes(w.Size))
+	}
+
+	if w.Version != "" {
+		s = w.Version + " " + s
+	}
+
+	return s
+}
+
+// A rule matches statements with a known lock impact.
+type rule struct {
+	match   *regexp.Regexp
+	unless  *regexp.Regexp
+	table   *regexp.Regexp
+	lock    string
+	rewrite bool
+	destroy bool
+	message string
+}
+
+// Patterns that extract the table affected by a statement.
+var (
+	tableAlter = regexp.MustCompile(`(?i)ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?([^\s(;]+)`)
+	tableIndex = regexp.MustCompile(`(?i)\sON\s+(?:ONLY\s+)?([^\s(;]+)`)
+	tableDrop  = regexp.MustCompile(`(?i)(?:DROP\s+TABLE|TRUNCATE(?:\s+TABLE)?|VACUUM\s+FULL|CLUSTER)\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?([^\s(;,]+)`)
+)
+
+// rules are the statements with a known lock impact on Postgres in order
+// of precedence. Only the first matching rule applies to a statement.
+var rules = []rule{
+	{
+		match:   regexp.MustCompile(`(?i)ALTER\s+TABLE\b.*\bALTER\s+(?:COLUMN\s+)?\S+\s+(?:SET\s+DATA\s+)?TYPE\b`),
+		table:   tableAlter,
+		lock:    "ACCESS EXCLUSIVE",
+		rewrite: true,
+		message: "changing a column type rewrites the table while blocking all access",
+	},
+	{
+		match:   regexp.MustCompile(`(?i)ALTER\s+TABLE\b.*\bADD\s+(?:COLUMN\s+)?.*\bDEFAULT\s+(?:now|random|clock_timestamp|gen_random_uuid|uuid_generate_\w+|nextval)\s*\(`),
+		table:   tableAlter,
+		lock:    "ACCESS EXCLUSIVE",
+		rewrite: true,
+		message: "adding a column with a volatile default rewrites the table while blocking all access",
+	},
+	{
+		match:   regexp.MustCompile(`(?i)ALTER\s+TABLE\b.*\bSET\s+NOT\s+NULL\b`),
+		table:   tableAlter,
+		lock:    "ACCESS EXCLUSIVE",
+		rewrite: true,
+		message: "setting NOT NULL scans the table while blocking all access; validate a check constraint first",
+	},
+	{
+		match:   regexp.MustCompile(`(?i)ALTER\s+TABLE\b.*\bADD\s+(?:CONSTRAINT\s+\S+\s+)?(?:FOREIGN\s+KEY|CHECK)\b`),
+		unless:  regexp.MustCompile(`(?i)\bNOT\s+VALID\b`),
+		table:   tableAlter,
+		lock:    "ACCESS EXCLUSIVE",
+		rewrite: true,
+		message: "adding a constraint scans the table while holding the lock; add it NOT VALID and validate it later",
+	},
+	{
+		match:   regexp.MustCompile(`(?i)ALTER\s+TABLE\b.*\bADD\s+(?:CONSTRAINT\s+\S+\s+)?(?:PRIMARY\s+KEY|UNIQUE)\b`),
+		unless:  regexp.MustCompile(`(?i)\bUSING\s+INDEX\b`),
+		table:   tableAlter,
+		lock:    "ACCESS EXCLUSIVE",
+		rewrite: true,
+		message: "adding a unique constraint builds an index while blocking all access; build the index concurrently first",
+	},
+	{
+		match:   regexp.MustCompile(`(?i)CREATE\s+(?:UNIQUE\s+)?INDEX\b`),
+		unless:  regexp.MustCompile(`(?i)\bCONCURRENTLY\b`),
+		table:   tableIndex,
+		lock:    "SHARE",
+		rewrite: true,
+		message: "creating an index blocks writes for the duration of the build; create it concurrently",
+	},
+	{
+		match:   regexp.MustCompile(`(?i)\b(?:VACUUM\s+FULL|CLUSTER)\b`),
+		table:   tableDrop,
+		lock:    "ACCESS EXCLUSIVE",
+		rewrite: true,
+		message: "rewrites the table while blocking all access",
+	},
+	{
+		match:   regexp.MustCompile(`(?i)\b(?:DROP\s+TABLE|TRUNCATE)\b`),
+		table:   tableDrop,
+		lock:    "ACCESS EXCLUSIVE",
+		destroy: true,
+		message: "destroys data while blocking all access",
+	},
+	{
+		match:   regexp.MustCompile(`(?i)ALTER\s+TABLE\b.*\bDROP\s+COLUMN\b`),
+		table:   tableAlter,
+		lock:    "ACCESS EXCLUSIVE",
+		destroy: true,
+		message: "destroys the data of the column while briefly blocking all access",
+	},
+	{
+		match:   regexp.MustCompile(`(?i)ALTER\s+TABLE\b`),
+		table:   tableAlter,
+		lock:    "ACCESS EXCLUSIVE",
+		message: "briefly blocks all access and waits behind running queries on the table",
+	},
+}
+
+// Analyze returns warnings for the SQL statement based on the locks it
+// takes on Postgres and whether or not it rewrites or scans a table.
+func Analyze(stmt string) []Warning {
+	var rv []Warning
+	for _, r := range rules {
+		if !r.match.MatchString(stmt) || (r.unless != nil && r.unless.MatchString(stmt)) {
+			continue
+		}
+
+		w := Warning{Statement: stmt, Lock: r.lock, Rewrite: r.rewrite, Destroy: r.destroy, Message: r.message}
+		if m := r.table.FindStringSubmatch(stmt); m != nil {
+			w.Table = strings.Trim(m[1], `"`)
+		}
+
+		rv = append(rv, w)
+		break
+	}
+
+	return rv
+}
+
+// queryTableSize selects the total size of a table in bytes.
+var queryTableSize = `
+SELECT COALESCE(pg_total_relation_size(to_regclass($1)), 0);
+`
+
+// Preflight returns the warnings for the statements of the SQL migrations
+// that would be performed to bring the database to the state of the
+// target version timestamp, without performing them. Warnings for
+// statements that rewrite or scan a table include its size.
+func Preflight(db *sql.DB, target string, opts ...Option) ([]Warning, error) {
+	o := newOptions(opts)
+
+	err := o.createVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	vs, _, up, err := plan(db, target, o)
+	if err != nil {
+		return nil, err
+	}
+
+	return analyze(db, vs, up, o)
+}
+
+// analyze returns the warnings for the statements of the SQL migrations
+// of the version timestamps in the direction of the run.
+func analyze(conn Conn, vs []string, up bool, o *options) ([]Warning, error) {
+	if o.dialect != Postgres {
+		return nil, nil
+	}
+
+	var rv []Warning
+	for _, v := range vs {
+		stmts := migrations[v].upSQL
+		if !up {
+			stmts = migrations[v].downSQL
+		}
+
+		for _, stmt := range stmts {
+			for _, w := range Analyze(stmt) {
+				w.Version = v
+				if w.Rewrite && w.Table != "" {
+					err := conn.QueryRowContext(o.ctx, queryTableSize, w.Table).Scan(&w.Size)
+					if err != nil {
+						return nil, err
+					}
+				}
+
+				rv = append(rv, w)
+			}
+		}
+	}
+
+	return rv, nil
+}
+
+// preflight analyzes the statements of the SQL migrations of the version
+// timestamps before they are performed. The warnings are passed to the
+// function set by WithPreflight or printed if it is not set.
+func preflight(conn Conn, vs []string, up bool, o *options) error {
+	ws, err := analyze(conn, vs, up, o)
+	if err != nil || len(ws) == 0 {
+		return err
+	}
+
+	if o.preflight != nil {
+		return o.preflight(ws)
+	}
+
+	for _, w := range ws {
+		o.logf(LevelWarn, "%s", w)
+	}
+
+	return nil
+}
+
+// bytes returns the size in bytes in human readable form.
+func bytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for i := n / unit; i >= unit; i /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
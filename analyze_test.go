@@ -0,0 +1,167 @@
+package migrator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		stmt    string
+		table   string
+		lock    string
+		rewrite bool
+		none    bool
+	}{
+		{stmt: "ALTER TABLE users ALTER COLUMN age TYPE bigint;", table: "users", lock: "ACCESS EXCLUSIVE", rewrite: true},
+		{stmt: "ALTER TABLE users ALTER age SET DATA TYPE bigint;", table: "users", lock: "ACCESS EXCLUSIVE", rewrite: true},
+		{stmt: "ALTER TABLE users ADD COLUMN token uuid DEFAULT gen_random_uuid();", table: "users", lock: "ACCESS EXCLUSIVE", rewrite: true},
+		{stmt: "ALTER TABLE users ADD COLUMN active boolean DEFAULT true;", table: "users", lock: "ACCESS EXCLUSIVE"},
+		{stmt: "ALTER TABLE users ALTER COLUMN email SET NOT NULL;", table: "users", lock: "ACCESS EXCLUSIVE", rewrite: true},
+		{stmt: "ALTER TABLE orders ADD CONSTRAINT orders_user_fk FOREIGN KEY (user_id) REFERENCES users (id);", table: "orders", lock: "ACCESS EXCLUSIVE", rewrite: true},
+		{stmt: "ALTER TABLE orders ADD CONSTRAINT orders_user_fk FOREIGN KEY (user_id) REFERENCES users (id) NOT VALID;", table: "orders", lock: "ACCESS EXCLUSIVE"},
+		{stmt: "ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email);", table: "users", lock: "ACCESS EXCLUSIVE", rewrite: true},
+		{stmt: "ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE USING INDEX users_email_idx;", table: "users", lock: "ACCESS EXCLUSIVE"},
+		{stmt: "CREATE INDEX users_email_idx ON users (email);", table: "users", lock: "SHARE", rewrite: true},
+		{stmt: "CREATE UNIQUE INDEX users_email_idx ON ONLY users (email);", table: "users", lock: "SHARE", rewrite: true},
+		{stmt: "CREATE INDEX CONCURRENTLY users_email_idx ON users (email);", none: true},
+		{stmt: "VACUUM FULL users;", table: "users", lock: "ACCESS EXCLUSIVE", rewrite: true},
+		{stmt: "DROP TABLE IF EXISTS users;", table: "users", lock: "ACCESS EXCLUSIVE"},
+		{stmt: "TRUNCATE TABLE users;", table: "users", lock: "ACCESS EXCLUSIVE"},
+		{stmt: "ALTER TABLE users DROP COLUMN email;", table: "users", lock: "ACCESS EXCLUSIVE"},
+		{stmt: `ALTER TABLE "users" RENAME COLUMN name TO full_name;`, table: "users", lock: "ACCESS EXCLUSIVE"},
+		{stmt: "INSERT INTO users (name) VALUES ('a');", none: true},
+		{stmt: "CREATE TABLE users (id bigint);", none: true},
+	}
+
+	for _, tt := range tests {
+		ws := Analyze(tt.stmt)
+		if tt.none {
+			if len(ws) != 0 {
+				t.Errorf("Analyze(%q) = %v, want no warnings", tt.stmt, ws)
+			}
+			continue
+		}
+
+		if len(ws) != 1 {
+			t.Errorf("Analyze(%q) returned %d warnings, want 1", tt.stmt, len(ws))
+			continue
+		}
+
+		w := ws[0]
+		if w.Statement != tt.stmt || w.Table != tt.table || w.Lock != tt.lock || w.Rewrite != tt.rewrite {
+			t.Errorf("Analyze(%q) = {Table: %q, Lock: %q, Rewrite: %t}, want {Table: %q, Lock: %q, Rewrite: %t}",
+				tt.stmt, w.Table, w.Lock, w.Rewrite, tt.table, tt.lock, tt.rewrite)
+		}
+	}
+}
+
+func TestWarningString(t *testing.T) {
+	tests := []struct {
+		w    Warning
+		want string
+	}{
+		{Warning{Message: "blocks"}, "blocks"},
+		{Warning{Table: "users", Message: "blocks"}, "users: blocks"},
+		{Warning{Version: "20240101T000000Z", Table: "users", Size: 2048, Message: "blocks"}, "20240101T000000Z users: blocks (2.0 KiB)"},
+	}
+
+	for _, tt := range tests {
+		have := tt.w.String()
+		if have != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.w, have, tt.want)
+		}
+	}
+}
+
+func TestBytes(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1024, "1.0 KiB"},
+		{1536, "1.5 KiB"},
+		{1 << 20, "1.0 MiB"},
+		{5 << 30, "5.0 GiB"},
+	}
+
+	for _, tt := range tests {
+		have := bytes(tt.n)
+		if have != tt.want {
+			t.Errorf("bytes(%d) = %q, want %q", tt.n, have, tt.want)
+		}
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	isolate(t)
+
+	registerSQL("20240101T000000Z", "plain", []string{"CREATE TABLE users (id bigint);"}, nil, false, nil)
+	registerSQL("20240102T000000Z", "drop", []string{"DROP TABLE users;"}, []string{"CREATE TABLE users (id bigint);"}, false, nil)
+
+	db := openTestDB(t)
+	vs := []string{"20240101T000000Z", "20240102T000000Z"}
+
+	ws, err := analyze(db, vs, true, newOptions(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ws) != 1 || ws[0].Version != "20240102T000000Z" || ws[0].Table != "users" {
+		t.Fatalf("analyze up = %v, want the drop of users by 20240102T000000Z", ws)
+	}
+
+	ws, err = analyze(db, vs, false, newOptions(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ws) != 0 {
+		t.Errorf("analyze down = %v, want no warnings", ws)
+	}
+
+	ws, err = analyze(db, vs, true, newOptions([]Option{WithDialect(SQLite)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ws) != 0 {
+		t.Errorf("analyze on SQLite = %v, want no warnings", ws)
+	}
+
+	abort := errors.New("abort")
+	var have []Warning
+	err = preflight(db, vs, true, newOptions([]Option{WithPreflight(func(ws []Warning) error {
+		have = ws
+		return abort
+	})}))
+	if err != abort {
+		t.Errorf("preflight error = %v, want %v", err, abort)
+	}
+
+	if len(have) != 1 {
+		t.Errorf("preflight passed %d warnings, want 1", len(have))
+	}
+}
+
+func TestAnalyzeDestroy(t *testing.T) {
+	tests := []struct {
+		stmt string
+		want bool
+	}{
+		{"DROP TABLE users;", true},
+		{"TRUNCATE users;", true},
+		{"ALTER TABLE users DROP COLUMN email;", true},
+		{"ALTER TABLE users ADD COLUMN email text;", false},
+		{"CREATE INDEX users_email_idx ON users (email);", false},
+	}
+
+	for _, tt := range tests {
+		ws := Analyze(tt.stmt)
+		if len(ws) != 1 || ws[0].Destroy != tt.want {
+			t.Errorf("Analyze(%q) = %+v, want Destroy %t", tt.stmt, ws, tt.want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package migrator
+
+// An Approval is the plan of a run submitted for approval.
+type Approval struct {
+	Target  string // target version timestamp, empty for the latest
+	Current string // version timestamp of the database before the run
+	Set     string // migration set of the run, if any
+	Up      bool   // whether or not the run migrates up
+	Steps   []Step // steps of the run in the order they are performed
+}
+
+// Destructive returns true if a step of the plan reverts a migration or
+// has a statement that destroys data.
+func (a Approval) Destructive() bool {
+	for _, s := range a.Steps {
+		if s.Destructive() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithApproval sets fn to receive the plan of the run after it is
+// planned and confirmed and before any migration is performed, such as
+// to post it to a change management system and block until it is
+// approved. Returning an error aborts the run with it. Unlike the
+// function of WithConfirm, fn is not called when the run has no
+// migrations to perform.
+func WithApproval(fn func(Approval) error) Option {
+	return func(o *options) {
+		o.approve = fn
+	}
+}
+
+// approve passes the plan of the version timestamps to the approval
+// function of the run.
+func approve(conn Conn, target string, vs []string, up bool, o *options) error {
+	if o.approve == nil || len(vs) == 0 {
+		return nil
+	}
+
+	current, err := currentVersion(conn, o)
+	if err != nil {
+		return err
+	}
+
+	s, err := steps(conn, vs, up, o)
+	if err != nil {
+		return err
+	}
+
+	return o.approve(Approval{
+		Target:  target,
+		Current: current,
+		Set:     o.set,
+		Up:      up,
+		Steps:   s,
+	})
+}
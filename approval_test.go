@@ -0,0 +1,56 @@
+package migrator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestWithApproval(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+
+	var have []Approval
+	denied := errors.New("denied")
+	approve := func(a Approval) error {
+		have = append(have, a)
+		if !a.Up {
+			return denied
+		}
+
+		return nil
+	}
+
+	db := openTestDB(t)
+	opts := []Option{WithDialect(SQLite), WithApproval(approve)}
+	err := Migrate(db, "", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(have) != 1 {
+		t.Fatalf("approval requested %d times, want 1", len(have))
+	}
+
+	a := have[0]
+	if a.Target != "" || a.Current != "" || !a.Up || len(a.Steps) != 2 || a.Steps[1].Version != "20240101T000000Z" || a.Destructive() {
+		t.Errorf("approval = %+v, want the up plan", a)
+	}
+
+	err = Migrate(db, "", opts...)
+	if err != nil || len(have) != 1 {
+		t.Errorf("up to date run error = %v, %d approvals, want none requested", err, len(have))
+	}
+
+	err = Migrate(db, NilVersion, opts...)
+	if !errors.Is(err, denied) {
+		t.Errorf("denied run error = %v, want %v", err, denied)
+	}
+
+	if len(have) != 2 || have[1].Current != "20240101T000000Z" || !have[1].Destructive() {
+		t.Errorf("approvals = %+v, want the destructive down plan", have)
+	}
+
+	if !tableExists(t, db, "users") {
+		t.Error("denied run reverted the migration")
+	}
+}
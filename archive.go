@@ -0,0 +1,167 @@
+package migrator
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// queryArchiveNew creates the archive table of the versions table if not
+// already created.
+var queryArchiveNew = `
+CREATE TABLE IF NOT EXISTS %[1]s_archive (
+  id          BIGINT NOT NULL,
+  version     VARCHAR(255) NOT NULL,
+  name        VARCHAR(255) NOT NULL,
+  skip_reason VARCHAR(255) NOT NULL DEFAULT '',
+  checksum    VARCHAR(64) NOT NULL DEFAULT '',
+  created_at  TIMESTAMP NOT NULL,
+  reverted_at TIMESTAMP NULL,
+  archived_at TIMESTAMP NOT NULL
+);
+`
+
+// queryArchiveAll selects the archived migrations by ascending version.
+var queryArchiveAll = `
+SELECT id, version, name, skip_reason, checksum, created_at
+  FROM %[1]s_archive
+  WHERE reverted_at IS NULL
+  ORDER BY version ASC;
+`
+
+// queryArchiveInsert copies the versions before a version timestamp to
+// the archive table at the time of the clock of the run.
+var queryArchiveInsert = `
+INSERT INTO %[1]s_archive (id, version, name, skip_reason, checksum, created_at, reverted_at, archived_at)
+  SELECT id, version, name, skip_reason, checksum, created_at, reverted_at, $1
+  FROM %[1]s
+  WHERE version < $2;
+`
+
+// queryArchiveImport inserts an archived version as it was recorded in
+// another database.
+var queryArchiveImport = `
+INSERT INTO %[1]s_archive (id, version, name, skip_reason, checksum, created_at, archived_at)
+  VALUES ($1, $2, $3, $4, $5, $6, $7);
+`
+
+// queryArchiveClear deletes every archived version.
+var queryArchiveClear = `
+DELETE FROM %[1]s_archive;
+`
+
+// queryVersionsPrune deletes the versions before a version timestamp.
+var queryVersionsPrune = `
+DELETE FROM %[1]s
+  WHERE version < $1;
+`
+
+// Queries that select whether a table exists by dialect.
+var queriesTableExists = map[Dialect]string{
+	Postgres: `SELECT COUNT(*) FROM pg_tables WHERE schemaname = current_schema() AND tablename = $1;`,
+	MySQL:    `SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?;`,
+	SQLite:   `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?;`,
+}
+
+// Archive moves the versions applied before the version timestamp from
+// the versions table to the table <table>_archive and writes them to w,
+// if not nil, as JSON lines of Info, keeping the versions table small
+// while retaining its history. Archived versions remain applied but can
+// no longer be reverted. Every registered migration before the version
+// must be applied, and the version may not be after the current version.
+// The number of archived versions is returned.
+func Archive(db *sql.DB, before string, w io.Writer, opts ...Option) (int, error) {
+	o := newOptions(opts)
+
+	err := o.createVersions(db)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = db.ExecContext(o.ctx, o.query(queryArchiveNew))
+	if err != nil {
+		return 0, err
+	}
+
+	current, err := currentVersion(db, o)
+	if err != nil {
+		return 0, err
+	}
+
+	if before > current {
+		return 0, fmt.Errorf("migrator: cannot archive versions before %s, after the current version %s", before, current)
+	}
+
+	vs, err := versions(db, o)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, v := range o.sorted() {
+		if v < before && find(v, vs) == nil {
+			return 0, fmt.Errorf("migrator: cannot archive versions before %s: %s is pending", before, v)
+		}
+	}
+
+	var n int
+	enc := json.NewEncoder(w)
+	for _, v := range vs {
+		if v.version >= before || v.archived {
+			continue
+		}
+
+		n++
+		if w != nil {
+			info := v.info()
+			info.Archived = true
+			err = enc.Encode(info)
+			if err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	tx, err := db.BeginTx(o.ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(o.ctx, o.query(queryArchiveInsert), o.now().UTC(), before)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(o.ctx, o.query(queryVersionsPrune), before)
+	if err != nil {
+		return 0, err
+	}
+
+	return n, tx.Commit()
+}
+
+// archived returns the versions archived from the versions table of the
+// run, or nil if none have been.
+func archived(conn Conn, o *options) ([]*version, error) {
+	ok, err := o.hasArchive(conn)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	vs, err := scanVersions(conn, o.query(queryArchiveAll), o)
+	for _, v := range vs {
+		v.archived = true
+	}
+
+	return vs, err
+}
+
+// hasArchive returns whether or not the archive table of the versions
+// table of the run exists.
+func (o *options) hasArchive(conn Conn) (bool, error) {
+	var n int
+	err := conn.QueryRowContext(o.ctx, o.dialect.rebind(queriesTableExists[o.dialect]), o.table+"_archive").Scan(&n)
+	return n > 0, err
+}
@@ -0,0 +1,139 @@
+package migrator
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestArchive(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{
+		"20240101T000000Z": "users",
+		"20240102T000000Z": "posts",
+		"20240103T000000Z": "tags",
+	})
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+	err := Migrate(db, "", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	n, err := Archive(db, "20240102T000000Z", &b, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 2 {
+		t.Errorf("archived %d versions, want 2", n)
+	}
+
+	var have []string
+	dec := json.NewDecoder(strings.NewReader(b.String()))
+	for dec.More() {
+		var info Info
+		err = dec.Decode(&info)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		have = append(have, info.Version)
+	}
+
+	if strings.Join(have, ",") != NilVersion+",20240101T000000Z" {
+		t.Errorf("wrote %v, want the archived versions", have)
+	}
+
+	var rows int
+	err = db.QueryRow("SELECT COUNT(*) FROM versions;").Scan(&rows)
+	if err != nil || rows != 2 {
+		t.Errorf("versions table has %d rows, %v, want 2", rows, err)
+	}
+
+	infos, err := Applied(db, sqlite)
+	if err != nil || len(infos) != 4 {
+		t.Errorf("Applied returned %d versions, %v, want the archived versions included", len(infos), err)
+	}
+
+	err = Migrate(db, "20240102T000000Z", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Migrate(db, NilVersion, sqlite)
+	if err == nil || !strings.Contains(err.Error(), "cannot revert archived version") {
+		t.Errorf("revert of an archived version error = %v", err)
+	}
+
+	n, err = Archive(db, "20240102T000000Z", nil, sqlite)
+	if err != nil || n != 0 {
+		t.Errorf("archive again = %d, %v, want nothing archived", n, err)
+	}
+}
+
+func TestArchiveCurrent(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240102T000000Z": "users"})
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+	err := Migrate(db, NilVersion, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Archive(db, "20240101T000000Z", nil, sqlite)
+	if err == nil || !strings.Contains(err.Error(), "after the current version") {
+		t.Errorf("archive after the current version error = %v", err)
+	}
+
+	err = Migrate(db, "", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Archive(db, "20240102T000001Z", nil, sqlite)
+	if err == nil {
+		t.Error("archive after the current version error = nil")
+	}
+
+	n, err := Archive(db, "20240102T000000Z", nil, sqlite)
+	if err != nil || n != 1 {
+		t.Fatalf("archived %d versions, %v, want the nil version", n, err)
+	}
+
+	err = Migrate(db, NilVersion, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := Current(db, sqlite)
+	if err != nil || v != NilVersion {
+		t.Errorf("Current = %q, %v, want the archived version", v, err)
+	}
+}
+
+func TestArchivePending(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+	err := Migrate(db, NilVersion, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.Exec("INSERT INTO versions (version, name) VALUES ('20240102T000000Z', 'hotfix');")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Archive(db, "20240102T000000Z", nil, sqlite)
+	if err == nil || !strings.Contains(err.Error(), "20240101T000000Z is pending") {
+		t.Errorf("archive with a pending version error = %v", err)
+	}
+}
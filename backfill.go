@@ -0,0 +1,229 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// A Conn is a database handle that can start transactions. Both *sql.DB
+// and *sql.Conn satisfy the interface.
+type Conn interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// A BatchFunc is a function that performs operations on the rows of a
+// backfill batch whose keys are within the half-open range [lo, hi).
+type BatchFunc func(tx *sql.Tx, lo, hi int64) error
+
+// A BackfillOption configures a backfill.
+type BackfillOption func(*backfill)
+
+// backfill is the configuration of a backfill.
+type backfill struct {
+	ctx        context.Context
+	checkpoint string
+	rate       float64
+	sleep      time.Duration
+	throttle   func(ctx context.Context) error
+}
+
+// BackfillContext sets the context of the backfill, which defaults to
+// the context of the run executing the migration. Cancelling the context
+// rolls back the batch in progress and stops the backfill.
+func BackfillContext(ctx context.Context) BackfillOption {
+	return func(b *backfill) {
+		b.ctx = ctx
+	}
+}
+
+// BackfillCheckpoint saves the progress of the backfill to the named
+// checkpoint with each committed batch. An interrupted backfill resumes
+// from the last committed batch when it is run again, and the checkpoint
+// is cleared once the backfill completes.
+func BackfillCheckpoint(name string) BackfillOption {
+	return func(b *backfill) {
+		b.checkpoint = name
+	}
+}
+
+// BackfillRate limits the backfill to processing about n keys per second
+// by pausing between batches. For tables with dense keys this
+// approximates a limit on rows per second.
+func BackfillRate(n float64) BackfillOption {
+	return func(b *backfill) {
+		b.rate = n
+	}
+}
+
+// BackfillSleep pauses the backfill for d between batches.
+func BackfillSleep(d time.Duration) BackfillOption {
+	return func(b *backfill) {
+		b.sleep = d
+	}
+}
+
+// BackfillThrottle sets a function consulted between batches. The
+// function may block to delay the next batch, for example while
+// replication lag is high, or return an error to stop the backfill.
+func BackfillThrottle(fn func(ctx context.Context) error) BackfillOption {
+	return func(b *backfill) {
+		b.throttle = fn
+	}
+}
+
+// queryBackfillBounds selects the smallest and largest key of a table.
+var queryBackfillBounds = `
+SELECT MIN(%[2]s), MAX(%[2]s)
+  FROM %[1]s;
+`
+
+// Backfill iterates over table in ranges of size keys of the integer
+// key column, calling fn with each range in its own transaction that is
+// committed before the next range begins. This keeps large data
+// migrations from holding a single transaction and its locks for the
+// duration of the migration. The table and key are interpolated into
+// the query and must be trusted identifiers.
+//
+// Backfill reports its progress in keys to the run executing the
+// migration as each batch is committed.
+//
+// Backfill commits as it goes and so must be called from a migration
+// registered with RegisterNoTransaction.
+func Backfill(conn Conn, table, key string, size int64, fn BatchFunc, opts ...BackfillOption) error {
+	if size <= 0 {
+		return fmt.Errorf("migrator: backfill batch size %d must be positive", size)
+	}
+
+	b := &backfill{ctx: optionsOf(conn).ctx}
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	var min, max sql.NullInt64
+	err := conn.QueryRowContext(b.ctx, fmt.Sprintf(queryBackfillBounds, table, key)).Scan(&min, &max)
+	if err != nil {
+		return err
+	}
+
+	if !min.Valid {
+		return nil
+	}
+
+	var began time.Time
+	total := max.Int64 - min.Int64 + 1
+	start := min.Int64
+	if b.checkpoint != "" {
+		position, ok, err := LoadCheckpoint(conn, DialectOf(conn), b.checkpoint)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			start = position
+		}
+	}
+
+	for lo := start; lo <= max.Int64; lo += size {
+		if lo > start {
+			err = b.pace(size, time.Since(began))
+			if err != nil {
+				return err
+			}
+		}
+
+		err = b.ctx.Err()
+		if err != nil {
+			return err
+		}
+
+		began = time.Now()
+		err = b.batch(conn, lo, lo+size, fn)
+		if err != nil {
+			return fmt.Errorf("migrator: backfill %s [%d, %d): %w", table, lo, lo+size, err)
+		}
+
+		done := lo + size - min.Int64
+		if done > total {
+			done = total
+		}
+
+		ReportProgress(conn, done, total)
+	}
+
+	if b.checkpoint != "" {
+		return ClearCheckpoint(conn, DialectOf(conn), b.checkpoint)
+	}
+
+	return nil
+}
+
+// pace waits between batches according to the configured sleep and
+// rate, given the number of keys and duration of the previous batch,
+// and then consults the throttle function.
+func (b *backfill) pace(keys int64, elapsed time.Duration) error {
+	d := b.sleep
+	if b.rate > 0 {
+		wait := time.Duration(float64(keys)/b.rate*float64(time.Second)) - elapsed
+		if wait > d {
+			d = wait
+		}
+	}
+
+	if d > 0 {
+		t := time.NewTimer(d)
+		select {
+		case <-t.C:
+		case <-b.ctx.Done():
+			t.Stop()
+			return b.ctx.Err()
+		}
+	}
+
+	if b.throttle != nil {
+		return b.throttle(b.ctx)
+	}
+
+	return nil
+}
+
+// batch calls fn with the range in its own transaction, saving the
+// checkpoint if configured before committing.
+func (b *backfill) batch(conn Conn, lo, hi int64, fn BatchFunc) error {
+	tx, err := conn.BeginTx(b.ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = fn(tx, lo, hi)
+	if err == nil && b.checkpoint != "" {
+		err = SaveCheckpoint(tx, DialectOf(conn), b.checkpoint, hi)
+	}
+
+	if err != nil {
+		if err := tx.Rollback(); err != nil && err != sql.ErrTxDone {
+			return err
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// batchDefaults returns the key column and batch size for backfills
+// performed by helpers, defaulting to the id column in batches of 10000.
+func batchDefaults(key string, size int64) (string, int64) {
+	if key == "" {
+		key = "id"
+	}
+
+	if size == 0 {
+		size = 10000
+	}
+
+	return key, size
+}
@@ -0,0 +1,270 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// backfillTestDB returns a database with the table items of keys 1 to n.
+func backfillTestDB(t *testing.T, n int) *sql.DB {
+	t.Helper()
+
+	db := openTestDB(t)
+	_, err := db.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY, done INTEGER NOT NULL DEFAULT 0)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= n; i++ {
+		_, err = db.Exec("INSERT INTO items (id) VALUES (?)", i)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return db
+}
+
+func TestBackfill(t *testing.T) {
+	db := backfillTestDB(t, 10)
+
+	var ranges [][2]int64
+	err := Backfill(db, "items", "id", 4, func(tx *sql.Tx, lo, hi int64) error {
+		ranges = append(ranges, [2]int64{lo, hi})
+		_, err := tx.Exec("UPDATE items SET done = 1 WHERE id >= ? AND id < ?", lo, hi)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][2]int64{{1, 5}, {5, 9}, {9, 13}}
+	if !reflect.DeepEqual(ranges, want) {
+		t.Errorf("ranges = %v, want %v", ranges, want)
+	}
+
+	var pending int
+	err = db.QueryRow("SELECT COUNT(*) FROM items WHERE done = 0").Scan(&pending)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if pending != 0 {
+		t.Errorf("%d rows not backfilled, want 0", pending)
+	}
+}
+
+func TestBackfillEmpty(t *testing.T) {
+	db := backfillTestDB(t, 0)
+	err := Backfill(db, "items", "id", 4, func(tx *sql.Tx, lo, hi int64) error {
+		t.Errorf("batch [%d, %d) called for empty table", lo, hi)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackfillError(t *testing.T) {
+	db := backfillTestDB(t, 10)
+	errBatch := errors.New("batch failed")
+	err := Backfill(db, "items", "id", 4, func(tx *sql.Tx, lo, hi int64) error {
+		_, err := tx.Exec("UPDATE items SET done = 1 WHERE id >= ? AND id < ?", lo, hi)
+		if err != nil {
+			return err
+		}
+
+		if lo == 5 {
+			return errBatch
+		}
+
+		return nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "[5, 9)") {
+		t.Fatalf("Backfill error = %v, want the failed range", err)
+	}
+
+	var done int
+	err = db.QueryRow("SELECT COUNT(*) FROM items WHERE done = 1").Scan(&done)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if done != 4 {
+		t.Errorf("%d rows committed, want the 4 rows of the first batch", done)
+	}
+}
+
+func TestBackfillSize(t *testing.T) {
+	db := backfillTestDB(t, 1)
+	err := Backfill(db, "items", "id", 0, func(tx *sql.Tx, lo, hi int64) error { return nil })
+	if err == nil {
+		t.Error("Backfill with size 0 succeeded, want error")
+	}
+}
+
+func TestBackfillCheckpoint(t *testing.T) {
+	db := backfillTestDB(t, 10)
+
+	var ranges [][2]int64
+	fn := func(fail int64) BatchFunc {
+		return func(tx *sql.Tx, lo, hi int64) error {
+			if lo == fail {
+				return errors.New("interrupted")
+			}
+
+			ranges = append(ranges, [2]int64{lo, hi})
+			return nil
+		}
+	}
+
+	err := Backfill(db, "items", "id", 4, fn(5), BackfillCheckpoint("items"))
+	if err == nil {
+		t.Fatal("interrupted Backfill succeeded")
+	}
+
+	position, ok, err := LoadCheckpoint(db, SQLite, "items")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !ok || position != 5 {
+		t.Fatalf("checkpoint = %d, %t, want 5, true", position, ok)
+	}
+
+	err = Backfill(db, "items", "id", 4, fn(0), BackfillCheckpoint("items"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][2]int64{{1, 5}, {5, 9}, {9, 13}}
+	if !reflect.DeepEqual(ranges, want) {
+		t.Errorf("ranges = %v, want %v resumed from the checkpoint", ranges, want)
+	}
+
+	_, ok, err = LoadCheckpoint(db, SQLite, "items")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok {
+		t.Error("checkpoint remains after the backfill completed")
+	}
+}
+
+func TestBackfillContext(t *testing.T) {
+	db := backfillTestDB(t, 10)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	batches := 0
+	err := Backfill(db, "items", "id", 4, func(tx *sql.Tx, lo, hi int64) error {
+		batches++
+		cancel()
+		return nil
+	}, BackfillContext(ctx))
+	if err == nil || !strings.Contains(err.Error(), context.Canceled.Error()) {
+		t.Errorf("Backfill error = %v, want context canceled", err)
+	}
+
+	if batches != 1 {
+		t.Errorf("%d batches ran, want 1 before the cancellation", batches)
+	}
+}
+
+func TestBackfillRunContext(t *testing.T) {
+	conn := testConn(t, backfillTestDB(t, 10))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	untrack := track(conn, "20240101T000000Z", newOptions([]Option{WithContext(ctx)}))
+	defer untrack()
+
+	err := Backfill(conn, "items", "id", 4, func(tx *sql.Tx, lo, hi int64) error {
+		t.Error("batch ran after the run was canceled")
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Backfill error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestBackfillThrottle(t *testing.T) {
+	db := backfillTestDB(t, 10)
+
+	throttled, batches := 0, 0
+	errStop := errors.New("replication lag")
+	throttle := func(ctx context.Context) error {
+		throttled++
+		if throttled == 2 {
+			return errStop
+		}
+
+		return nil
+	}
+
+	err := Backfill(db, "items", "id", 2, func(tx *sql.Tx, lo, hi int64) error {
+		batches++
+		return nil
+	}, BackfillThrottle(throttle))
+	if err != errStop {
+		t.Errorf("Backfill error = %v, want the error of the throttle", err)
+	}
+
+	if batches != 2 || throttled != 2 {
+		t.Errorf("%d batches and %d throttles, want the throttle between each of 2 batches", batches, throttled)
+	}
+}
+
+func TestBackfillPace(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		b    *backfill
+	}{
+		{"sleep", &backfill{ctx: ctx, sleep: time.Hour}},
+		{"rate", &backfill{ctx: ctx, rate: 0.001}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.b.pace(100, 0)
+			if err != context.Canceled {
+				t.Errorf("pace = %v, want context.Canceled while waiting", err)
+			}
+		})
+	}
+
+	b := &backfill{ctx: context.Background(), rate: 1e9}
+	err := b.pace(1, time.Second)
+	if err != nil {
+		t.Errorf("pace = %v for a batch slower than the rate, want nil without waiting", err)
+	}
+}
+
+func TestBatchDefaults(t *testing.T) {
+	tests := []struct {
+		key      string
+		size     int64
+		wantKey  string
+		wantSize int64
+	}{
+		{"", 0, "id", 10000},
+		{"user_id", 0, "user_id", 10000},
+		{"", 500, "id", 500},
+		{"user_id", 500, "user_id", 500},
+	}
+
+	for _, tt := range tests {
+		key, size := batchDefaults(tt.key, tt.size)
+		if key != tt.wantKey || size != tt.wantSize {
+			t.Errorf("batchDefaults(%q, %d) = %q, %d, want %q, %d", tt.key, tt.size, key, size, tt.wantKey, tt.wantSize)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package migrator
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// WithBackup calls fn before each migration that is destructive or has
+// warnings, such as one that drops a column or rewrites a table, to take
+// a backup of the data it affects. The location returned by fn is logged
+// and recorded in the Backups of the run. If fn returns an error, the
+// run is aborted with it before the migration is performed. See PGDump.
+func WithBackup(fn func(ctx context.Context, s Step) (string, error)) Option {
+	return func(o *options) {
+		o.backup = fn
+	}
+}
+
+// PGDump returns a function for WithBackup that dumps the tables named by
+// the warnings of the step, or the whole database if none are named, from
+// the Postgres database at the url into a file in dir with pg_dump in its
+// custom format for pg_restore. The location is the path of the file.
+func PGDump(rawurl, dir string) func(ctx context.Context, s Step) (string, error) {
+	return func(ctx context.Context, s Step) (string, error) {
+		name := fmt.Sprintf("%s_%s_%s.dump", time.Now().UTC().Format(VersionLayout), s.Version, s.Name)
+		path := filepath.Join(dir, name)
+		args := []string{"--format=custom", "--file=" + path}
+
+		seen := make(map[string]bool)
+		for _, w := range s.Warnings {
+			if w.Table != "" && !seen[w.Table] {
+				seen[w.Table] = true
+				args = append(args, "--table="+w.Table)
+			}
+		}
+
+		args = append(args, rawurl)
+		out, err := exec.CommandContext(ctx, "pg_dump", args...).CombinedOutput()
+		if err != nil {
+			return "", fmt.Errorf("pg_dump: %w: %s", err, strings.TrimSpace(string(out)))
+		}
+
+		return path, nil
+	}
+}
+
+// backups returns the steps of the version timestamps that are backed up
+// before they are performed by version timestamp.
+func (o *options) backups(conn Conn, vs []string, up bool) (map[string]Step, error) {
+	if o.backup == nil || len(vs) == 0 {
+		return nil, nil
+	}
+
+	ss, err := steps(conn, vs, up, o)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := make(map[string]Step)
+	for _, s := range ss {
+		if s.Destructive() || len(s.Warnings) > 0 {
+			rv[s.Version] = s
+		}
+	}
+
+	return rv, nil
+}
+
+// takeBackup backs up the data affected by the step if it is backed up and
+// records the location of the backup in the run.
+func (o *options) takeBackup(s Step, ok bool) error {
+	if !ok {
+		return nil
+	}
+
+	o.logf(LevelInfo, "backing up before %q", s.Version)
+	location, err := o.backup(o.ctx, s)
+	if err != nil {
+		return fmt.Errorf("migrator: backing up before %s: %w", s.Version, err)
+	}
+
+	if o.run.Backups == nil {
+		o.run.Backups = make(map[string]string)
+	}
+
+	o.run.Backups[s.Version] = location
+	o.logf(LevelInfo, "backed up %q to %s", s.Version, location)
+	return nil
+}
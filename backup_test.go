@@ -0,0 +1,101 @@
+package migrator
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWithBackup(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{
+		"20240101T000000Z": "users",
+		"20240102T000000Z": "posts",
+	})
+
+	var have []string
+	backup := func(ctx context.Context, s Step) (string, error) {
+		have = append(have, s.Version)
+		return "/backups/" + s.Version, nil
+	}
+
+	db := openTestDB(t)
+	o := newOptions([]Option{WithDialect(SQLite), WithBackup(backup)})
+	err := migrateRun(db, "", o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(have) != 0 {
+		t.Errorf("backed up %v before migrations without warnings, want none", have)
+	}
+
+	o = newOptions([]Option{WithDialect(SQLite), WithBackup(backup)})
+	err = migrateRun(db, "20240101T000000Z", o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{"20240102T000000Z": "/backups/20240102T000000Z"}
+	if !reflect.DeepEqual(o.run.Backups, want) {
+		t.Errorf("backups = %v, want %v", o.run.Backups, want)
+	}
+
+	failed := errors.New("disk full")
+	err = Migrate(db, NilVersion, WithDialect(SQLite), WithLogger(&testLogger{}), WithBackup(func(ctx context.Context, s Step) (string, error) {
+		return "", failed
+	}))
+	if err == nil || !strings.Contains(err.Error(), "backing up before 20240101T000000Z: disk full") {
+		t.Errorf("Migrate error = %v, want the backup error", err)
+	}
+
+	if !tableExists(t, db, "users") {
+		t.Error("migration reverted after its backup failed")
+	}
+}
+
+func TestPGDump(t *testing.T) {
+	bin := t.TempDir()
+	out := filepath.Join(t.TempDir(), "args")
+	script := "#!/bin/sh\necho \"$@\" > " + out + "\n"
+	err := os.WriteFile(filepath.Join(bin, "pg_dump"), []byte(script), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	dir := t.TempDir()
+	s := Step{
+		Version: "20240101T000000Z",
+		Name:    "drop_email",
+		Warnings: []Warning{
+			{Table: "users"},
+			{Table: "users"},
+			{Table: "orders"},
+		},
+	}
+
+	path, err := PGDump("postgres://localhost/app", dir)(context.Background(), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if filepath.Dir(path) != dir || !strings.HasSuffix(path, "_20240101T000000Z_drop_email.dump") {
+		t.Errorf("path = %q, want a dump of the step in %s", path, dir)
+	}
+
+	b, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "--format=custom --file=" + path + " --table=users --table=orders postgres://localhost/app\n"
+	if string(b) != want {
+		t.Errorf("pg_dump args = %q, want %q", b, want)
+	}
+}
@@ -0,0 +1,43 @@
+package migrator
+
+import "fmt"
+
+// A Builder executes the statements of a migration in order, remembering
+// the first error so that the statements read as a list:
+//
+//	b := migrator.Begin(tx)
+//	b.Exec(`ALTER TABLE accounts ADD COLUMN email TEXT;`)
+//	b.Exec(`UPDATE accounts SET email = $1;`, "")
+//	return b.Err()
+type Builder struct {
+	e   Execer
+	err error
+}
+
+// Begin returns a builder of the statements executed on the *sql.Tx or
+// *sql.Conn provided to a migration.
+func Begin(e Execer) *Builder {
+	return &Builder{e: e}
+}
+
+// Exec executes the statement with the arguments with Exec unless an
+// earlier statement failed, in which case it does nothing. It returns
+// the builder so that calls may be chained.
+func (b *Builder) Exec(query string, args ...interface{}) *Builder {
+	if b.err != nil {
+		return b
+	}
+
+	_, err := Exec(b.e, query, args...)
+	if err != nil {
+		b.err = fmt.Errorf("migrator: %q: %w", query, err)
+	}
+
+	return b
+}
+
+// Err returns the error of the first statement that failed, naming the
+// statement, or nil if every statement succeeded.
+func (b *Builder) Err() error {
+	return b.err
+}
@@ -0,0 +1,31 @@
+package migrator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBuilder(t *testing.T) {
+	db := openTestDB(t)
+	b := Begin(db)
+	b.Exec("CREATE TABLE users (id INTEGER);").
+		Exec("INSERT INTO users (id) VALUES (?);", 1)
+	if err := b.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	b = Begin(db)
+	b.Exec("INSERT INTO missing (id) VALUES (1);").
+		Exec("INSERT INTO users (id) VALUES (?);", 2)
+
+	err := b.Err()
+	if err == nil || !strings.HasPrefix(err.Error(), `migrator: "INSERT INTO missing (id) VALUES (1);": `) {
+		t.Errorf("Err = %v, want the first failed statement named", err)
+	}
+
+	var n int
+	err = db.QueryRow("SELECT COUNT(*) FROM users;").Scan(&n)
+	if err != nil || n != 1 {
+		t.Errorf("users has %d rows, %v, want statements after the error skipped", n, err)
+	}
+}
@@ -0,0 +1,75 @@
+package migrator
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// WithCanary performs the run on the canary database at the url, such as
+// a clone of the database in staging, before the database of the run,
+// and aborts the run unless the canary is migrated to the target within
+// the budget. A budget of zero does not limit the canary. The canary run
+// does not notify, confirm, run the preflight or the before and after
+// hooks, request approval or enter maintenance. See Open for the
+// supported URLs.
+func WithCanary(url string, budget time.Duration) Option {
+	return func(o *options) {
+		o.canary = url
+		o.canaryBudget = budget
+	}
+}
+
+// migrateCanary migrates the canary database of the run, if any, to the
+// target version timestamp within the budget of the canary.
+func (o *options) migrateCanary(target string) error {
+	if o.canary == "" {
+		return nil
+	}
+
+	db, d, err := Open(o.canary)
+	if err != nil {
+		return fmt.Errorf("migrator: canary: %w", err)
+	}
+
+	defer db.Close()
+
+	ctx, cancel := context.WithCancel(o.ctx)
+	if o.canaryBudget > 0 {
+		ctx, cancel = context.WithTimeout(o.ctx, o.canaryBudget)
+	}
+
+	defer cancel()
+
+	co := *o
+	co.canary = ""
+	co.shadow = ""
+	co.dialect = d
+	co.ctx = ctx
+	co.run = &Run{Target: target}
+	co.notifiers = nil
+	co.events = nil
+	co.confirm = nil
+	co.preflight = nil
+	co.approve = nil
+	co.backup = nil
+	co.recovery = false
+	co.enter, co.exit = nil, nil
+	co.before, co.after = nil, nil
+	co.primary = false
+	co.channel = ""
+
+	o.logf(LevelInfo, "migrating canary %s", redacted(o.canary))
+	began := o.now()
+	err = migrateDB(db, target, &co)
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("migrator: canary: not migrated within %s: %w", o.canaryBudget, err)
+	}
+
+	if err != nil {
+		return fmt.Errorf("migrator: canary: %w", err)
+	}
+
+	o.logf(LevelInfo, "canary migrated in %s", o.since(began).Round(time.Millisecond))
+	return nil
+}
@@ -0,0 +1,97 @@
+package migrator
+
+import (
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithCanary(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+
+	path := filepath.Join(t.TempDir(), "canary.db")
+	canary, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer canary.Close()
+
+	db := openTestDB(t)
+	err = Migrate(db, "", WithDialect(SQLite), WithCanary("sqlite://"+path, time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !tableExists(t, canary, "users") || !tableExists(t, db, "users") {
+		t.Error("canary or database not migrated")
+	}
+}
+
+func TestWithCanaryFailure(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+
+	path := filepath.Join(t.TempDir(), "canary.db")
+	canary, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer canary.Close()
+
+	_, err = canary.Exec("CREATE TABLE users (id INTEGER);")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	db := openTestDB(t)
+	err = Migrate(db, "", WithDialect(SQLite), WithCanary("sqlite://"+path, 0), WithLogger(&testLogger{}))
+	if err == nil || !strings.HasPrefix(err.Error(), "migrator: canary:") {
+		t.Errorf("Migrate error = %v, want the canary error", err)
+	}
+
+	if tableExists(t, db, "users") {
+		t.Error("database migrated after the canary failed")
+	}
+
+	err = Migrate(db, "", WithDialect(SQLite), WithCanary("sqlite://"+path, time.Nanosecond), WithLogger(&testLogger{}))
+	if err == nil || !strings.Contains(err.Error(), "not migrated within 1ns") {
+		t.Errorf("Migrate error = %v, want the canary budget exceeded", err)
+	}
+}
+
+func TestWithCanaryHooks(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+
+	path := filepath.Join(t.TempDir(), "canary.db")
+	calls := make(map[string]int)
+	count := func(name string) func(conn *sql.Conn) error {
+		return func(conn *sql.Conn) error {
+			calls[name]++
+			return nil
+		}
+	}
+
+	db := openTestDB(t)
+	err := Migrate(db, "", WithDialect(SQLite), WithCanary("sqlite://"+path, 0),
+		WithBeforeFunc(count("before")),
+		WithAfterFunc(count("after")),
+		WithConfirm(func([]Step) error {
+			calls["confirm"]++
+			return nil
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"before", "after", "confirm"} {
+		if calls[name] != 1 {
+			t.Errorf("%s called %d times, want once for the database but not the canary", name, calls[name])
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package migrator
+
+import "database/sql"
+
+// A State is the state of the database relative to the registered
+// migrations.
+type State int
+
+// States of the database relative to the registered migrations.
+const (
+	UpToDate State = iota // every registered migration is applied
+	Pending               // registered migrations remain to be applied
+	Diverged              // applied versions are not registered
+)
+
+// String returns the name of the state.
+func (s State) String() string {
+	switch s {
+	case UpToDate:
+		return "up to date"
+	case Pending:
+		return "pending"
+	case Diverged:
+		return "diverged"
+	}
+
+	return "unknown"
+}
+
+// Check returns the state of the database and the version timestamps
+// responsible for it, either the pending versions that migrating up to
+// the latest version would perform or the applied versions that are not
+// registered. Diverged takes precedence over pending.
+func Check(db *sql.DB, opts ...Option) (State, []string, error) {
+	o := newOptions(opts)
+
+	err := o.createVersions(db)
+	if err != nil {
+		return UpToDate, nil, err
+	}
+
+	pending, done, up, err := plan(db, "", o)
+	if err != nil {
+		return UpToDate, nil, err
+	}
+
+	var unknown []string
+	for _, v := range done {
+		if !o.inSet(v.version) {
+			unknown = append(unknown, v.version)
+		}
+	}
+
+	if len(unknown) > 0 {
+		return Diverged, unknown, nil
+	}
+
+	if up && len(pending) > 0 {
+		return Pending, pending, nil
+	}
+
+	return UpToDate, nil, nil
+}
+
+// Verify returns the applied migrations whose checksums recorded when
+// they were applied differ from the checksums of the registered
+// migrations, meaning a migration was edited after it was applied. Left
+// is the registered migration and Right is the applied migration. Only
+// SQL migrations applied with a recorded checksum are compared.
+func Verify(db *sql.DB, opts ...Option) ([]Difference, error) {
+	applied, err := Applied(db, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var rv []Difference
+	for _, d := range Compare(Registered(), applied) {
+		if d.Left != nil && d.Right != nil {
+			rv = append(rv, d)
+		}
+	}
+
+	return rv, nil
+}
@@ -0,0 +1,102 @@
+package migrator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{
+		"20240101T000000Z": "users",
+		"20240102T000000Z": "posts",
+	})
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+
+	err := Migrate(db, "20240101T000000Z", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, vs, err := Check(db, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if state != Pending || !reflect.DeepEqual(vs, []string{"20240102T000000Z"}) {
+		t.Errorf("Check = %s %q, want pending 20240102T000000Z", state, vs)
+	}
+
+	err = Migrate(db, "", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, vs, err = Check(db, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if state != UpToDate || vs != nil {
+		t.Errorf("Check = %s %q, want up to date", state, vs)
+	}
+
+	delete(migrations, "20240102T000000Z")
+	state, vs, err = Check(db, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if state != Diverged || !reflect.DeepEqual(vs, []string{"20240102T000000Z"}) {
+		t.Errorf("Check = %s %q, want diverged 20240102T000000Z", state, vs)
+	}
+}
+
+func TestStateString(t *testing.T) {
+	tests := []struct {
+		s    State
+		want string
+	}{
+		{UpToDate, "up to date"},
+		{Pending, "pending"},
+		{Diverged, "diverged"},
+		{State(-1), "unknown"},
+	}
+
+	for _, tt := range tests {
+		have := tt.s.String()
+		if have != tt.want {
+			t.Errorf("State(%d).String() = %q, want %q", int(tt.s), have, tt.want)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	isolate(t)
+	registerSQL("20240101T000000Z", "create_users", []string{"CREATE TABLE users (id INTEGER);"}, nil, false, nil)
+	Register("20240102T000000Z", "go", empty, empty)
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+	err := Migrate(db, "", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ds, err := Verify(db, sqlite)
+	if err != nil || len(ds) != 0 {
+		t.Fatalf("Verify of unedited migrations = %+v, %v", ds, err)
+	}
+
+	migrations["20240101T000000Z"].upSQL = []string{"CREATE TABLE users (id BIGINT);"}
+	ds, err = Verify(db, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ds) != 1 || ds[0].Version != "20240101T000000Z" || ds[0].Left.Checksum == ds[0].Right.Checksum {
+		t.Errorf("Verify = %+v, want the edited migration", ds)
+	}
+}
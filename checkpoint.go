@@ -0,0 +1,109 @@
+package migrator
+
+import (
+	"database/sql"
+)
+
+// queryCheckpointsNew creates the checkpoints table if not already created.
+var queryCheckpointsNew = `
+CREATE TABLE IF NOT EXISTS checkpoints (
+  name       TEXT PRIMARY KEY,
+  position   BIGINT NOT NULL,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+`
+
+// queryCheckpointsNewMySQL creates the checkpoints table in MySQL if not
+// already created.
+var queryCheckpointsNewMySQL = `
+CREATE TABLE IF NOT EXISTS checkpoints (
+  name       VARCHAR(255) PRIMARY KEY,
+  position   BIGINT NOT NULL,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+`
+
+// queriesCheckpointsNew are the queries that create the checkpoints table
+// in each dialect.
+var queriesCheckpointsNew = map[Dialect]string{
+	Postgres: queryCheckpointsNew,
+	MySQL:    queryCheckpointsNewMySQL,
+	SQLite:   queryCheckpointsNew,
+}
+
+// queryCheckpointsGet selects the position of a checkpoint by name.
+var queryCheckpointsGet = `
+SELECT position
+  FROM checkpoints
+  WHERE name = $1;
+`
+
+// queryCheckpointsSave inserts or updates the position of a checkpoint.
+// SQLite supports the upsert as of 3.24.0.
+var queryCheckpointsSave = `
+INSERT INTO checkpoints (name, position)
+  VALUES ($1, $2)
+  ON CONFLICT (name) DO UPDATE
+  SET position = EXCLUDED.position, updated_at = CURRENT_TIMESTAMP;
+`
+
+// queryCheckpointsSaveMySQL inserts or updates the position of a
+// checkpoint in MySQL.
+var queryCheckpointsSaveMySQL = `
+INSERT INTO checkpoints (name, position)
+  VALUES ($1, $2)
+  ON DUPLICATE KEY UPDATE
+  position = VALUES(position), updated_at = CURRENT_TIMESTAMP;
+`
+
+// queriesCheckpointsSave are the queries that save a checkpoint in each
+// dialect.
+var queriesCheckpointsSave = map[Dialect]string{
+	Postgres: queryCheckpointsSave,
+	MySQL:    queryCheckpointsSaveMySQL,
+	SQLite:   queryCheckpointsSave,
+}
+
+// queryCheckpointsDelete deletes the checkpoint by name.
+var queryCheckpointsDelete = `
+DELETE FROM checkpoints
+  WHERE name = $1;
+`
+
+// LoadCheckpoint returns the last saved position of the named checkpoint
+// and whether or not it exists. The checkpoints table is created if it
+// does not exist. Checkpoints require SQLite 3.24.0 or later.
+func LoadCheckpoint(conn Conn, d Dialect, name string) (int64, bool, error) {
+	ctx := optionsOf(conn).ctx
+	_, err := conn.ExecContext(ctx, queriesCheckpointsNew[d])
+	if err != nil {
+		return 0, false, err
+	}
+
+	var position int64
+	err = conn.QueryRowContext(ctx, d.rebind(queryCheckpointsGet), name).Scan(&position)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+
+	if err != nil {
+		return 0, false, err
+	}
+
+	return position, true, nil
+}
+
+// SaveCheckpoint records the position of the named checkpoint within the
+// transaction so that the checkpoint is committed with the work it
+// describes. LoadCheckpoint must have been called for the checkpoint to
+// ensure the checkpoints table exists.
+func SaveCheckpoint(tx *sql.Tx, d Dialect, name string, position int64) error {
+	_, err := tx.ExecContext(optionsOf(tx).ctx, d.rebind(queriesCheckpointsSave[d]), name, position)
+	return err
+}
+
+// ClearCheckpoint deletes the named checkpoint.
+func ClearCheckpoint(conn Conn, d Dialect, name string) error {
+	_, err := conn.ExecContext(optionsOf(conn).ctx, d.rebind(queryCheckpointsDelete), name)
+	return err
+}
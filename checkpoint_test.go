@@ -0,0 +1,56 @@
+package migrator
+
+import "testing"
+
+func TestCheckpoint(t *testing.T) {
+	db := openTestDB(t)
+
+	_, ok, err := LoadCheckpoint(db, SQLite, "backfill")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok {
+		t.Fatal("LoadCheckpoint found a checkpoint that was never saved")
+	}
+
+	for _, position := range []int64{10, 20} {
+		tx, err := db.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = SaveCheckpoint(tx, SQLite, "backfill", position)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, ok, err := LoadCheckpoint(db, SQLite, "backfill")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !ok || got != position {
+			t.Errorf("LoadCheckpoint = %d, %t, want %d, true", got, ok, position)
+		}
+	}
+
+	err = ClearCheckpoint(db, SQLite, "backfill")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, ok, err = LoadCheckpoint(db, SQLite, "backfill")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ok {
+		t.Error("LoadCheckpoint found a cleared checkpoint")
+	}
+}
@@ -0,0 +1,45 @@
+package migrator
+
+import "time"
+
+// A Clock reports the current time. Tests can provide a clock that
+// returns fixed or advancing times to run deterministically.
+type Clock interface {
+	Now() time.Time
+}
+
+// systemClock is the Clock of the system.
+type systemClock struct{}
+
+// Now returns the current local time.
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SystemClock is the Clock of the system and the default of a run.
+var SystemClock Clock = systemClock{}
+
+// WithClock sets the clock of the run, which times the run, its
+// migrations and the wait for its lock and records when versions are
+// applied.
+func WithClock(c Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// NewVersion returns the version timestamp of a new migration created
+// at the current time of the clock.
+func NewVersion(c Clock) string {
+	return c.Now().UTC().Format(VersionLayout)
+}
+
+// now returns the current time of the clock of the run.
+func (o *options) now() time.Time {
+	return o.clock.Now()
+}
+
+// since returns the time elapsed since t by the clock of the run.
+func (o *options) since(t time.Time) time.Duration {
+	return o.now().Sub(t)
+}
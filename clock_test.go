@@ -0,0 +1,52 @@
+package migrator
+
+import (
+	"testing"
+	"time"
+)
+
+// fixedClock is a Clock that always returns the same time.
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+func TestNewVersion(t *testing.T) {
+	loc := time.FixedZone("EST", -5*60*60)
+	c := fixedClock(time.Date(2024, 1, 1, 19, 30, 0, 0, loc))
+	have := NewVersion(c)
+	if have != "20240102T003000Z" {
+		t.Errorf("NewVersion = %q, want 20240102T003000Z", have)
+	}
+}
+
+func TestWithClock(t *testing.T) {
+	isolate(t)
+	Register("20240101T000000Z", "a", empty, empty)
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	opts := []Option{WithDialect(SQLite), WithClock(fixedClock(now))}
+
+	db := openTestDB(t)
+	err := Migrate(db, "", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := LastRun()
+	if !r.Started.Equal(now) || !r.Finished.Equal(now) {
+		t.Errorf("run %s to %s, want both at %s", r.Started, r.Finished, now)
+	}
+
+	infos, err := Applied(db, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, info := range infos {
+		if info.Version != NilVersion && (info.AppliedAt == nil || !info.AppliedAt.Equal(now)) {
+			t.Errorf("%s applied at %v, want %s", info.Version, info.AppliedAt, now)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pnelson/migrator"
+)
+
+// archive moves the versions applied before a version to the archive
+// table, appending them to the file of the -o flag as JSON lines if set.
+func archive(e *env, args []string) error {
+	fs := flag.NewFlagSet("archive", flag.ContinueOnError)
+	out := fs.String("o", "", "also append the archived versions to the file as JSON lines")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("archive requires the version before which to archive")
+	}
+
+	var f *os.File
+	var w io.Writer
+	if *out != "" {
+		f, err = os.OpenFile(*out, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+		w = f
+	}
+
+	n, err := migrator.Archive(e.db, fs.Arg(0), w, e.opts...)
+	if err != nil {
+		return err
+	}
+
+	// The deferred Close releases the file if archiving fails. Closing it
+	// here reports whether the archived versions were written.
+	if f != nil {
+		err = f.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("archived %d versions\n", n)
+	return nil
+}
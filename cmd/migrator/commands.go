@@ -0,0 +1,493 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/pnelson/migrator"
+	"gopkg.in/yaml.v3"
+)
+
+// phases are the phases of a deployment by name.
+var phases = map[string]migrator.Phase{
+	"pre":  migrator.PhasePre,
+	"post": migrator.PhasePost,
+}
+
+// up migrates up to the target version or the latest version, or the
+// migrations of the phase of the -phase flag, one of pre or post.
+func up(e *env, args []string) error {
+	fs := flag.NewFlagSet("up", flag.ContinueOnError)
+	phase := fs.String("phase", "", "run only the migrations of the deployment phase: pre or post")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	opts := e.opts
+	if *phase != "" {
+		p, ok := phases[*phase]
+		if !ok {
+			return fmt.Errorf("unknown phase %q", *phase)
+		}
+
+		opts = append(opts[:len(opts):len(opts)], migrator.WithPhase(p))
+	}
+
+	return migrator.Migrate(e.db, fs.Arg(0), opts...)
+}
+
+// down reverts the most recently applied n migrations.
+func down(e *env, args []string) error {
+	n := 1
+	if len(args) > 0 {
+		var err error
+		n, err = strconv.Atoi(args[0])
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid number of migrations %q", args[0])
+		}
+	}
+
+	return migrator.Rollback(e.db, n, e.opts...)
+}
+
+// redo reverts and reapplies the most recently applied migration.
+func redo(e *env, args []string) error {
+	current, err := migrator.Current(e.db, e.opts...)
+	if err != nil {
+		return err
+	}
+
+	if current == "" || current == migrator.NilVersion {
+		return fmt.Errorf("no migration to redo")
+	}
+
+	err = migrator.Rollback(e.db, 1, e.opts...)
+	if err != nil {
+		return err
+	}
+
+	return migrator.Migrate(e.db, current, e.opts...)
+}
+
+// status prints the migrations and whether they are applied in the
+// format of the -format flag, one of table, json or yaml.
+func status(e *env, args []string) error {
+	fs := flag.NewFlagSet("status", flag.ContinueOnError)
+	format := fs.String("format", "table", "output format: table, json or yaml")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	infos, err := migrator.Inspect(e.db, e.opts...)
+	if err != nil {
+		return err
+	}
+
+	switch *format {
+	case "table":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tPHASE\tSTATUS\tAPPLIED AT")
+		for _, info := range infos {
+			state, at := "pending", ""
+			if info.Applied {
+				state, at = "applied", info.AppliedAt.Format(time.RFC3339)
+				if info.SkipReason != "" {
+					state = "skipped: " + info.SkipReason
+				}
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", info.Version, info.Name, info.Phase, state, at)
+		}
+		return w.Flush()
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(infos)
+	case "yaml":
+		enc := yaml.NewEncoder(os.Stdout)
+		enc.SetIndent(2)
+		err := enc.Encode(infos)
+		if err != nil {
+			return err
+		}
+		return enc.Close()
+	}
+
+	return fmt.Errorf("unknown format %q", *format)
+}
+
+// version prints the most recently applied version.
+func version(e *env, args []string) error {
+	current, err := migrator.Current(e.db, e.opts...)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(current)
+	return nil
+}
+
+// history prints every recorded migration in the order applied and when
+// it was reverted, if it was.
+func history(e *env, args []string) error {
+	infos, err := migrator.History(e.db, e.opts...)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT\tREVERTED AT")
+	for _, info := range infos {
+		reverted := ""
+		if info.RevertedAt != nil {
+			reverted = info.RevertedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", info.Version, info.Name, info.AppliedAt.Format(time.RFC3339), reverted)
+	}
+
+	return w.Flush()
+}
+
+// plan prints the migrations that would run to migrate to the target
+// version or the latest version, their direction and their SQL.
+func plan(e *env, args []string) error {
+	target := ""
+	if len(args) > 0 {
+		target = args[0]
+	}
+
+	steps, err := migrator.Plan(e.db, target, e.opts...)
+	if err != nil {
+		return err
+	}
+
+	if len(steps) == 0 {
+		fmt.Println("nothing to migrate")
+		return nil
+	}
+
+	for _, s := range steps {
+		direction := "up"
+		if !s.Up {
+			direction = "down"
+		}
+
+		fmt.Printf("-- %s %s %s\n", direction, s.Version, s.Name)
+		for _, w := range s.Warnings {
+			fmt.Printf("-- warning: %s\n", w.Message)
+		}
+		for _, stmt := range s.Statements {
+			fmt.Println(terminated(stmt))
+		}
+		fmt.Println()
+	}
+
+	return nil
+}
+
+// check exits with a code describing the state of the database: 0 if
+// up to date, 3 if migrations are pending or 4 if applied versions are
+// not registered.
+func check(e *env, args []string) error {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	path := fs.String("schema", "", "also compare the schema to the snapshot in the file")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	state, vs, err := migrator.Check(e.db, e.opts...)
+	if err != nil {
+		return err
+	}
+
+	switch state {
+	case migrator.Pending:
+		return &exitError{code: 3, err: fmt.Errorf("pending migrations: %s", strings.Join(vs, ", "))}
+	case migrator.Diverged:
+		return &exitError{code: 4, err: fmt.Errorf("unknown applied versions: %s", strings.Join(vs, ", "))}
+	}
+
+	if *path != "" {
+		expected, err := readSchema(*path)
+		if err != nil {
+			return err
+		}
+
+		opts := append(append([]migrator.Option{}, e.opts...), migrator.WithExpectedSchema(expected))
+		diff, err := migrator.CheckDrift(e.db, opts...)
+		if err != nil {
+			return err
+		}
+
+		if len(diff) > 0 {
+			return &exitError{code: 4, err: fmt.Errorf("schema drift:\n\t%s", strings.Join(diff, "\n\t"))}
+		}
+	}
+
+	fmt.Println(state)
+	return nil
+}
+
+// verify compares the checksums recorded when migrations were applied to
+// the checksums of the migration files and fails if any differ.
+func verify(e *env, args []string) error {
+	diffs, err := migrator.Verify(e.db, e.opts...)
+	if err != nil {
+		return err
+	}
+
+	if len(diffs) == 0 {
+		fmt.Println("ok")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, d := range diffs {
+		fmt.Fprintf(w, "%s\t%s\tapplied %.12s\tfile %.12s\n", d.Version, d.Name, d.Right.Checksum, d.Left.Checksum)
+	}
+
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+
+	return &exitError{code: 4, err: fmt.Errorf("%d applied migrations were edited", len(diffs))}
+}
+
+// replay migrates a temporary database on the server at the url to the
+// version of the database and fails if the schemas differ.
+func replay(e *env, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("replay requires the url of a scratch database server")
+	}
+
+	diff, err := migrator.Replay(e.db, args[0], e.opts...)
+	if err != nil {
+		return err
+	}
+
+	if len(diff) > 0 {
+		return &exitError{code: 4, err: fmt.Errorf("schema differs from the replayed history:\n\t%s", strings.Join(diff, "\n\t"))}
+	}
+
+	fmt.Println("ok")
+	return nil
+}
+
+// dryrun migrates a schema-only copy of the database on the scratch
+// server of the url to the target version or the latest version and
+// prints the versions performed.
+func dryrun(e *env, args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return fmt.Errorf("dryrun requires the url of a scratch database server")
+	}
+
+	target := ""
+	if len(args) == 2 {
+		target = args[1]
+	}
+
+	run, err := migrator.DryRun(e.db, args[0], target, e.opts...)
+	if run != nil {
+		for _, v := range run.Performed {
+			fmt.Println(v)
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("ok")
+	return nil
+}
+
+// lint prints the migrations whose down migrations do nothing, are the
+// same as their up migrations or are marked TODO and fails if there are
+// any.
+func lint(e *env, args []string) error {
+	err := migrator.LoadDir(e.dir)
+	if err != nil {
+		return err
+	}
+
+	findings := migrator.Lint()
+	for _, f := range findings {
+		fmt.Println(f)
+	}
+
+	if len(findings) > 0 {
+		return fmt.Errorf("%d migrations are not reversible; mark deliberate ones with -- migrator:irreversible", len(findings))
+	}
+
+	return nil
+}
+
+// snapshot prints the schema of the database as JSON to be compared by
+// check -schema.
+func snapshot(e *env, args []string) error {
+	s, err := migrator.Snapshot(e.db, e.opts...)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
+
+// readSchema reads the schema snapshot of the file.
+func readSchema(path string) (*migrator.Schema, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	var s migrator.Schema
+	err = json.NewDecoder(f).Decode(&s)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return &s, nil
+}
+
+// name matches a valid migration name.
+var name = regexp.MustCompile(`^\w+$`)
+
+// goTemplate is the default template of Go migration files.
+var goTemplate = `package {{.Package}}
+
+import (
+	"database/sql"
+
+	"github.com/pnelson/migrator"
+)
+
+func Up_{{.Version}}(tx *sql.Tx) error {
+	_, err := tx.Exec(` + "``" + `)
+	return err
+}
+
+func Down_{{.Version}}(tx *sql.Tx) error {
+	_, err := tx.Exec(` + "``" + `)
+	return err
+}
+
+func init() {
+	migrator.Register("{{.Version}}", "{{.Name}}",
+		Up_{{.Version}}, Down_{{.Version}})
+}
+`
+
+// A scaffold is the data of a Go migration file template.
+type scaffold struct {
+	Package string // package name, the base name of the directory by default
+	Version string // version timestamp of the migration
+	Name    string // name of the migration
+}
+
+// create creates a pair of empty migration files, or a Go migration file
+// from a template with -type=go.
+func create(e *env, args []string) error {
+	fs := flag.NewFlagSet("create", flag.ContinueOnError)
+	typ := fs.String("type", "sql", "type of migration: sql or go")
+	tmpl := fs.String("template", "", "text/template file of Go migrations")
+	pkg := fs.String("package", "", "package of Go migrations (default base name of -dir)")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	args = fs.Args()
+	if len(args) != 1 || !name.MatchString(args[0]) {
+		return fmt.Errorf("create requires a name of letters, digits and underscores")
+	}
+
+	err = os.MkdirAll(e.dir, 0755)
+	if err != nil {
+		return err
+	}
+
+	v := migrator.NewVersion(migrator.SystemClock)
+	switch *typ {
+	case "sql":
+		for _, direction := range []string{"up", "down"} {
+			path := filepath.Join(e.dir, fmt.Sprintf("%s_%s.%s.sql", v, args[0], direction))
+			err := write(path, nil)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case "go":
+		text := goTemplate
+		if *tmpl != "" {
+			b, err := os.ReadFile(*tmpl)
+			if err != nil {
+				return err
+			}
+			text = string(b)
+		}
+
+		t, err := template.New("migration").Parse(text)
+		if err != nil {
+			return err
+		}
+
+		data := scaffold{Package: *pkg, Version: v, Name: args[0]}
+		if data.Package == "" {
+			abs, err := filepath.Abs(e.dir)
+			if err != nil {
+				return err
+			}
+			data.Package = strings.ReplaceAll(filepath.Base(abs), "-", "_")
+		}
+
+		var buf strings.Builder
+		err = t.Execute(&buf, data)
+		if err != nil {
+			return err
+		}
+
+		return write(filepath.Join(e.dir, fmt.Sprintf("%s_%s.go", v, args[0])), []byte(buf.String()))
+	}
+
+	return fmt.Errorf("unknown type %q", *typ)
+}
+
+// write creates the file with the contents, failing if it exists, and
+// prints its path.
+func write(path string, b []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(b)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(path)
+	return nil
+}
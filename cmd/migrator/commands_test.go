@@ -0,0 +1,399 @@
+package main
+
+import (
+	"errors"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pnelson/migrator"
+)
+
+func TestCreate(t *testing.T) {
+	e := &env{dir: filepath.Join(t.TempDir(), "migrations")}
+
+	for _, args := range [][]string{nil, {"add users"}, {"a", "b"}} {
+		err := create(e, args)
+		if err == nil {
+			t.Errorf("create(%q) error = nil", args)
+		}
+	}
+
+	captureStdout(t, func() error { return create(e, []string{"add_users"}) })
+
+	entries, err := os.ReadDir(e.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	file := regexp.MustCompile(`^\d{8}T\d{6}Z_add_users\.(up|down)\.sql$`)
+	if len(entries) != 2 {
+		t.Fatalf("%d files created, want 2", len(entries))
+	}
+
+	for _, entry := range entries {
+		if !file.MatchString(entry.Name()) {
+			t.Errorf("created %q", entry.Name())
+		}
+	}
+}
+
+func TestDownInvalid(t *testing.T) {
+	for _, arg := range []string{"0", "-1", "one"} {
+		err := down(&env{}, []string{arg})
+		if err == nil {
+			t.Errorf("down(%q) error = nil", arg)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	for _, cmd := range commands {
+		if lookup(cmd.name) != cmd {
+			t.Errorf("lookup(%q) did not return the command", cmd.name)
+		}
+	}
+
+	if lookup("missing") != nil {
+		t.Error("lookup of an unknown command returned a command")
+	}
+}
+
+// testEnv returns an environment with a SQLite database in a temporary
+// directory that is closed when the test completes.
+func testEnv(t *testing.T) *env {
+	t.Helper()
+
+	dsn := "sqlite://" + filepath.Join(t.TempDir(), "test.db")
+	db, d, err := migrator.Open(dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	e := &env{dsn: dsn, dir: t.TempDir(), db: db, opts: []migrator.Option{migrator.WithDialect(d)}}
+	_, err = migrator.Current(e.db, e.opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return e
+}
+
+func TestStatusFormat(t *testing.T) {
+	e := testEnv(t)
+
+	stdout := os.Stdout
+	os.Stdout, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	defer func() { os.Stdout = stdout }()
+
+	for _, format := range []string{"table", "json", "yaml"} {
+		err := status(e, []string{"-format", format})
+		if err != nil {
+			t.Errorf("status -format %s: %v", format, err)
+		}
+	}
+
+	err := status(e, []string{"-format", "xml"})
+	if err == nil {
+		t.Error("status -format xml error = nil")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	e := testEnv(t)
+
+	stdout := os.Stdout
+	os.Stdout, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	defer func() { os.Stdout = stdout }()
+
+	err := check(e, nil)
+	if err, ok := err.(*exitError); !ok || err.code != 3 {
+		t.Fatalf("check before migrating = %v, want exit code 3", err)
+	}
+
+	err = migrator.Migrate(e.db, "", e.opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = check(e, nil)
+	if err != nil {
+		t.Errorf("check after migrating = %v, want up to date", err)
+	}
+}
+
+func TestInterrupted(t *testing.T) {
+	e := testEnv(t)
+
+	err := e.interrupted(errors.New("context canceled"))
+	if err, ok := err.(*exitError); !ok || err.code != 130 || !strings.Contains(err.Error(), "database is at version") {
+		t.Errorf("interrupted = %v, want exit code 130 reporting the version", err)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	e := testEnv(t)
+
+	out := captureStdout(t, func() error { return plan(e, nil) })
+	if out != "-- up 00010101T000000Z nil\n\n" {
+		t.Errorf("plan = %q, want the nil migration up", out)
+	}
+
+	err := migrator.Migrate(e.db, "", e.opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out = captureStdout(t, func() error { return plan(e, nil) })
+	if out != "nothing to migrate\n" {
+		t.Errorf("plan after migrating = %q, want nothing to migrate", out)
+	}
+}
+
+func TestCreateGo(t *testing.T) {
+	e := &env{dir: filepath.Join(t.TempDir(), "db-migrations")}
+
+	out := captureStdout(t, func() error { return create(e, []string{"-type=go", "add_users"}) })
+	path := strings.TrimSpace(out)
+	if !regexp.MustCompile(`^\d{8}T\d{6}Z_add_users\.go$`).MatchString(filepath.Base(path)) {
+		t.Fatalf("created %q", path)
+	}
+
+	f, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if f.Name.Name != "db_migrations" {
+		t.Errorf("package %q, want db_migrations", f.Name.Name)
+	}
+
+	tmpl := filepath.Join(t.TempDir(), "migration.tmpl")
+	err = os.WriteFile(tmpl, []byte("package {{.Package}} // {{.Name}}\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out = captureStdout(t, func() error {
+		return create(e, []string{"-type=go", "-template", tmpl, "-package", "schema", "add_posts"})
+	})
+	b, err := os.ReadFile(strings.TrimSpace(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(b) != "package schema // add_posts\n" {
+		t.Errorf("created %q from the template", b)
+	}
+
+	err = create(e, []string{"-type=rb", "add_tags"})
+	if err == nil {
+		t.Error("create -type=rb error = nil")
+	}
+}
+
+func TestCheckSchema(t *testing.T) {
+	e := testEnv(t)
+	err := migrator.Migrate(e.db, "", e.opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = e.db.Exec("CREATE TABLE accounts (id INTEGER);")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "schema.json")
+	out := captureStdout(t, func() error { return snapshot(e, nil) })
+	err = os.WriteFile(path, []byte(out), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out = captureStdout(t, func() error { return check(e, []string{"-schema", path}) })
+	if out != "up to date\n" {
+		t.Errorf("check -schema = %q, want up to date", out)
+	}
+
+	_, err = e.db.Exec("DROP TABLE accounts;")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	captureStdout(t, func() error {
+		err := check(e, []string{"-schema", path})
+		if err, ok := err.(*exitError); !ok || err.code != 4 || !strings.Contains(err.Error(), "table accounts removed") {
+			t.Errorf("check -schema after the drop = %v, want exit code 4", err)
+		}
+		return nil
+	})
+}
+
+func TestVerify(t *testing.T) {
+	e := testEnv(t)
+	err := migrator.Migrate(e.db, "", e.opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := captureStdout(t, func() error { return verify(e, nil) })
+	if out != "ok\n" {
+		t.Errorf("verify = %q, want ok", out)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	e := testEnv(t)
+	err := migrator.Migrate(e.db, "", e.opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := captureStdout(t, func() error { return replay(e, []string{"sqlite://replay"}) })
+	if out != "ok\n" {
+		t.Errorf("replay = %q, want ok", out)
+	}
+
+	_, err = e.db.Exec("CREATE TABLE hotfix (id INTEGER);")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = replay(e, []string{"sqlite://replay"})
+	if err, ok := err.(*exitError); !ok || err.code != 4 || !strings.Contains(err.Error(), "table hotfix added") {
+		t.Errorf("replay after the change = %v, want exit code 4", err)
+	}
+
+	err = replay(e, nil)
+	if err == nil {
+		t.Error("replay without a url error = nil")
+	}
+}
+
+func TestUpPhase(t *testing.T) {
+	e := testEnv(t)
+	err := up(e, []string{"-phase", "post"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, _, err := migrator.Check(e.db, e.opts...)
+	if err != nil || state != migrator.Pending {
+		t.Errorf("after the post phase = %s, %v, want the pre-deploy migrations pending", state, err)
+	}
+
+	err = up(e, []string{"-phase", "during"})
+	if err == nil {
+		t.Error("up -phase during error = nil")
+	}
+}
+
+func TestArchive(t *testing.T) {
+	e := testEnv(t)
+	err := migrator.Migrate(e.db, "", e.opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = e.db.Exec("INSERT INTO versions (version, name) VALUES ('20240101T000000Z', 'hotfix');")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "archive.jsonl")
+	out := captureStdout(t, func() error { return archive(e, []string{"-o", path, "20240101T000000Z"}) })
+	if out != "archived 1 versions\n" {
+		t.Errorf("archive = %q, want 1 archived", out)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(b), `"version":"00010101T000000Z"`) {
+		t.Errorf("archive file = %q, want the nil version", b)
+	}
+
+	err = archive(e, nil)
+	if err == nil {
+		t.Error("archive without a version error = nil")
+	}
+}
+
+func TestHistory(t *testing.T) {
+	e := testEnv(t)
+	e.opts = append(e.opts, migrator.WithSoftDelete())
+	err := migrator.Migrate(e.db, "", e.opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = e.db.Exec("INSERT INTO versions (version, name, reverted_at) VALUES ('20240101T000000Z', 'hotfix', '2024-02-01 00:00:00');")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := captureStdout(t, func() error { return history(e, nil) })
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "VERSION") || !strings.Contains(lines[2], "2024-02-01T00:00:00Z") {
+		t.Errorf("history = %q, want the reverted migration", out)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	e := testEnv(t)
+	err := migrator.Migrate(e.db, "", e.opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := captureStdout(t, func() error { return export(e, nil) })
+	if !strings.Contains(out, `"version": "00010101T000000Z"`) {
+		t.Errorf("export = %q, want the nil version", out)
+	}
+
+	path := filepath.Join(t.TempDir(), "versions.json")
+	err = os.WriteFile(path, []byte(out), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := testEnv(t)
+	other.yes = true
+	err = importVersions(other, []string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := migrator.Current(other.db, other.opts...)
+	if err != nil || v != migrator.NilVersion {
+		t.Errorf("imported version = %q, %v, want the nil version", v, err)
+	}
+
+	err = importVersions(other, nil)
+	if err == nil {
+		t.Error("import without a file error = nil")
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	e := testEnv(t)
+	out := captureStdout(t, func() error { return dryrun(e, []string{"sqlite://dryrun"}) })
+	if !strings.HasSuffix(out, "ok\n") {
+		t.Errorf("dryrun = %q, want ok", out)
+	}
+
+	err := dryrun(e, nil)
+	if err == nil {
+		t.Error("dryrun without a url error = nil")
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/pnelson/migrator"
+)
+
+// completions are the completion scripts by shell. Each script completes
+// the commands and flags of the program and the version timestamps of
+// the migrations in the directory, listed by the versions command with
+// the flags preceding the command, such as -dir.
+var completions = map[string]string{
+	"bash": `_migrator() {
+  local cur prev words cword
+  _init_completion || return
+  local cmd i
+  for ((i = 1; i < cword; i++)); do
+    case "${words[i]}" in
+      -*) ;;
+      *) cmd="${words[i]}"; break ;;
+    esac
+  done
+  if [[ -z "$cmd" ]]; then
+    if [[ "$cur" == -* ]]; then
+      COMPREPLY=($(compgen -W "%[2]s" -- "$cur"))
+    else
+      COMPREPLY=($(compgen -W "%[1]s" -- "$cur"))
+    fi
+    return
+  fi
+  case "$cmd" in
+    up|plan|job)
+      COMPREPLY=($(compgen -W "$(migrator "${words[@]:1:i-1}" versions 2>/dev/null | cut -f1)" -- "$cur")) ;;
+    completion)
+      COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur")) ;;
+  esac
+}
+complete -F _migrator migrator
+`,
+	"zsh": `#compdef migrator
+
+_migrator() {
+  local -a cmds versions
+  local i cmd
+  cmds=(%[1]s)
+  for ((i = 2; i < CURRENT; i++)); do
+    case ${words[i]} in
+      -*) ;;
+      *) cmd=${words[i]}; break ;;
+    esac
+  done
+  if [[ -z $cmd ]]; then
+    _describe 'command' cmds
+    return
+  fi
+  case $cmd in
+    up|plan|job)
+      versions=(${(f)"$(migrator ${words[2,i-1]} versions 2>/dev/null | tr '\t' ':')"})
+      _describe 'version' versions ;;
+    completion)
+      _values 'shell' bash zsh fish ;;
+  esac
+}
+
+compdef _migrator migrator
+`,
+	"fish": `function __migrator_versions
+  set -l flags
+  for arg in (commandline -opc)[2..-1]
+    switch $arg
+      case '-*'
+        set -a flags $arg
+      case '*'
+        break
+    end
+  end
+  migrator $flags versions 2>/dev/null
+end
+
+complete -c migrator -f
+complete -c migrator -n __fish_use_subcommand -a "%[1]s"
+complete -c migrator -n "__fish_seen_subcommand_from up plan job" -a "(__migrator_versions)"
+complete -c migrator -n "__fish_seen_subcommand_from completion" -a "bash zsh fish"
+`,
+}
+
+// completion prints the completion script of the shell.
+func completion(e *env, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("completion requires a shell: bash, zsh or fish")
+	}
+
+	script, ok := completions[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown shell %q", args[0])
+	}
+
+	var names, flags []string
+	for _, cmd := range commands {
+		names = append(names, cmd.name)
+	}
+
+	flag.VisitAll(func(f *flag.Flag) {
+		flags = append(flags, "-"+f.Name)
+	})
+
+	fmt.Printf(script, strings.Join(names, " "), strings.Join(flags, " "))
+	return nil
+}
+
+// versions prints the version timestamps and names of the migrations in
+// the directory, separated by a tab, for completion.
+func versions(e *env, args []string) error {
+	err := migrator.LoadDir(e.dir)
+	if err != nil {
+		return err
+	}
+
+	for _, info := range migrator.Registered() {
+		fmt.Printf("%s\t%s\n", info.Version, info.Name)
+	}
+
+	return nil
+}
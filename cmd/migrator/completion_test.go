@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// captureStdout returns what fn prints to standard output.
+func captureStdout(t *testing.T, fn func() error) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "stdout")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stdout := os.Stdout
+	os.Stdout = f
+	err = fn()
+	os.Stdout = stdout
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(b)
+}
+
+func TestCompletion(t *testing.T) {
+	for _, args := range [][]string{nil, {"powershell"}, {"bash", "zsh"}} {
+		err := completion(&env{}, args)
+		if err == nil {
+			t.Errorf("completion(%q) error = nil", args)
+		}
+	}
+
+	for shell := range completions {
+		out := captureStdout(t, func() error { return completion(&env{}, []string{shell}) })
+		for _, cmd := range commands {
+			if !strings.Contains(out, cmd.name) {
+				t.Errorf("%s completion missing %q", shell, cmd.name)
+			}
+		}
+	}
+
+	out := captureStdout(t, func() error { return completion(&env{}, []string{"bash"}) })
+	if !strings.Contains(out, "-test.run") {
+		t.Error("bash completion is missing the registered flags")
+	}
+
+	// The versions are listed with the flags preceding the command, so
+	// that they are those of the directory given by -dir.
+	for shell, want := range map[string]string{
+		"bash": `migrator "${words[@]:1:i-1}" versions`,
+		"zsh":  `migrator ${words[2,i-1]} versions`,
+		"fish": `migrator $flags versions`,
+	} {
+		out := captureStdout(t, func() error { return completion(&env{}, []string{shell}) })
+		if !strings.Contains(out, want) {
+			t.Errorf("%s completion lists the versions without the flags, want %q", shell, want)
+		}
+	}
+}
+
+func TestVersions(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"20240101T000000Z_add_users.up.sql", "20240101T000000Z_add_users.down.sql"} {
+		err := os.WriteFile(filepath.Join(dir, name), []byte("SELECT 1;\n"), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out := captureStdout(t, func() error { return versions(&env{dir: dir}, nil) })
+	if !strings.Contains(out, "20240101T000000Z\tadd_users\n") {
+		t.Errorf("versions = %q, want the migration in the directory", out)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/pnelson/migrator"
+	"gopkg.in/yaml.v3"
+)
+
+// configFiles are the names of the configuration files searched for in
+// the working directory when the -config flag is not provided.
+var configFiles = []string{"migrator.yaml", "migrator.yml", "migrator.toml"}
+
+// A config is the configuration file of the program.
+type config struct {
+	Environments map[string]*profile `yaml:"environments" toml:"environments"`
+}
+
+// A profile is the configuration of a named environment.
+type profile struct {
+	DSN     string `yaml:"dsn" toml:"dsn"`
+	Dir     string `yaml:"dir" toml:"dir"`
+	Table   string `yaml:"table" toml:"table"`
+	Dialect string `yaml:"dialect" toml:"dialect"`
+}
+
+// readConfig reads the configuration file at path, or the first of the
+// configuration files found in the working directory if path is empty.
+// A nil config is returned if path is empty and no file is found.
+func readConfig(path string) (*config, error) {
+	if path == "" {
+		for _, name := range configFiles {
+			_, err := os.Stat(name)
+			if err == nil {
+				path = name
+				break
+			}
+		}
+
+		if path == "" {
+			return nil, nil
+		}
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &config{}
+	switch filepath.Ext(path) {
+	case ".toml":
+		err = toml.Unmarshal(b, c)
+	default:
+		err = yaml.Unmarshal(b, c)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return c, nil
+}
+
+// configure applies the profile of the named environment to the
+// environment of the command, except for the values set by flags. The
+// DSN of the profile may refer to environment variables, such as
+// ${DB_PASSWORD}, to keep secrets out of the file.
+func (e *env) configure(c *config, name string, set map[string]bool) error {
+	p, ok := c.Environments[name]
+	if !ok {
+		return fmt.Errorf("unknown environment %q", name)
+	}
+
+	if !set["dsn"] && p.DSN != "" {
+		e.dsn = os.ExpandEnv(p.DSN)
+	}
+
+	if !set["dir"] && p.Dir != "" {
+		e.dir = p.Dir
+	}
+
+	if !set["table"] && p.Table != "" {
+		e.table = p.Table
+	}
+
+	if !set["dialect"] && p.Dialect != "" {
+		e.dialect = p.Dialect
+	}
+
+	return nil
+}
+
+// dialects are the dialects by name.
+var dialects = map[string]migrator.Dialect{
+	"postgres": migrator.Postgres,
+	"mysql":    migrator.MySQL,
+	"sqlite":   migrator.SQLite,
+}
+
+// options returns the migrator options of the environment. The dialect
+// is omitted unless set, leaving it to be detected from the database url.
+func (e *env) options() ([]migrator.Option, error) {
+	opts := []migrator.Option{migrator.WithTable(e.table)}
+	if e.dialect == "" {
+		return opts, nil
+	}
+
+	d, ok := dialects[strings.ToLower(e.dialect)]
+	if !ok {
+		return nil, fmt.Errorf("unknown dialect %q", e.dialect)
+	}
+
+	return append(opts, migrator.WithDialect(d)), nil
+}
+
+// kind returns the dialect of the environment, which is set explicitly
+// or detected from the database url once connected.
+func (e *env) kind() migrator.Dialect {
+	return dialects[strings.ToLower(e.dialect)]
+}
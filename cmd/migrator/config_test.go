@@ -0,0 +1,96 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadConfig(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"migrator.yaml": "environments:\n  production:\n    dsn: postgres://db/app\n    dir: db/migrations\n    table: schema_versions\n    dialect: postgres\n",
+		"migrator.toml": "[environments.production]\ndsn = \"postgres://db/app\"\ndir = \"db/migrations\"\ntable = \"schema_versions\"\ndialect = \"postgres\"\n",
+	}
+
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		err := os.WriteFile(path, []byte(data), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c, err := readConfig(path)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		want := profile{DSN: "postgres://db/app", Dir: "db/migrations", Table: "schema_versions", Dialect: "postgres"}
+		p := c.Environments["production"]
+		if p == nil || *p != want {
+			t.Errorf("%s: production = %+v, want %+v", name, p, want)
+		}
+	}
+}
+
+func TestReadConfigMissing(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = os.Chdir(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer os.Chdir(wd)
+
+	c, err := readConfig("")
+	if c != nil || err != nil {
+		t.Errorf("readConfig without a file = %v, %v, want nil", c, err)
+	}
+
+	_, err = readConfig("missing.yaml")
+	if err == nil {
+		t.Error("readConfig of a missing path error = nil")
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	t.Setenv("DB_PASSWORD", "secret")
+
+	c := &config{Environments: map[string]*profile{
+		"production": {DSN: "postgres://app:${DB_PASSWORD}@db/app", Dir: "db/migrations", Table: "schema_versions", Dialect: "mysql"},
+	}}
+
+	e := &env{dsn: "flag", dir: "migrations", table: "versions", dialect: "postgres"}
+	err := e.configure(c, "production", map[string]bool{"dir": true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := env{dsn: "postgres://app:secret@db/app", dir: "migrations", table: "schema_versions", dialect: "mysql"}
+	if e.dsn != want.dsn || e.dir != want.dir || e.table != want.table || e.dialect != want.dialect {
+		t.Errorf("env = %+v, want %+v with the flag kept", e, want)
+	}
+
+	err = e.configure(c, "staging", nil)
+	if err == nil {
+		t.Error("configure of an unknown environment error = nil")
+	}
+}
+
+func TestOptions(t *testing.T) {
+	for _, dialect := range []string{"postgres", "MySQL", "sqlite"} {
+		_, err := (&env{dialect: dialect}).options()
+		if err != nil {
+			t.Errorf("options(%q): %v", dialect, err)
+		}
+	}
+
+	_, err := (&env{dialect: "oracle"}).options()
+	if err == nil {
+		t.Error("options of an unknown dialect error = nil")
+	}
+}
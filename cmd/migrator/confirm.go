@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pnelson/migrator"
+)
+
+// errDeclined is returned when the operator declines a destructive run.
+var errDeclined = errors.New("aborted by operator")
+
+// stdin is the standard input shared by the prompts and the repl, so that
+// input buffered by one is not lost to the other.
+var stdin = bufio.NewReader(os.Stdin)
+
+// confirm prompts the operator to proceed if any of the steps reverts a
+// migration or destroys data, listing exactly which versions will run.
+func confirm(steps []migrator.Step) error {
+	destructive := false
+	for _, s := range steps {
+		if s.Destructive() {
+			destructive = true
+			break
+		}
+	}
+
+	if !destructive {
+		return nil
+	}
+
+	fmt.Fprintf(os.Stderr, "the following migrations will run:\n")
+	for _, s := range steps {
+		direction := "up"
+		if !s.Up {
+			direction = "down"
+		}
+		fmt.Fprintf(os.Stderr, "  %-4s %s %s\n", direction, s.Version, s.Name)
+		for _, w := range s.Warnings {
+			if w.Destroy {
+				fmt.Fprintf(os.Stderr, "       warning: %s\n", w)
+			}
+		}
+	}
+
+	ok, err := prompt(stdin, "proceed? [y/N] ")
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return errDeclined
+	}
+
+	return nil
+}
+
+// prompt prints the question and returns true if the answer read from r
+// is yes.
+func prompt(r *bufio.Reader, question string) (bool, error) {
+	fmt.Fprint(os.Stderr, question)
+	answer, err := r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	}
+
+	return false, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pnelson/migrator"
+)
+
+func TestPrompt(t *testing.T) {
+	stderr := os.Stderr
+	os.Stderr, _ = os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	defer func() { os.Stderr = stderr }()
+
+	tests := []struct {
+		answer string
+		want   bool
+	}{
+		{"y\n", true},
+		{"YES\n", true},
+		{" yes ", true},
+		{"n\n", false},
+		{"\n", false},
+		{"", false},
+		{"yep\n", false},
+	}
+
+	for _, tt := range tests {
+		have, err := prompt(bufio.NewReader(strings.NewReader(tt.answer)), "proceed? ")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if have != tt.want {
+			t.Errorf("prompt(%q) = %t, want %t", tt.answer, have, tt.want)
+		}
+	}
+}
+
+func TestConfirmNotDestructive(t *testing.T) {
+	steps := []migrator.Step{{Version: "20240101T000000Z", Name: "users", Up: true}}
+	err := confirm(steps)
+	if err != nil {
+		t.Errorf("confirm of steps that are not destructive = %v, want nil without prompting", err)
+	}
+}
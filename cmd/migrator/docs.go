@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pnelson/migrator"
+)
+
+// docs prints the documentation of the tables of the database.
+func docs(e *env, args []string) error {
+	fs := flag.NewFlagSet("docs", flag.ContinueOnError)
+	format := fs.String("format", "markdown", "format of the documentation, markdown, html, mermaid or dot")
+	diagram := fs.Bool("diagram", false, "include a mermaid diagram in markdown or html")
+	out := fs.String("o", "", "write to the file instead of standard output")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	s, err := migrator.Snapshot(e.db, e.opts...)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+		w = f
+	}
+
+	switch *format {
+	case "markdown", "md":
+		err = s.WriteMarkdown(w, *diagram)
+	case "html":
+		err = s.WriteHTML(w, *diagram)
+	case "mermaid":
+		err = s.WriteMermaid(w)
+	case "dot":
+		err = s.WriteDOT(w)
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if f, ok := w.(*os.File); ok && f != os.Stdout {
+		return f.Close()
+	}
+
+	return nil
+}
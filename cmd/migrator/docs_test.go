@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDocs(t *testing.T) {
+	e := testEnv(t)
+	_, err := e.db.Exec("CREATE TABLE users (id INTEGER);")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := captureStdout(t, func() error { return docs(e, []string{"-format", "mermaid"}) })
+	if out != "erDiagram\n  users {\n    INTEGER id\n  }\n" {
+		t.Errorf("docs -format mermaid = %q", out)
+	}
+
+	path := filepath.Join(t.TempDir(), "schema.html")
+	err = docs(e, []string{"-format", "html", "-o", path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(b), `<h2 id="users">users</h2>`) {
+		t.Errorf("docs -o = %q, %v", b, err)
+	}
+
+	err = docs(e, []string{"-format", "pdf"})
+	if err == nil {
+		t.Error("docs -format pdf error = nil")
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pnelson/migrator"
+)
+
+// drift compares the applied versions of the database with those of the
+// database at the other url and prints the migrations that differ.
+func drift(e *env, args []string) error {
+	fs := flag.NewFlagSet("drift", flag.ContinueOnError)
+	checksums := fs.Bool("checksums", false, "also compare the checksums of applied migrations")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("drift requires the url of the other database")
+	}
+
+	other, d, err := migrator.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	defer other.Close()
+
+	left, err := migrator.Applied(e.db, e.opts...)
+	if err != nil {
+		return err
+	}
+
+	opts := append(append([]migrator.Option{}, e.opts...), migrator.WithDialect(d))
+	right, err := migrator.Applied(other, opts...)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	n := 0
+	for _, d := range migrator.Compare(left, right) {
+		reason := d.Reason()
+		switch {
+		case d.Right == nil:
+			reason = "only in " + e.name
+		case d.Left == nil:
+			reason = "only in other"
+		case !*checksums:
+			continue
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\n", d.Version, d.Name, reason)
+		n++
+	}
+
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+
+	if n > 0 {
+		return &exitError{code: 4, err: fmt.Errorf("%d migrations differ", n)}
+	}
+
+	fmt.Println("no drift")
+	return nil
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pnelson/migrator"
+)
+
+func TestDrift(t *testing.T) {
+	e := testEnv(t)
+	e.name = "development"
+
+	err := migrator.Migrate(e.db, "", e.opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := testEnv(t)
+	out := captureStdout(t, func() error {
+		err := drift(e, []string{other.dsn})
+		if err, ok := err.(*exitError); !ok || err.code != 4 {
+			t.Errorf("drift = %v, want exit code 4", err)
+		}
+		return nil
+	})
+
+	if !strings.HasPrefix(out, migrator.NilVersion) || !strings.Contains(out, "only in development") {
+		t.Errorf("drift = %q, want %s only in development", out, migrator.NilVersion)
+	}
+
+	out = captureStdout(t, func() error { return drift(e, []string{"-checksums", e.dsn}) })
+	if out != "no drift\n" {
+		t.Errorf("drift of the same database = %q, want no drift", out)
+	}
+
+	for _, args := range [][]string{nil, {"a", "b"}} {
+		err := drift(e, args)
+		if err == nil {
+			t.Errorf("drift(%q) error = nil", args)
+		}
+	}
+}
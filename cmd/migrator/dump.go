@@ -0,0 +1,59 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pnelson/migrator"
+)
+
+// dump prints the structure of the database and the contents of the
+// versions table as SQL.
+func dump(e *env, args []string) error {
+	fs := flag.NewFlagSet("dump", flag.ContinueOnError)
+	out := fs.String("o", "", "write to the file instead of standard output")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+
+		defer f.Close()
+		w = f
+	}
+
+	err = migrator.DumpSchema(e.db, w, e.opts...)
+	if err != nil {
+		return err
+	}
+
+	if f, ok := w.(*os.File); ok && f != os.Stdout {
+		return f.Close()
+	}
+
+	return nil
+}
+
+// load executes the SQL of a file written by dump on the database.
+func load(e *env, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("load requires the file written by dump")
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	return migrator.LoadSchema(e.db, f, e.opts...)
+}
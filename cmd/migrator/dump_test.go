@@ -0,0 +1,63 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/pnelson/migrator"
+)
+
+func TestDumpLoad(t *testing.T) {
+	e := testEnv(t)
+	err := migrator.Migrate(e.db, "", e.opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = e.db.Exec("CREATE TABLE users (id INTEGER);")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "schema.sql")
+	err = dump(e, []string{"-o", path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := testEnv(t)
+	_, err = other.db.Exec("DROP TABLE versions;")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = load(other, []string{path})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tables, err := migrator.Tables(other.db, migrator.SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !contains(tables, "users") || !contains(tables, "versions") {
+		t.Errorf("tables after load = %q, want users and versions", tables)
+	}
+
+	err = load(other, nil)
+	if err == nil {
+		t.Error("load without a file error = nil")
+	}
+}
+
+// contains returns true if the string is in the slice.
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pnelson/migrator"
+)
+
+// export prints the applied migrations of the database as JSON for
+// import.
+func export(e *env, args []string) error {
+	return migrator.Export(e.db, os.Stdout, e.opts...)
+}
+
+// importVersions replaces the applied migrations of the database with
+// those of a file written by export after confirming unless -yes is set.
+func importVersions(e *env, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("import requires the file written by export")
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	if !e.yes {
+		ok, err := prompt(stdin, fmt.Sprintf("replace the applied versions in the %s environment? [y/N] ", e.name))
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			return errDeclined
+		}
+	}
+
+	return migrator.Import(e.db, f, e.opts...)
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pnelson/migrator"
+)
+
+// protected are the environments in which fresh, reset, roundtrip and
+// watch refuse to run.
+var protected = map[string]bool{
+	"production": true,
+	"prod":       true,
+	"staging":    true,
+}
+
+// fresh drops everything in the database and migrates up to the latest
+// version.
+func fresh(e *env, args []string) error {
+	err := e.guard("drop everything in the database and migrate up")
+	if err != nil {
+		return err
+	}
+
+	err = e.drop()
+	if err != nil {
+		return err
+	}
+
+	return migrator.Migrate(e.db, "", e.unconfirmed()...)
+}
+
+// reset reverts every migration and migrates up to the latest version.
+func reset(e *env, args []string) error {
+	err := e.guard("revert every migration and migrate up")
+	if err != nil {
+		return err
+	}
+
+	opts := e.unconfirmed()
+	err = migrator.Migrate(e.db, migrator.NilVersion, opts...)
+	if err != nil {
+		return err
+	}
+
+	return migrator.Migrate(e.db, "", opts...)
+}
+
+// roundtrip applies, reverts and reapplies each pending migration and
+// fails if a down migration does not reverse the schema changes of its
+// up migration.
+func roundtrip(e *env, args []string) error {
+	err := e.guard("apply, revert and reapply every pending migration")
+	if err != nil {
+		return err
+	}
+
+	err = migrator.Roundtrip(e.db, e.unconfirmed()...)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("ok")
+	return nil
+}
+
+// watch migrates up to the latest version whenever the migration files
+// change, once no other change follows within the -debounce duration,
+// until interrupted.
+func watch(e *env, args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	debounce := fs.Duration("debounce", 500*time.Millisecond, "duration without changes before migrating")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	err = e.guard("migrate up whenever the migration files change")
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "watching %s\n", e.dir)
+	return migrator.Watch(e.ctx, e.db, e.dir, *debounce, e.unconfirmed()...)
+}
+
+// guard refuses to run in protected environments and otherwise prompts
+// the operator to confirm the action unless -yes is set.
+func (e *env) guard(action string) error {
+	if protected[strings.ToLower(e.name)] {
+		return fmt.Errorf("refusing to %s in the %s environment", action, e.name)
+	}
+
+	if e.yes {
+		return nil
+	}
+
+	ok, err := prompt(stdin, fmt.Sprintf("%s in the %s environment? [y/N] ", action, e.name))
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return errDeclined
+	}
+
+	return nil
+}
+
+// unconfirmed returns the options of the environment without the
+// confirmation of destructive runs, which guard has already obtained.
+func (e *env) unconfirmed() []migrator.Option {
+	return append(append([]migrator.Option{}, e.opts...), migrator.WithConfirm(nil))
+}
+
+// queriesViews select the names of the views of the current database by
+// dialect. Triggers are dropped along with their tables.
+var queriesViews = map[migrator.Dialect]string{
+	migrator.MySQL:  "SELECT table_name FROM information_schema.views WHERE table_schema = DATABASE() ORDER BY table_name",
+	migrator.SQLite: "SELECT name FROM sqlite_master WHERE type = 'view' ORDER BY name",
+}
+
+// querySchema selects the current schema and its owner in Postgres.
+const querySchema = "SELECT n.nspname, pg_get_userbyid(n.nspowner) FROM pg_namespace n WHERE n.nspname = current_schema()"
+
+// queryGrants selects the grantees and privileges of the current schema in
+// Postgres, where a grantee of 0 is PUBLIC.
+const queryGrants = `SELECT CASE WHEN a.grantee = 0 THEN 'PUBLIC' ELSE quote_ident(pg_get_userbyid(a.grantee)) END, a.privilege_type
+FROM pg_namespace n, aclexplode(n.nspacl) a
+WHERE n.nspname = current_schema()
+ORDER BY 1, 2`
+
+// drop drops everything in the database, including the versions table,
+// on a single connection. Postgres drops and recreates the current
+// schema with its owner and grants. MySQL and SQLite drop every view and
+// table with foreign key checks disabled.
+func (e *env) drop() error {
+	ctx := context.Background()
+	conn, err := e.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	var stmts []string
+	switch d := e.kind(); d {
+	case migrator.Postgres:
+		stmts, err = dropSchema(ctx, conn)
+		if err != nil {
+			return err
+		}
+	case migrator.MySQL:
+		stmts, err = dropAll(ctx, conn, d, "`")
+		if err != nil {
+			return err
+		}
+		stmts = append([]string{"SET FOREIGN_KEY_CHECKS = 0"}, stmts...)
+		stmts = append(stmts, "SET FOREIGN_KEY_CHECKS = 1")
+	case migrator.SQLite:
+		stmts, err = dropAll(ctx, conn, d, `"`)
+		if err != nil {
+			return err
+		}
+		stmts = append([]string{"PRAGMA foreign_keys = OFF"}, stmts...)
+		stmts = append(stmts, "PRAGMA foreign_keys = ON")
+	}
+
+	for _, stmt := range stmts {
+		_, err = conn.ExecContext(ctx, stmt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dropSchema returns the statements that drop the current schema in
+// Postgres with everything in it and recreate it with its owner and
+// grants.
+func dropSchema(ctx context.Context, conn *sql.Conn) ([]string, error) {
+	var name, owner string
+	err := conn.QueryRowContext(ctx, querySchema).Scan(&name, &owner)
+	if err != nil {
+		return nil, err
+	}
+
+	schema := quoteIdent(name, `"`)
+	stmts := []string{
+		fmt.Sprintf("DROP SCHEMA %s CASCADE", schema),
+		fmt.Sprintf("CREATE SCHEMA %s AUTHORIZATION %s", schema, quoteIdent(owner, `"`)),
+	}
+
+	rows, err := conn.QueryContext(ctx, queryGrants)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var grantee, privilege string
+		err = rows.Scan(&grantee, &privilege)
+		if err != nil {
+			return nil, err
+		}
+
+		stmts = append(stmts, fmt.Sprintf("GRANT %s ON SCHEMA %s TO %s", privilege, schema, grantee))
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return stmts, nil
+}
+
+// dropAll returns the statements that drop every view and table of the
+// database in MySQL and SQLite, quoting identifiers with q.
+func dropAll(ctx context.Context, conn *sql.Conn, d migrator.Dialect, q string) ([]string, error) {
+	views, err := views(ctx, conn, d)
+	if err != nil {
+		return nil, err
+	}
+
+	tables, err := migrator.Tables(conn, d)
+	if err != nil {
+		return nil, err
+	}
+
+	var stmts []string
+	for _, v := range views {
+		stmts = append(stmts, fmt.Sprintf("DROP VIEW IF EXISTS %s", quoteIdent(v, q)))
+	}
+
+	for _, t := range tables {
+		stmts = append(stmts, fmt.Sprintf("DROP TABLE IF EXISTS %s", quoteIdent(t, q)))
+	}
+
+	return stmts, nil
+}
+
+// views returns the names of the views of the database in MySQL and
+// SQLite.
+func views(ctx context.Context, conn *sql.Conn, d migrator.Dialect) ([]string, error) {
+	var rv []string
+	rows, err := conn.QueryContext(ctx, queriesViews[d])
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		err = rows.Scan(&name)
+		if err != nil {
+			return nil, err
+		}
+
+		rv = append(rv, name)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return rv, nil
+}
+
+// quoteIdent returns the identifier quoted by q.
+func quoteIdent(s, q string) string {
+	return q + strings.ReplaceAll(s, q, q+q) + q
+}
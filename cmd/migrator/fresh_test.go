@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pnelson/migrator"
+)
+
+func TestGuard(t *testing.T) {
+	for _, name := range []string{"production", "Prod", "staging"} {
+		e := &env{name: name, yes: true}
+		err := e.guard("drop every table")
+		if err == nil || !strings.Contains(err.Error(), "refusing") {
+			t.Errorf("guard in %s = %v, want refusal", name, err)
+		}
+	}
+
+	e := &env{name: "development", yes: true}
+	err := e.guard("drop every table")
+	if err != nil {
+		t.Errorf("guard in development = %v", err)
+	}
+}
+
+func TestFresh(t *testing.T) {
+	e := testEnv(t)
+	e.name = "development"
+	e.dialect = "sqlite"
+	e.yes = true
+
+	_, err := e.db.Exec("CREATE TABLE stale (id INTEGER)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = e.db.Exec("CREATE VIEW stale_ids AS SELECT id FROM stale")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = fresh(e, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	err = e.db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name IN ('stale', 'stale_ids')").Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 0 {
+		t.Error("fresh did not drop the tables and views")
+	}
+
+	err = reset(e, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, _, err := migrator.Check(e.db, e.opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if state != migrator.UpToDate {
+		t.Errorf("state after reset = %v, want %v", state, migrator.UpToDate)
+	}
+}
+
+func TestRoundtrip(t *testing.T) {
+	e := testEnv(t)
+	e.name = "development"
+	e.yes = true
+
+	out := captureStdout(t, func() error { return roundtrip(e, nil) })
+	if out != "ok\n" {
+		t.Errorf("roundtrip = %q, want ok", out)
+	}
+
+	e.name = "production"
+	err := roundtrip(e, nil)
+	if err == nil {
+		t.Error("roundtrip in production error = nil")
+	}
+}
+
+func TestQuoteIdent(t *testing.T) {
+	tests := []struct {
+		s    string
+		q    string
+		want string
+	}{
+		{"users", `"`, `"users"`},
+		{`a"b`, `"`, `"a""b"`},
+		{"a`b", "`", "`a``b`"},
+	}
+
+	for _, tt := range tests {
+		have := quoteIdent(tt.s, tt.q)
+		if have != tt.want {
+			t.Errorf("quoteIdent(%q, %q) = %q, want %q", tt.s, tt.q, have, tt.want)
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	e := testEnv(t)
+	e.name = "production"
+	e.yes = true
+	err := watch(e, nil)
+	if err == nil || !strings.Contains(err.Error(), "refusing") {
+		t.Errorf("watch in production = %v, want refusal", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	e.name = "development"
+	e.ctx = ctx
+	err = watch(e, []string{"-debounce", "10ms"})
+	if err != nil {
+		t.Errorf("watch until interrupted = %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pnelson/migrator"
+)
+
+// terminationLog is the file whose contents Kubernetes reports as the
+// message of a terminated container.
+const terminationLog = "/dev/termination-log"
+
+// An outcome is the machine-readable completion of a job.
+type outcome struct {
+	Status  string        `json:"status"`            // succeeded, failed, diverged, timeout or interrupted
+	Code    int           `json:"code"`              // exit code of the job
+	Version string        `json:"version,omitempty"` // version of the database after the job
+	Error   string        `json:"error,omitempty"`   // error of the job, if any
+	Run     *migrator.Run `json:"run,omitempty"`     // run of the job, if any
+}
+
+// job migrates up to the target version or the latest version without
+// prompting, holding the lock so that retried or overlapping jobs run one
+// at a time, within the -timeout of the job. The completion is printed as
+// JSON and written to the termination log of the container. Jobs exit 0
+// when the database is migrated, even if it already was, 1 when a
+// migration fails, 4 when applied versions are not registered and 5 when
+// the timeout elapses.
+func job(e *env, args []string) error {
+	fs := flag.NewFlagSet("job", flag.ContinueOnError)
+	timeout := fs.Duration("timeout", 10*time.Minute, "maximum duration of the job")
+	path := fs.String("termination-log", terminationLog, "file to write the completion to, if it exists")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	target := fs.Arg(0)
+	ctx, cancel := context.WithTimeout(e.ctx, *timeout)
+	defer cancel()
+
+	opts := append(e.unconfirmed(), migrator.WithLock(), migrator.WithContext(ctx))
+	c := &outcome{Status: "succeeded"}
+	state, vs, err := migrator.Check(e.db, opts...)
+	if err == nil && state == migrator.Diverged {
+		err = &exitError{code: 4, err: fmt.Errorf("unknown applied versions: %s", strings.Join(vs, ", "))}
+	} else if err == nil {
+		c.Run = &migrator.Run{}
+		err = migrator.Migrate(e.db, target, append(opts, migrator.WithRun(c.Run))...)
+	}
+
+	switch {
+	case err == nil:
+	case e.ctx.Err() != nil:
+		c.Status, c.Code = "interrupted", 130
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		err = &exitError{code: 5, err: fmt.Errorf("timed out after %s: %w", *timeout, err)}
+		c.Status, c.Code = "timeout", 5
+	case state == migrator.Diverged:
+		c.Status, c.Code = "diverged", 4
+	default:
+		c.Status, c.Code = "failed", 1
+	}
+
+	if err != nil {
+		c.Error = err.Error()
+	}
+
+	c.Version, _ = migrator.Current(e.db, e.opts...)
+	b, merr := json.Marshal(c)
+	if merr != nil {
+		return merr
+	}
+
+	fmt.Println(string(b))
+	_, serr := os.Stat(*path)
+	if serr == nil {
+		werr := os.WriteFile(*path, b, 0644)
+		if werr != nil {
+			fmt.Fprintf(os.Stderr, "migrator: writing termination log: %v\n", werr)
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJob(t *testing.T) {
+	e := testEnv(t)
+	e.ctx = context.Background()
+	path := filepath.Join(t.TempDir(), "termination-log")
+	err := os.WriteFile(path, nil, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := captureStdout(t, func() error { return job(e, []string{"-termination-log", path}) })
+
+	var c outcome
+	err = json.Unmarshal([]byte(out), &c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if c.Status != "succeeded" || c.Code != 0 || c.Version == "" || c.Run == nil {
+		t.Errorf("job = %s, want succeeded", out)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil || string(b)+"\n" != out {
+		t.Errorf("termination log = %q, %v, want %q", b, err, out)
+	}
+
+	_, err = e.db.Exec("INSERT INTO versions (version, name) VALUES ('20990101T000000Z', 'unknown');")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out = captureStdout(t, func() error {
+		err := job(e, []string{"-termination-log", filepath.Join(t.TempDir(), "missing")})
+		if err, ok := err.(*exitError); !ok || err.code != 4 {
+			t.Errorf("job of a diverged database = %v, want exit code 4", err)
+		}
+		return nil
+	})
+
+	if !strings.Contains(out, `"status":"diverged","code":4`) || !strings.Contains(out, "20990101T000000Z") {
+		t.Errorf("job of a diverged database = %s", out)
+	}
+}
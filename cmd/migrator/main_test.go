@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestParseWindow(t *testing.T) {
+	tests := []struct {
+		s          string
+		start, end time.Duration
+		err        bool
+	}{
+		{s: "02:00-05:00", start: 2 * time.Hour, end: 5 * time.Hour},
+		{s: "22:30-03:15", start: 22*time.Hour + 30*time.Minute, end: 3*time.Hour + 15*time.Minute},
+		{s: "02:00", err: true},
+		{s: "02:00-05:00-06:00", err: true},
+		{s: "2am-5am", err: true},
+		{s: "25:00-05:00", err: true},
+	}
+
+	for _, tt := range tests {
+		start, end, err := parseWindow(tt.s)
+		if tt.err {
+			if err == nil {
+				t.Errorf("parseWindow(%q) error = nil", tt.s)
+			}
+			continue
+		}
+
+		if err != nil || start != tt.start || end != tt.end {
+			t.Errorf("parseWindow(%q) = %s, %s, %v, want %s, %s", tt.s, start, end, err, tt.start, tt.end)
+		}
+	}
+}
+
+func TestExitErrorUnwrap(t *testing.T) {
+	err := &exitError{code: 130, err: fmt.Errorf("interrupted: %w", context.Canceled)}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("errors.Is(%v, context.Canceled) = false, want the wrapped error", err)
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pnelson/migrator"
+)
+
+// replHelp describes the commands of the repl.
+const replHelp = `commands:
+  l         list the migrations
+  s <n>     show the SQL of migration n
+  m <n>     migrate up or down to migration n
+  q         quit
+`
+
+// repl reads commands from standard input at a prompt to browse the
+// migrations, view their SQL and run them. It is a line-oriented loop
+// rather than a full-screen interface, so it also reads commands piped
+// to it. Commands are read from the reader shared with the confirmation
+// prompt of the runs it starts.
+func repl(e *env, args []string) error {
+	infos, err := list(e)
+	if err != nil {
+		return err
+	}
+
+	fmt.Print(replHelp)
+	for {
+		fmt.Print("> ")
+		line, err := stdin.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			fmt.Println()
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+
+		var info *migrator.Info
+		if len(fields) > 1 {
+			n, err := strconv.Atoi(fields[1])
+			if err != nil || n < 1 || n > len(infos) {
+				fmt.Printf("no migration %q\n", fields[1])
+				continue
+			}
+			info = infos[n-1]
+		}
+
+		switch fields[0] {
+		case "l":
+			infos, err = list(e)
+			if err != nil {
+				return err
+			}
+		case "s":
+			if info == nil {
+				fmt.Println("s requires a migration number")
+				continue
+			}
+			view(info)
+		case "m":
+			if info == nil {
+				fmt.Println("m requires a migration number")
+				continue
+			}
+			err = migrate(e, info.Version)
+			if err != nil {
+				fmt.Printf("error: %v\n", err)
+			}
+			infos, err = list(e)
+			if err != nil {
+				return err
+			}
+		case "q":
+			return nil
+		default:
+			fmt.Print(replHelp)
+		}
+	}
+}
+
+// list prints the numbered migrations with their state and returns them.
+func list(e *env) ([]*migrator.Info, error) {
+	infos, err := migrator.Inspect(e.db, e.opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tVERSION\tNAME\tSTATE")
+	for i, info := range infos {
+		state := "pending"
+		if info.Applied {
+			state = "applied"
+			if info.SkipReason != "" {
+				state = "skipped: " + info.SkipReason
+			}
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", i+1, info.Version, info.Name, state)
+	}
+
+	return infos, w.Flush()
+}
+
+// view prints the SQL of the migration in both directions.
+func view(info *migrator.Info) {
+	for _, up := range []bool{true, false} {
+		direction := "up"
+		if !up {
+			direction = "down"
+		}
+
+		stmts := migrator.Statements(info.Version, up)
+		fmt.Printf("-- %s %s %s\n", info.Version, info.Name, direction)
+		if len(stmts) == 0 {
+			fmt.Println("-- (not a SQL migration)")
+		}
+		for _, stmt := range stmts {
+			fmt.Println(terminated(stmt))
+		}
+	}
+}
+
+// migrate migrates to the target version timestamp, printing each
+// statement as it completes and the progress reported by migrations.
+func migrate(e *env, target string) error {
+	opts := append([]migrator.Option{}, e.opts...)
+	opts = append(opts,
+		migrator.WithTimings(func(t migrator.Timing) {
+			fmt.Printf("%s %8s %s\n", t.Version, t.Elapsed.Round(time.Microsecond), oneLine(t.Statement))
+		}),
+		migrator.WithProgress(func(p migrator.Progress) {
+			if pct := p.Percent(); pct >= 0 {
+				fmt.Printf("%s %.1f%% (%d/%d)\n", p.Version, pct, p.Done, p.Total)
+			} else {
+				fmt.Printf("%s %d done\n", p.Version, p.Done)
+			}
+		}),
+	)
+
+	return migrator.Migrate(e.db, target, opts...)
+}
+
+// oneLine returns the statement collapsed to a single line of at most 60
+// characters.
+func oneLine(stmt string) string {
+	s := strings.Join(strings.Fields(stmt), " ")
+	if len(s) > 60 {
+		s = s[:57] + "..."
+	}
+
+	return s
+}
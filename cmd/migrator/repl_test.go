@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOneLine(t *testing.T) {
+	tests := []struct {
+		stmt string
+		want string
+	}{
+		{"SELECT 1", "SELECT 1"},
+		{"CREATE TABLE users (\n\tid bigint\n)", "CREATE TABLE users ( id bigint )"},
+		{strings.Repeat("a ", 40), strings.Repeat("a ", 28) + "a..."},
+	}
+
+	for _, tt := range tests {
+		have := oneLine(tt.stmt)
+		if have != tt.want {
+			t.Errorf("oneLine(%q) = %q, want %q", tt.stmt, have, tt.want)
+		}
+	}
+}
+
+func TestREPL(t *testing.T) {
+	e := testEnv(t)
+	dir := t.TempDir()
+
+	r, stdout := stdin, os.Stdout
+	defer func() { stdin, os.Stdout = r, stdout }()
+
+	stdin = bufio.NewReader(strings.NewReader("s\ns 9\n\nl\ns 1\nq\nl\n"))
+
+	var err error
+	os.Stdout, err = os.Create(filepath.Join(dir, "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Stdout.Close()
+
+	err = repl(e, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := os.ReadFile(filepath.Join(dir, "out"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"s requires a migration number",
+		`no migration "9"`,
+		"#  VERSION",
+		"-- 00010101T000000Z nil up",
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	if strings.Count(string(out), "#  VERSION") != 2 {
+		t.Errorf("commands read after quit:\n%s", out)
+	}
+
+	// The input after quit is left to the next reader, such as a prompt.
+	rest, err := stdin.ReadString('\n')
+	if err != nil || rest != "l\n" {
+		t.Errorf("input after quit = %q, %v, want the unread command", rest, err)
+	}
+}
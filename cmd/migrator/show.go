@@ -0,0 +1,172 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/pnelson/migrator"
+)
+
+// keyword matches the SQL keywords highlighted by show.
+var keyword = regexp.MustCompile(`(?i)\b(?:ADD|ALTER|AND|AS|BEGIN|BY|CASCADE|COLUMN|COMMIT|CONCURRENTLY|CONSTRAINT|CREATE|DEFAULT|DELETE|DROP|EXISTS|EXTENSION|FOREIGN|FROM|FUNCTION|GRANT|IF|INDEX|INSERT|INTO|KEY|NOT|NULL|ON|OR|PRIMARY|REFERENCES|RENAME|REPLACE|RETURNS|REVOKE|SELECT|SET|TABLE|TO|TRIGGER|TYPE|UNIQUE|UPDATE|USING|VALUES|VIEW|WHERE|WITH)\b`)
+
+// comment matches SQL line comments.
+var comment = regexp.MustCompile(`--[^\n]*`)
+
+// ANSI escape sequences of highlighted SQL.
+const (
+	ansiKeyword = "\x1b[1;34m"
+	ansiComment = "\x1b[2m"
+	ansiReset   = "\x1b[0m"
+)
+
+// show prints the up and down SQL, or the source location of Go
+// migrations, of the migration of the version or of every pending
+// migration, optionally highlighted and paged.
+func show(e *env, args []string) error {
+	fs := flag.NewFlagSet("show", flag.ContinueOnError)
+	color := fs.Bool("color", isTerminal(os.Stdout), "highlight SQL keywords and comments")
+	page := fs.Bool("page", false, "page the output with $PAGER, or less -R")
+	err := fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	err = migrator.LoadDir(e.dir)
+	if err != nil {
+		return err
+	}
+
+	var vs []string
+	if fs.NArg() > 0 {
+		vs = fs.Args()
+	} else {
+		disconnect, err := e.connect()
+		if err != nil {
+			return err
+		}
+
+		defer disconnect()
+
+		steps, err := migrator.Plan(e.db, "", e.opts...)
+		if err != nil {
+			return err
+		}
+
+		for _, s := range steps {
+			vs = append(vs, s.Version)
+		}
+	}
+
+	var b strings.Builder
+	for _, v := range vs {
+		info := find(v)
+		if info == nil {
+			return fmt.Errorf("unknown version %q", v)
+		}
+
+		fmt.Fprintf(&b, "-- %s %s\n", info.Version, info.Name)
+		if src := migrator.Source(v); src != "" {
+			fmt.Fprintf(&b, "-- source: %s\n", src)
+		}
+
+		for _, up := range []bool{true, false} {
+			direction := "up"
+			if !up {
+				direction = "down"
+			}
+
+			fmt.Fprintf(&b, "\n-- %s\n", direction)
+			stmts := migrator.Statements(v, up)
+			if migrator.Statements(v, true) == nil {
+				fmt.Fprintf(&b, "-- (Go migration, see source)\n")
+			} else if len(stmts) == 0 {
+				fmt.Fprintf(&b, "-- (none)\n")
+			}
+			for _, stmt := range stmts {
+				fmt.Fprintln(&b, terminated(stmt))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	out := b.String()
+	if *color {
+		out = highlight(out)
+	}
+
+	if !*page {
+		_, err = io.WriteString(os.Stdout, out)
+		return err
+	}
+
+	return pager(out)
+}
+
+// terminated returns the statement terminated by a semicolon.
+func terminated(stmt string) string {
+	if strings.HasSuffix(stmt, ";") {
+		return stmt
+	}
+
+	return stmt + ";"
+}
+
+// find returns the registered migration of the version or nil.
+func find(version string) *migrator.Info {
+	for _, info := range migrator.Registered() {
+		if info.Version == version {
+			return info
+		}
+	}
+
+	return nil
+}
+
+// highlight returns the SQL with its comments and keywords highlighted.
+func highlight(sql string) string {
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(sql, "\n") {
+		code, rest := line, ""
+		if loc := comment.FindStringIndex(line); loc != nil {
+			code, rest = line[:loc[0]], line[loc[0]:]
+		}
+
+		b.WriteString(keyword.ReplaceAllString(code, ansiKeyword+"$0"+ansiReset))
+		if rest != "" {
+			nl := strings.HasSuffix(rest, "\n")
+			b.WriteString(ansiComment + strings.TrimSuffix(rest, "\n") + ansiReset)
+			if nl {
+				b.WriteString("\n")
+			}
+		}
+	}
+
+	return b.String()
+}
+
+// pager writes the output to the pager of $PAGER, or less -R.
+func pager(out string) error {
+	name := os.Getenv("PAGER")
+	if name == "" {
+		name = "less -R"
+	}
+
+	fields := strings.Fields(name)
+	cmd := exec.Command(fields[0], fields[1:]...)
+	cmd.Stdin = strings.NewReader(out)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// isTerminal returns true if the file is a terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestShow(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"20240201T000000Z_show_users.up.sql":   "-- users\nCREATE TABLE users (id INTEGER);",
+		"20240201T000000Z_show_users.down.sql": "",
+	} {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	e := &env{dir: dir}
+	out := captureStdout(t, func() error { return show(e, []string{"-color=false", "20240201T000000Z"}) })
+	for _, want := range []string{
+		"-- 20240201T000000Z show_users\n",
+		"-- source: " + filepath.Join(dir, "20240201T000000Z_show_users.up.sql") + "\n",
+		"\n-- up\n-- users\nCREATE TABLE users (id INTEGER);\n",
+		"\n-- down\n-- (none)\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("show missing %q:\n%s", want, out)
+		}
+	}
+
+	err := show(&env{dir: t.TempDir()}, []string{"20990101T000000Z"})
+	if err == nil {
+		t.Error("show of an unknown version error = nil")
+	}
+}
+
+func TestHighlight(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want string
+	}{
+		{"create table t;\n", ansiKeyword + "create" + ansiReset + " " + ansiKeyword + "table" + ansiReset + " t;\n"},
+		{"-- drop table\n", ansiComment + "-- drop table" + ansiReset + "\n"},
+		{"tables -- x", "tables " + ansiComment + "-- x" + ansiReset},
+	}
+
+	for _, tt := range tests {
+		have := highlight(tt.sql)
+		if have != tt.want {
+			t.Errorf("highlight(%q) = %q, want %q", tt.sql, have, tt.want)
+		}
+	}
+}
+
+func TestTerminated(t *testing.T) {
+	for stmt, want := range map[string]string{"SELECT 1": "SELECT 1;", "SELECT 1;": "SELECT 1;"} {
+		if have := terminated(stmt); have != want {
+			t.Errorf("terminated(%q) = %q, want %q", stmt, have, want)
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package migrator
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// A DefaultColumn is a NOT NULL column with a default value to add to a
+// table that already contains rows.
+type DefaultColumn struct {
+	Table     string // table to alter
+	Column    string // column to add
+	Type      string // SQL type of the column
+	Default   string // SQL expression of the default value
+	Key       string // integer key column to backfill by, "id" if empty
+	BatchSize int64  // keys per backfill batch, 10000 if zero
+}
+
+// AddColumnWithDefault adds the NOT NULL column with a default without
+// rewriting or locking the table for the duration of the change.
+//
+// On Postgres the column is added as nullable, the default is set for
+// new rows, existing rows are backfilled in batches and the NOT NULL
+// constraint is added by validating a check constraint first so that
+// setting it does not scan the table while holding an exclusive lock.
+// Each step is idempotent so an interrupted call can be retried. On
+// MySQL and SQLite, adding a column with a default does not rewrite the
+// table and the column is added in a single statement.
+//
+// The identifiers and default are interpolated into the statements and
+// must be trusted. AddColumnWithDefault commits as it goes and so must
+// be called from a migration registered with RegisterNoTransaction.
+func AddColumnWithDefault(conn Conn, d Dialect, c DefaultColumn, opts ...BackfillOption) error {
+	if d != Postgres {
+		return ExecAll(conn, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s NOT NULL DEFAULT %s;",
+			c.Table, c.Column, c.Type, c.Default))
+	}
+
+	key, size := batchDefaults(c.Key, c.BatchSize)
+	check := c.Table + "_" + c.Column + "_not_null"
+	err := ExecAll(conn,
+		fmt.Sprintf("ALTER TABLE %s ADD COLUMN IF NOT EXISTS %s %s;", c.Table, c.Column, c.Type),
+		fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;", c.Table, c.Column, c.Default),
+	)
+	if err != nil {
+		return err
+	}
+
+	update := fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s >= $1 AND %s < $2 AND %s IS NULL;",
+		c.Table, c.Column, c.Default, key, key, c.Column)
+	err = Backfill(conn, c.Table, key, size, func(tx *sql.Tx, lo, hi int64) error {
+		_, err := tx.Exec(update, lo, hi)
+		return err
+	}, opts...)
+	if err != nil {
+		return err
+	}
+
+	return ExecAll(conn,
+		fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;", c.Table, check),
+		fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s CHECK (%s IS NOT NULL) NOT VALID;", c.Table, check, c.Column),
+		fmt.Sprintf("ALTER TABLE %s VALIDATE CONSTRAINT %s;", c.Table, check),
+		fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;", c.Table, c.Column),
+		fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s;", c.Table, check),
+	)
+}
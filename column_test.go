@@ -0,0 +1,28 @@
+package migrator
+
+import "testing"
+
+func TestAddColumnWithDefault(t *testing.T) {
+	db := backfillTestDB(t, 3)
+
+	c := DefaultColumn{Table: "items", Column: "status", Type: "TEXT", Default: "'new'"}
+	err := AddColumnWithDefault(db, SQLite, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	err = db.QueryRow("SELECT COUNT(*) FROM items WHERE status = 'new'").Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 3 {
+		t.Errorf("%d rows have the default, want 3", n)
+	}
+
+	_, err = db.Exec("INSERT INTO items (id, status) VALUES (4, NULL)")
+	if err == nil {
+		t.Error("inserted NULL into the NOT NULL column")
+	}
+}
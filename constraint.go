@@ -0,0 +1,83 @@
+package migrator
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// A Constraint is a foreign key or check constraint on a table. The
+// identifiers and definition are interpolated into statements and must
+// be trusted.
+type Constraint struct {
+	Table      string // table to constrain
+	Name       string // name of the constraint
+	Definition string // such as CHECK (price > 0) or FOREIGN KEY ...
+}
+
+// AddConstraintNotValid adds the constraint without checking the
+// existing rows, which only briefly locks the table. New and updated
+// rows are checked immediately. Use ValidateConstraint later to check
+// the existing rows. On dialects without NOT VALID, the constraint is
+// added and checked normally.
+func AddConstraintNotValid(tx *sql.Tx, d Dialect, c Constraint) error {
+	notValid := ""
+	if d == Postgres {
+		notValid = " NOT VALID"
+	}
+
+	_, err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s%s;",
+		c.Table, c.Name, c.Definition, notValid))
+	return err
+}
+
+// ValidateConstraint checks the existing rows against a constraint added
+// by AddConstraintNotValid. Validation scans the table but does not
+// block reads or writes. It is a no-op on dialects without NOT VALID.
+func ValidateConstraint(tx *sql.Tx, d Dialect, c Constraint) error {
+	if d != Postgres {
+		return nil
+	}
+
+	_, err := tx.Exec(fmt.Sprintf("ALTER TABLE %s VALIDATE CONSTRAINT %s;", c.Table, c.Name))
+	return err
+}
+
+// DropConstraint drops the constraint if it exists.
+func DropConstraint(tx *sql.Tx, d Dialect, c Constraint) error {
+	query := "ALTER TABLE %s DROP CONSTRAINT IF EXISTS %s;"
+	if d == MySQL {
+		query = "ALTER TABLE %s DROP CONSTRAINT %s;"
+	}
+
+	_, err := tx.Exec(fmt.Sprintf(query, c.Table, c.Name))
+	return err
+}
+
+// RegisterConstraint makes two migrations available for the two-step
+// pattern of adding a constraint. The migration at version adds the
+// constraint as NOT VALID and is named add_constraint_<name>. The
+// follow-up migration at validate validates it in a separate transaction
+// and is named validate_constraint_<name>. The options apply to both
+// migrations. The migrations use the dialect of the run. If a version is
+// already registered, it panics.
+func RegisterConstraint(version, validate string, c Constraint, opts ...MigrationOption) {
+	if validate <= version {
+		panic("migrator: RegisterConstraint validate must be after " + version)
+	}
+
+	Register(version, "add_constraint_"+c.Name,
+		func(tx *sql.Tx) error {
+			return AddConstraintNotValid(tx, DialectOf(tx), c)
+		},
+		func(tx *sql.Tx) error {
+			return DropConstraint(tx, DialectOf(tx), c)
+		},
+		opts...)
+
+	Register(validate, "validate_constraint_"+c.Name,
+		func(tx *sql.Tx) error {
+			return ValidateConstraint(tx, DialectOf(tx), c)
+		},
+		empty,
+		opts...)
+}
@@ -0,0 +1,56 @@
+package migrator
+
+import "testing"
+
+func TestRegisterConstraint(t *testing.T) {
+	isolate(t)
+
+	c := Constraint{Table: "items", Name: "items_done_check", Definition: "CHECK (done IN (0, 1))"}
+	RegisterConstraint("20240101T000000Z", "20240102T000000Z", c)
+
+	tests := []struct {
+		version string
+		name    string
+	}{
+		{"20240101T000000Z", "add_constraint_items_done_check"},
+		{"20240102T000000Z", "validate_constraint_items_done_check"},
+	}
+
+	for _, tt := range tests {
+		m, ok := migrations[tt.version]
+		if !ok {
+			t.Fatalf("version %s not registered", tt.version)
+		}
+
+		if m.name != tt.name {
+			t.Errorf("version %s name = %q, want %q", tt.version, m.name, tt.name)
+		}
+	}
+}
+
+func TestRegisterConstraintOrder(t *testing.T) {
+	isolate(t)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("RegisterConstraint with validate before version did not panic")
+		}
+	}()
+
+	RegisterConstraint("20240102T000000Z", "20240101T000000Z", Constraint{Name: "c"})
+}
+
+func TestValidateConstraint(t *testing.T) {
+	db := openTestDB(t)
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tx.Rollback()
+
+	err = ValidateConstraint(tx, SQLite, Constraint{Table: "missing", Name: "c"})
+	if err != nil {
+		t.Errorf("ValidateConstraint in SQLite = %v, want no-op", err)
+	}
+}
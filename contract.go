@@ -0,0 +1,200 @@
+package migrator
+
+import (
+	"database/sql"
+	"time"
+)
+
+// instanceTTL is the duration after which an instance that has not
+// checked in is considered gone.
+const instanceTTL = 15 * time.Minute
+
+// queryInstancesNew creates the instances table if not already created.
+var queryInstancesNew = `
+CREATE TABLE IF NOT EXISTS instances (
+  name    VARCHAR(255) PRIMARY KEY,
+  version VARCHAR(255) NOT NULL,
+  seen_at TIMESTAMP NOT NULL
+);
+`
+
+// queryInstancesSave inserts or updates the version of an instance.
+var queryInstancesSave = `
+INSERT INTO instances (name, version, seen_at)
+  VALUES ($1, $2, $3)
+  ON CONFLICT (name) DO UPDATE
+  SET version = EXCLUDED.version, seen_at = EXCLUDED.seen_at;
+`
+
+// queryInstancesSaveMySQL inserts or updates the version of an instance
+// in MySQL.
+var queryInstancesSaveMySQL = `
+INSERT INTO instances (name, version, seen_at)
+  VALUES (?, ?, ?)
+  ON DUPLICATE KEY UPDATE
+  version = VALUES(version), seen_at = VALUES(seen_at);
+`
+
+// queryInstancesDelete deletes an instance.
+var queryInstancesDelete = `
+DELETE FROM instances
+  WHERE name = $1;
+`
+
+// queryInstancesLive selects the instances seen since a time.
+var queryInstancesLive = `
+SELECT name, version
+  FROM instances
+  WHERE seen_at >= $1
+  ORDER BY name;
+`
+
+// Expand declares the migration as an expanding change, one that adds to
+// the schema without breaking code written for the schema before it.
+func Expand() MigrationOption {
+	return func(m *migration) {
+		m.expand = true
+	}
+}
+
+// Contract declares the migration as contracting the change of the
+// expand migration version timestamp, removing the schema that code
+// written before it relies on. Contract migrations run in PhasePost and
+// are safe once every running instance was built with the expand
+// migration. See Blocked.
+func Contract(expand string) MigrationOption {
+	return func(m *migration) {
+		m.contracts = expand
+		m.phase = PhasePost
+	}
+}
+
+// CheckIn records the instance as running code built with the latest
+// registered migration of the set of the run, so that contract migrations
+// wait for the instances built before their expand migrations. Instances
+// are considered gone 15 minutes after they last checked in, so check in
+// on startup and periodically, and CheckOut on shutdown.
+func CheckIn(db *sql.DB, instance string, opts ...Option) error {
+	o := newOptions(opts)
+
+	_, err := db.ExecContext(o.ctx, queryInstancesNew)
+	if err != nil {
+		return err
+	}
+
+	query := queryInstancesSave
+	if o.dialect == MySQL {
+		query = queryInstancesSaveMySQL
+	}
+
+	vs := o.sorted()
+	_, err = db.ExecContext(o.ctx, o.dialect.rebind(query), instance, vs[len(vs)-1], o.now().UTC())
+	return err
+}
+
+// CheckOut removes the instance, such as when it shuts down.
+func CheckOut(db *sql.DB, instance string, opts ...Option) error {
+	o := newOptions(opts)
+
+	_, err := db.ExecContext(o.ctx, queryInstancesNew)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.ExecContext(o.ctx, o.dialect.rebind(queryInstancesDelete), instance)
+	return err
+}
+
+// A Blocker is a pending contract migration that is not yet safe to run
+// and the running instances built before its expand migration.
+type Blocker struct {
+	Version   string   `json:"version"`
+	Name      string   `json:"name"`
+	Expand    string   `json:"expand"`
+	Instances []string `json:"instances"`
+}
+
+// Blocked returns the pending contract migrations of the set of the run
+// that are not yet safe to run, because instances that checked in within
+// the last 15 minutes were built before their expand migrations, in
+// ascending order by version timestamp.
+func Blocked(db *sql.DB, opts ...Option) ([]*Blocker, error) {
+	o := newOptions(opts)
+
+	_, err := db.ExecContext(o.ctx, queryInstancesNew)
+	if err != nil {
+		return nil, err
+	}
+
+	err = o.createVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	done, err := versions(db, o)
+	if err != nil {
+		return nil, err
+	}
+
+	live, err := instances(db, o)
+	if err != nil {
+		return nil, err
+	}
+
+	var rv []*Blocker
+	for _, v := range o.sorted() {
+		m := migrations[v]
+		if m.contracts == "" || find(v, done) != nil {
+			continue
+		}
+
+		b := &Blocker{Version: v, Name: m.name, Expand: m.contracts}
+		for _, i := range live {
+			if i.version < m.contracts {
+				b.Instances = append(b.Instances, i.name)
+			}
+		}
+
+		if len(b.Instances) > 0 {
+			rv = append(rv, b)
+		}
+	}
+
+	return rv, nil
+}
+
+// An instance is a running instance and the latest migration it was
+// built with.
+type instance struct {
+	name    string
+	version string
+}
+
+// instances returns the instances of the run seen within the ttl.
+func instances(db *sql.DB, o *options) ([]instance, error) {
+	var rv []instance
+	since := o.now().Add(-instanceTTL).UTC()
+	rows, err := db.QueryContext(o.ctx, o.dialect.rebind(queryInstancesLive), since)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var i instance
+		err := rows.Scan(&i.name, &i.version)
+		if err != nil {
+			return nil, err
+		}
+
+		rv = append(rv, i)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return rv, err
+	}
+
+	return rv, nil
+}
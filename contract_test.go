@@ -0,0 +1,72 @@
+package migrator
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBlocked(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+	err := CheckIn(db, "old", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	err = CheckIn(db, "gone", sqlite, WithClock(fixedClock(now.Add(-time.Hour))))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Register("20240102T000000Z", "add_email", empty, empty, Expand())
+	Register("20240103T000000Z", "drop_login", empty, empty, Contract("20240102T000000Z"))
+	err = CheckIn(db, "new", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bs, err := Blocked(db, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*Blocker{{Version: "20240103T000000Z", Name: "drop_login", Expand: "20240102T000000Z", Instances: []string{"old"}}}
+	if !reflect.DeepEqual(bs, want) {
+		t.Errorf("Blocked = %+v, want the contract blocked by old", bs)
+	}
+
+	err = CheckOut(db, "old", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bs, err = Blocked(db, sqlite)
+	if err != nil || len(bs) != 0 {
+		t.Errorf("Blocked after old checked out = %+v, %v, want none", bs, err)
+	}
+}
+
+func TestRegisteredChange(t *testing.T) {
+	isolate(t)
+	Register("20240101T000000Z", "add_email", empty, empty, Expand())
+	Register("20240102T000000Z", "drop_login", empty, empty, Contract("20240101T000000Z"))
+
+	var have []string
+	for _, info := range Registered() {
+		have = append(have, info.Change)
+	}
+
+	want := []string{"", "expand", "contract"}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("changes = %q, want %q", have, want)
+	}
+
+	if m := migrations["20240102T000000Z"]; m.phase != PhasePost {
+		t.Errorf("contract phase = %v, want %v", m.phase, PhasePost)
+	}
+}
@@ -0,0 +1,149 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// electInterval is the delay between the campaigns of an instance that
+// was not elected to perform a run.
+const electInterval = time.Second
+
+// A Coordinator elects the one instance of a fleet that performs the
+// migrations, such as when every instance of an application migrates on
+// startup. Lead returns true and a function that resigns if the instance
+// is elected, or false without blocking if another instance leads.
+type Coordinator interface {
+	Lead(ctx context.Context) (resign func(), ok bool, err error)
+}
+
+// WithCoordinator performs the run only if the coordinator elects the
+// instance. The other instances wait until the leader has migrated the
+// database to the target version timestamp, campaigning again every
+// second so that one takes over if the leader fails.
+func WithCoordinator(c Coordinator) Option {
+	return func(o *options) {
+		o.coordinator = c
+	}
+}
+
+// elect performs the run if the instance is elected by the coordinator
+// of the run, or otherwise waits until the leader has migrated the
+// database to the target version timestamp.
+func (o *options) elect(db *sql.DB, target string) error {
+	if o.coordinator == nil {
+		return migrateDB(db, target, o)
+	}
+
+	err := o.waitForDB(db)
+	if err != nil {
+		return err
+	}
+
+	for {
+		resign, ok, err := o.coordinator.Lead(o.ctx)
+		if err != nil {
+			return fmt.Errorf("migrator: electing leader: %w", err)
+		}
+
+		if ok {
+			defer resign()
+			return migrateDB(db, target, o)
+		}
+
+		reached, err := o.reached(db, target)
+		if err != nil {
+			return err
+		}
+
+		if reached {
+			o.logf(LevelInfo, "database migrated by another instance")
+			return nil
+		}
+
+		err = o.checkDeadline()
+		if err != nil {
+			return err
+		}
+
+		t := time.NewTimer(electInterval)
+		select {
+		case <-o.ctx.Done():
+			t.Stop()
+			return o.ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// reached returns true if no migrations of the run remain to bring the
+// database to the state of the target version timestamp.
+func (o *options) reached(db *sql.DB, target string) (bool, error) {
+	err := o.createVersions(db)
+	if err != nil {
+		return false, err
+	}
+
+	vs, _, _, err := plan(db, target, o)
+	if err != nil {
+		return false, err
+	}
+
+	return len(vs) == 0, nil
+}
+
+// Queries that try to acquire a lock without blocking by dialect.
+var (
+	queryTryLockPostgres = `SELECT pg_try_advisory_lock($1);`
+	queryTryLockMySQL    = `SELECT GET_LOCK(?, 0);`
+)
+
+// A DBCoordinator elects the instance holding a named lock on the
+// database, a session advisory lock on Postgres and a named lock on
+// MySQL. SQLite databases are local to one instance, which is always
+// elected.
+type DBCoordinator struct {
+	db      *sql.DB
+	dialect Dialect
+	name    string
+}
+
+// NewDBCoordinator returns a coordinator holding the named lock on the
+// database of the dialect. The name must differ from the lock of
+// WithLock, which the leader takes separately.
+func NewDBCoordinator(db *sql.DB, d Dialect, name string) *DBCoordinator {
+	return &DBCoordinator{db: db, dialect: d, name: name}
+}
+
+// Lead tries to acquire the lock on a connection held until resigning.
+func (c *DBCoordinator) Lead(ctx context.Context) (func(), bool, error) {
+	var lock, unlock string
+	var key interface{}
+	switch c.dialect {
+	case Postgres:
+		lock, unlock, key = queryTryLockPostgres, queryUnlockPostgres, lockKey(c.name)
+	case MySQL:
+		lock, unlock, key = queryTryLockMySQL, queryUnlockMySQL, c.name
+	default:
+		return func() {}, true, nil
+	}
+
+	conn, err := c.db.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var granted sql.NullString
+	err = conn.QueryRowContext(ctx, lock, key).Scan(&granted)
+	if err != nil || (granted.String != "1" && granted.String != "true") {
+		conn.Close()
+		return nil, false, err
+	}
+
+	return func() {
+		conn.QueryRowContext(context.WithoutCancel(ctx), unlock, key).Scan(&granted)
+		conn.Close()
+	}, true, nil
+}
@@ -0,0 +1,83 @@
+package migrator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// testCoordinator elects the instance when leader is true.
+type testCoordinator struct {
+	leader   bool
+	err      error
+	campaign int
+	resigned bool
+}
+
+func (c *testCoordinator) Lead(ctx context.Context) (func(), bool, error) {
+	c.campaign++
+	if c.err != nil || !c.leader {
+		return nil, false, c.err
+	}
+
+	return func() { c.resigned = true }, true, nil
+}
+
+func TestWithCoordinatorLeader(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+
+	db := openTestDB(t)
+	c := &testCoordinator{leader: true}
+	err := Migrate(db, "", WithDialect(SQLite), WithCoordinator(c))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !tableExists(t, db, "users") || !c.resigned {
+		t.Errorf("leader migrated %t and resigned %t, want both", tableExists(t, db, "users"), c.resigned)
+	}
+}
+
+func TestWithCoordinatorFollower(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+	err := Migrate(db, "", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &testCoordinator{}
+	err = Migrate(db, "", sqlite, WithCoordinator(c))
+	if err != nil || c.campaign != 1 {
+		t.Errorf("follower of a migrated database = %v after %d campaigns, want nil after 1", err, c.campaign)
+	}
+
+	registerTables(t, map[string]string{"20240102T000000Z": "posts"})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err = Migrate(db, "", sqlite, WithCoordinator(c), WithContext(ctx))
+	if !errors.Is(err, context.DeadlineExceeded) || tableExists(t, db, "posts") {
+		t.Errorf("follower of a pending database error = %v, want it to wait for the leader", err)
+	}
+
+	err = Migrate(db, "", sqlite, WithCoordinator(&testCoordinator{err: errors.New("unavailable")}))
+	if err == nil || err.Error() != "migrator: electing leader: unavailable" {
+		t.Errorf("Migrate with a failing coordinator error = %v", err)
+	}
+}
+
+func TestDBCoordinatorSQLite(t *testing.T) {
+	c := NewDBCoordinator(openTestDB(t), SQLite, "migrator_leader")
+	resign, ok, err := c.Lead(context.Background())
+	if err != nil || !ok {
+		t.Fatalf("Lead = %t, %v, want elected", ok, err)
+	}
+
+	resign()
+}
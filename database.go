@@ -0,0 +1,57 @@
+package migrator
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CreateDatabase creates the named database on the server of the url and
+// returns the url of the new database and a function that drops it. In
+// SQLite, the database is a file in a new temporary directory that the
+// function removes.
+func CreateDatabase(rawurl, name string) (string, func() error, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return "", nil, err
+	}
+
+	switch u.Scheme {
+	case "sqlite", "sqlite3", "file":
+		dir, err := os.MkdirTemp("", "migrator")
+		if err != nil {
+			return "", nil, err
+		}
+
+		return "sqlite://" + filepath.Join(dir, name+".db"), func() error { return os.RemoveAll(dir) }, nil
+	}
+
+	quoted := ident(name)
+	if u.Scheme == "mysql" {
+		quoted = "`" + strings.Replace(name, "`", "``", -1) + "`"
+	}
+
+	err = admin(rawurl, "CREATE DATABASE "+quoted)
+	if err != nil {
+		return "", nil, err
+	}
+
+	target := *u
+	target.Path = "/" + name
+	return target.String(), func() error { return admin(rawurl, "DROP DATABASE IF EXISTS "+quoted) }, nil
+}
+
+// admin executes the statement on the database of the url, such as to
+// create or drop another database on its server.
+func admin(rawurl, stmt string) error {
+	db, _, err := Open(rawurl)
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	_, err = db.Exec(stmt)
+	return err
+}
@@ -0,0 +1,127 @@
+package migrator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A ColumnType is an abstract column type that is rendered as the
+// appropriate SQL type of each dialect.
+type ColumnType int
+
+// Column types.
+const (
+	Serial    ColumnType = iota // auto-incrementing integer key
+	Integer                     // 32-bit integer
+	BigInt                      // 64-bit integer
+	Float                       // double precision floating point
+	Boolean                     // true or false
+	String                      // variable length string of up to 255 characters
+	Text                        // unbounded string
+	Bytes                       // binary data
+	Timestamp                   // date and time
+	JSON                        // JSON document
+)
+
+// columnTypes are the SQL types of each column type in Postgres, MySQL
+// and SQLite order.
+var columnTypes = map[ColumnType][3]string{
+	Serial:    {"BIGSERIAL", "BIGINT AUTO_INCREMENT", "INTEGER"},
+	Integer:   {"INTEGER", "INT", "INTEGER"},
+	BigInt:    {"BIGINT", "BIGINT", "INTEGER"},
+	Float:     {"DOUBLE PRECISION", "DOUBLE", "REAL"},
+	Boolean:   {"BOOLEAN", "BOOLEAN", "BOOLEAN"},
+	String:    {"VARCHAR(255)", "VARCHAR(255)", "TEXT"},
+	Text:      {"TEXT", "LONGTEXT", "TEXT"},
+	Bytes:     {"BYTEA", "LONGBLOB", "BLOB"},
+	Timestamp: {"TIMESTAMP", "DATETIME", "TIMESTAMP"},
+	JSON:      {"JSONB", "JSON", "TEXT"},
+}
+
+// sql returns the SQL type of the column type in the dialect.
+func (t ColumnType) sql(d Dialect) string {
+	types, ok := columnTypes[t]
+	if !ok || d < Postgres || d > SQLite {
+		panic(fmt.Sprintf("migrator: unknown column type %d for %s", t, d))
+	}
+
+	return types[d]
+}
+
+// A Column is a column of a table. The name and default are interpolated
+// into statements and must be trusted.
+type Column struct {
+	Name       string     // name of the column
+	Type       ColumnType // abstract type of the column
+	Null       bool       // whether or not the column is nullable
+	Default    string     // SQL expression of the default value, if any
+	PrimaryKey bool       // whether or not the column is the primary key
+	Unique     bool       // whether or not the column is unique
+}
+
+// sql returns the column definition in the dialect.
+func (c Column) sql(d Dialect) string {
+	s := c.Name + " " + c.Type.sql(d)
+	if c.PrimaryKey {
+		s += " PRIMARY KEY"
+		if d == SQLite && c.Type == Serial {
+			s += " AUTOINCREMENT"
+		}
+	} else if !c.Null {
+		s += " NOT NULL"
+	}
+
+	if c.Unique {
+		s += " UNIQUE"
+	}
+
+	if c.Default != "" {
+		s += " DEFAULT " + c.Default
+	}
+
+	return s
+}
+
+// CreateTable returns the statement that creates the table with the
+// columns in the dialect.
+func CreateTable(d Dialect, name string, cols ...Column) string {
+	defs := make([]string, len(cols))
+	for i, c := range cols {
+		defs[i] = "  " + c.sql(d)
+	}
+
+	return fmt.Sprintf("CREATE TABLE %s (\n%s\n);", name, strings.Join(defs, ",\n"))
+}
+
+// DropTable returns the statement that drops the table in the dialect.
+func DropTable(d Dialect, name string) string {
+	return fmt.Sprintf("DROP TABLE %s;", name)
+}
+
+// AddColumn returns the statement that adds the column to the table in
+// the dialect.
+func AddColumn(d Dialect, table string, c Column) string {
+	return fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;", table, c.sql(d))
+}
+
+// DropColumn returns the statement that drops the column from the table
+// in the dialect. SQLite supports dropping columns since version 3.35.
+func DropColumn(d Dialect, table, column string) string {
+	return fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", table, column)
+}
+
+// AddIndex returns the statement that creates the index in the dialect.
+// Use CreateIndexConcurrently to build indexes on large tables without
+// blocking writes.
+func AddIndex(d Dialect, idx Index) string {
+	return fmt.Sprintf("CREATE %sINDEX %s ON %s (%s);", unique(idx), idx.Name, idx.Table, idx.Columns)
+}
+
+// DropIndex returns the statement that drops the index in the dialect.
+func DropIndex(d Dialect, idx Index) string {
+	if d == MySQL {
+		return fmt.Sprintf("DROP INDEX %s ON %s;", idx.Name, idx.Table)
+	}
+
+	return fmt.Sprintf("DROP INDEX %s;", idx.Name)
+}
@@ -0,0 +1,79 @@
+package migrator
+
+import "testing"
+
+func TestCreateTable(t *testing.T) {
+	cols := []Column{
+		{Name: "id", Type: Serial, PrimaryKey: true},
+		{Name: "email", Type: String, Unique: true},
+		{Name: "bio", Type: Text, Null: true},
+		{Name: "created_at", Type: Timestamp, Default: "CURRENT_TIMESTAMP"},
+	}
+
+	tests := []struct {
+		d    Dialect
+		want string
+	}{
+		{Postgres, "CREATE TABLE users (\n  id BIGSERIAL PRIMARY KEY,\n  email VARCHAR(255) NOT NULL UNIQUE,\n  bio TEXT,\n  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP\n);"},
+		{MySQL, "CREATE TABLE users (\n  id BIGINT AUTO_INCREMENT PRIMARY KEY,\n  email VARCHAR(255) NOT NULL UNIQUE,\n  bio LONGTEXT,\n  created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP\n);"},
+		{SQLite, "CREATE TABLE users (\n  id INTEGER PRIMARY KEY AUTOINCREMENT,\n  email TEXT NOT NULL UNIQUE,\n  bio TEXT,\n  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP\n);"},
+	}
+
+	for _, tt := range tests {
+		have := CreateTable(tt.d, "users", cols...)
+		if have != tt.want {
+			t.Errorf("CreateTable(%s) =\n%s\nwant\n%s", tt.d, have, tt.want)
+		}
+	}
+}
+
+func TestDDLSQLite(t *testing.T) {
+	db := openTestDB(t)
+	idx := Index{Name: "users_email_idx", Table: "users", Columns: "email", Unique: true}
+
+	stmts := []string{
+		CreateTable(SQLite, "users", Column{Name: "id", Type: Serial, PrimaryKey: true}, Column{Name: "email", Type: String}),
+		AddColumn(SQLite, "users", Column{Name: "data", Type: JSON, Null: true}),
+		AddIndex(SQLite, idx),
+		DropIndex(SQLite, idx),
+		DropColumn(SQLite, "users", "data"),
+		DropTable(SQLite, "users"),
+	}
+
+	for _, stmt := range stmts {
+		_, err := db.Exec(stmt)
+		if err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+}
+
+func TestDropIndex(t *testing.T) {
+	idx := Index{Name: "users_email_idx", Table: "users"}
+
+	tests := []struct {
+		d    Dialect
+		want string
+	}{
+		{Postgres, "DROP INDEX users_email_idx;"},
+		{MySQL, "DROP INDEX users_email_idx ON users;"},
+		{SQLite, "DROP INDEX users_email_idx;"},
+	}
+
+	for _, tt := range tests {
+		have := DropIndex(tt.d, idx)
+		if have != tt.want {
+			t.Errorf("DropIndex(%s) = %q, want %q", tt.d, have, tt.want)
+		}
+	}
+}
+
+func TestColumnTypeUnknown(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("unknown column type did not panic")
+		}
+	}()
+
+	ColumnType(-1).sql(Postgres)
+}
@@ -0,0 +1,44 @@
+package migrator
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// WithRunTimeout bounds the run to d. Once d elapses, the run stops
+// before the next migration with an error, leaving the migration in
+// progress to complete, and waiting for the lock of WithLock or for
+// another instance elected by WithCoordinator is abandoned.
+func WithRunTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.runTimeout = d
+	}
+}
+
+// startDeadline starts the timeout of the run, if any.
+func (o *options) startDeadline() {
+	o.deadline = time.Time{}
+	if o.runTimeout > 0 {
+		o.deadline = o.now().Add(o.runTimeout)
+	}
+}
+
+// checkDeadline returns an error if the timeout of the run has elapsed.
+func (o *options) checkDeadline() error {
+	if o.deadline.IsZero() || o.now().Before(o.deadline) {
+		return nil
+	}
+
+	return fmt.Errorf("migrator: run timeout of %s elapsed", o.runTimeout)
+}
+
+// waitContext returns the context of the run bounded by its timeout, if
+// any, for waits that would otherwise block the run indefinitely.
+func (o *options) waitContext() (context.Context, context.CancelFunc) {
+	if o.deadline.IsZero() {
+		return context.WithCancel(o.ctx)
+	}
+
+	return context.WithTimeout(o.ctx, o.deadline.Sub(o.now()))
+}
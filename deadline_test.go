@@ -0,0 +1,70 @@
+package migrator
+
+import (
+	"database/sql"
+	"strings"
+	"testing"
+	"time"
+)
+
+// manualClock is a Clock whose time is advanced by the test.
+type manualClock struct {
+	t time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	return c.t
+}
+
+func TestWithRunTimeout(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240102T000000Z": "posts"})
+
+	c := &manualClock{t: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	Register("20240101T000000Z", "slow", func(tx *sql.Tx) error {
+		c.t = c.t.Add(2 * time.Minute)
+		return nil
+	}, empty)
+
+	db := openTestDB(t)
+	opts := []Option{WithDialect(SQLite), WithClock(c), WithRunTimeout(time.Minute)}
+	err := Migrate(db, "", opts...)
+	if err == nil || !strings.Contains(err.Error(), "run timeout of 1m0s elapsed") {
+		t.Fatalf("Migrate error = %v, want the run timeout", err)
+	}
+
+	v, err := Current(db, opts...)
+	if err != nil || v != "20240101T000000Z" {
+		t.Errorf("Current = %q, %v, want the migration in progress completed", v, err)
+	}
+
+	if tableExists(t, db, "posts") {
+		t.Error("migration performed after the run timeout elapsed")
+	}
+
+	err = Migrate(db, "", opts...)
+	if err != nil {
+		t.Errorf("next run error = %v, want the timeout restarted", err)
+	}
+}
+
+func TestWaitContext(t *testing.T) {
+	o := newOptions(nil)
+	o.startDeadline()
+	ctx, cancel := o.waitContext()
+	defer cancel()
+
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("wait without a run timeout has a deadline")
+	}
+
+	o = newOptions([]Option{WithRunTimeout(time.Minute)})
+	o.startDeadline()
+	ctx, cancel = o.waitContext()
+	defer cancel()
+
+	deadline, ok := ctx.Deadline()
+	if !ok || time.Until(deadline) > time.Minute {
+		t.Errorf("wait deadline = %v, want within the run timeout", deadline)
+	}
+}
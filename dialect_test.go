@@ -0,0 +1,122 @@
+package migrator
+
+import (
+	"net/url"
+	"path/filepath"
+	"testing"
+)
+
+func TestDialectString(t *testing.T) {
+	tests := []struct {
+		d    Dialect
+		want string
+	}{
+		{Postgres, "postgres"},
+		{MySQL, "mysql"},
+		{SQLite, "sqlite"},
+		{Dialect(-1), "unknown"},
+	}
+
+	for _, tt := range tests {
+		got := tt.d.String()
+		if got != tt.want {
+			t.Errorf("Dialect(%d).String() = %q, want %q", int(tt.d), got, tt.want)
+		}
+	}
+}
+
+func TestRebind(t *testing.T) {
+	query := "SELECT $1 FROM t WHERE a = $2 AND b = $10;"
+
+	tests := []struct {
+		d    Dialect
+		want string
+	}{
+		{Postgres, query},
+		{MySQL, "SELECT ? FROM t WHERE a = ? AND b = ?;"},
+		{SQLite, "SELECT ? FROM t WHERE a = ? AND b = ?;"},
+	}
+
+	for _, tt := range tests {
+		have := tt.d.rebind(query)
+		if have != tt.want {
+			t.Errorf("%s.rebind = %q, want %q", tt.d, have, tt.want)
+		}
+	}
+}
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, d, err := Open("sqlite://" + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if d != SQLite {
+		t.Errorf("dialect = %s, want sqlite", d)
+	}
+
+	var fk int
+	err = db.QueryRow("PRAGMA foreign_keys;").Scan(&fk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fk != 1 {
+		t.Error("foreign keys not enforced")
+	}
+
+	_, _, err = Open("oracle://localhost/db")
+	if err == nil {
+		t.Error("Open of an unsupported scheme error = nil")
+	}
+}
+
+func TestMySQLDSN(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"mysql://app:secret@db/app", "app:secret@tcp(db:3306)/app?multiStatements=true&parseTime=true"},
+		{"mysql://app@db:3307/app?tls=true", "app@tcp(db:3307)/app?multiStatements=true&parseTime=true&tls=true"},
+		{"mysql://app:p%40ss%3Aw%2Frd%25@db/app", "app:p@ss:w/rd%@tcp(db:3306)/app?multiStatements=true&parseTime=true"},
+		{"mysql://db/app", "@tcp(db:3306)/app?multiStatements=true&parseTime=true"},
+	}
+
+	for _, tt := range tests {
+		u, err := url.Parse(tt.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		have := mysqlDSN(u)
+		if have != tt.want {
+			t.Errorf("mysqlDSN(%q) = %q, want %q", tt.url, have, tt.want)
+		}
+	}
+}
+
+func TestSQLiteDSN(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"sqlite:///var/db/app.db", "file:/var/db/app.db?_foreign_keys=on"},
+		{"sqlite://app.db?cache=shared", "file:app.db?_foreign_keys=on&cache=shared"},
+		{"file:app.db", "file:app.db?_foreign_keys=on"},
+	}
+
+	for _, tt := range tests {
+		u, err := url.Parse(tt.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		have := sqliteDSN(u)
+		if have != tt.want {
+			t.Errorf("sqliteDSN(%q) = %q, want %q", tt.url, have, tt.want)
+		}
+	}
+}
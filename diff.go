@@ -0,0 +1,118 @@
+package migrator
+
+import (
+	"fmt"
+	"sort"
+)
+
+// A Difference is a migration whose applied state differs between two
+// databases. Left and Right are the applied migrations of each database,
+// or nil if it is not applied there.
+type Difference struct {
+	Version string `json:"version"`
+	Name    string `json:"name"`
+	Left    *Info  `json:"left,omitempty"`
+	Right   *Info  `json:"right,omitempty"`
+}
+
+// Reason returns a description of the difference.
+func (d Difference) Reason() string {
+	switch {
+	case d.Right == nil:
+		return "only in left"
+	case d.Left == nil:
+		return "only in right"
+	}
+
+	return "checksum differs"
+}
+
+// Diff opens the databases at the urls and returns the migrations that
+// are applied to only one of them or applied with different checksums,
+// in ascending order by version timestamp. The dialect of each database
+// is detected from its url.
+func Diff(left, right string, opts ...Option) ([]Difference, error) {
+	l, err := appliedURL(left, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := appliedURL(right, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return Compare(l, r), nil
+}
+
+// appliedURL opens the database at the url and returns its applied
+// migrations.
+func appliedURL(rawurl string, opts []Option) ([]*Info, error) {
+	db, d, err := Open(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	defer db.Close()
+
+	rv, err := Applied(db, append(append([]Option{}, opts...), WithDialect(d))...)
+	if err != nil {
+		return nil, fmt.Errorf("migrator: %s: %w", d, err)
+	}
+
+	return rv, nil
+}
+
+// Compare returns the migrations that are applied to only one of the
+// databases or applied with different checksums, in ascending order by
+// version timestamp, given the applied migrations of each database.
+// Checksums are only compared when both are recorded.
+func Compare(left, right []*Info) []Difference {
+	var rv []Difference
+	ls, rs := index(left), index(right)
+	for _, v := range union(ls, rs) {
+		l, r := ls[v], rs[v]
+		if l != nil && r != nil && (l.Checksum == "" || r.Checksum == "" || l.Checksum == r.Checksum) {
+			continue
+		}
+
+		d := Difference{Version: v, Left: l, Right: r}
+		if l != nil {
+			d.Name = l.Name
+		} else {
+			d.Name = r.Name
+		}
+
+		rv = append(rv, d)
+	}
+
+	return rv
+}
+
+// index returns the migrations by version timestamp.
+func index(infos []*Info) map[string]*Info {
+	rv := make(map[string]*Info, len(infos))
+	for _, info := range infos {
+		rv[info.Version] = info
+	}
+
+	return rv
+}
+
+// union returns the version timestamps of both maps in ascending order.
+func union(a, b map[string]*Info) []string {
+	var rv []string
+	for v := range a {
+		rv = append(rv, v)
+	}
+
+	for v := range b {
+		if _, ok := a[v]; !ok {
+			rv = append(rv, v)
+		}
+	}
+
+	sort.Strings(rv)
+
+	return rv
+}
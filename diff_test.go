@@ -0,0 +1,103 @@
+package migrator
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	a := &Info{Version: "20240101T000000Z", Name: "a", Checksum: "1"}
+	b := &Info{Version: "20240102T000000Z", Name: "b", Checksum: "2"}
+	bChanged := &Info{Version: "20240102T000000Z", Name: "b", Checksum: "3"}
+	bUnrecorded := &Info{Version: "20240102T000000Z", Name: "b"}
+	c := &Info{Version: "20240103T000000Z", Name: "c"}
+
+	tests := []struct {
+		left, right []*Info
+		want        []Difference
+	}{
+		{[]*Info{a, b}, []*Info{a, b}, nil},
+		{[]*Info{a, b}, []*Info{a, bUnrecorded}, nil},
+		{[]*Info{a, c}, []*Info{a, b}, []Difference{
+			{Version: b.Version, Name: "b", Right: b},
+			{Version: c.Version, Name: "c", Left: c},
+		}},
+		{[]*Info{b}, []*Info{bChanged}, []Difference{
+			{Version: b.Version, Name: "b", Left: b, Right: bChanged},
+		}},
+	}
+
+	for i, tt := range tests {
+		have := Compare(tt.left, tt.right)
+		if !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("%d. Compare = %+v, want %+v", i, have, tt.want)
+		}
+	}
+}
+
+func TestDifferenceReason(t *testing.T) {
+	info := &Info{}
+	tests := []struct {
+		d    Difference
+		want string
+	}{
+		{Difference{Left: info}, "only in left"},
+		{Difference{Right: info}, "only in right"},
+		{Difference{Left: info, Right: info}, "checksum differs"},
+	}
+
+	for _, tt := range tests {
+		have := tt.d.Reason()
+		if have != tt.want {
+			t.Errorf("Reason = %q, want %q", have, tt.want)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	isolate(t)
+	Register("20240101T000000Z", "a", empty, empty)
+
+	dir := t.TempDir()
+	left := "sqlite://" + filepath.Join(dir, "left.db")
+	right := "sqlite://" + filepath.Join(dir, "right.db")
+
+	db, _, err := Open(left)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	err = Migrate(db, "", WithDialect(SQLite))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other, _, err := Open(right)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer other.Close()
+
+	_, err = Current(other, WithDialect(SQLite))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ds, err := Diff(left, right)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(ds) != 2 || ds[0].Version != NilVersion || ds[1].Version != "20240101T000000Z" || ds[1].Right != nil {
+		t.Errorf("Diff = %+v, want both versions only in left", ds)
+	}
+
+	ds, err = Diff(left, left)
+	if err != nil || len(ds) != 0 {
+		t.Errorf("Diff of the same database = %+v, %v", ds, err)
+	}
+}
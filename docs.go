@@ -0,0 +1,197 @@
+package migrator
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// WriteMarkdown writes the documentation of the tables of the schema as
+// Markdown, preceded by a mermaid entity relationship diagram if diagram
+// is true.
+func (s *Schema) WriteMarkdown(w io.Writer, diagram bool) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# Schema\n\n")
+	if s.Version != "" {
+		fmt.Fprintf(bw, "Version `%s`.\n\n", s.Version)
+	}
+
+	if diagram {
+		fmt.Fprintf(bw, "```mermaid\n")
+		s.mermaid(bw)
+		fmt.Fprintf(bw, "```\n\n")
+	}
+
+	for _, t := range s.Tables {
+		fmt.Fprintf(bw, "## %s\n\n", t.Name)
+		if t.Comment != "" {
+			fmt.Fprintf(bw, "%s\n\n", t.Comment)
+		}
+
+		fmt.Fprintf(bw, "| Column | Type | Nullable | Default | Comment |\n")
+		fmt.Fprintf(bw, "| --- | --- | --- | --- | --- |\n")
+		for _, c := range t.Columns {
+			fmt.Fprintf(bw, "| %s | %s | %s | %s | %s |\n", cell(c.Name), cell(c.Type), yesNo(c.Nullable), cell(c.Default), cell(c.Comment))
+		}
+
+		if len(t.Indexes) > 0 {
+			fmt.Fprintf(bw, "\nIndexes:\n\n")
+			for _, idx := range t.Indexes {
+				fmt.Fprintf(bw, "- `%s`\n", idx)
+			}
+		}
+
+		if len(t.ForeignKeys) > 0 {
+			fmt.Fprintf(bw, "\nForeign keys:\n\n")
+			for _, fk := range t.ForeignKeys {
+				fmt.Fprintf(bw, "- `%s` %s\n", fk.Name, fk)
+			}
+		}
+
+		fmt.Fprintln(bw)
+	}
+
+	return bw.Flush()
+}
+
+// cell returns the text escaped for a cell of a Markdown table.
+func cell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+// yesNo returns "yes" if b is true or "no" otherwise.
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+
+	return "no"
+}
+
+// docsHTML is the template of the HTML documentation of a schema.
+var docsHTML = template.Must(template.New("schema").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Schema</title>
+{{- if .Diagram}}
+<script type="module">import mermaid from "https://cdn.jsdelivr.net/npm/mermaid@10/dist/mermaid.esm.min.mjs"; mermaid.initialize({startOnLoad: true});</script>
+{{- end}}
+</head>
+<body>
+<h1>Schema</h1>
+{{- if .Schema.Version}}
+<p>Version <code>{{.Schema.Version}}</code>.</p>
+{{- end}}
+{{- if .Diagram}}
+<pre class="mermaid">
+{{.Mermaid}}</pre>
+{{- end}}
+{{- range .Schema.Tables}}
+<h2 id="{{.Name}}">{{.Name}}</h2>
+{{- if .Comment}}
+<p>{{.Comment}}</p>
+{{- end}}
+<table>
+<tr><th>Column</th><th>Type</th><th>Nullable</th><th>Default</th><th>Comment</th></tr>
+{{- range .Columns}}
+<tr><td>{{.Name}}</td><td>{{.Type}}</td><td>{{if .Nullable}}yes{{else}}no{{end}}</td><td>{{.Default}}</td><td>{{.Comment}}</td></tr>
+{{- end}}
+</table>
+{{- if .Indexes}}
+<p>Indexes:</p>
+<ul>
+{{- range .Indexes}}
+<li><code>{{.}}</code></li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .ForeignKeys}}
+<p>Foreign keys:</p>
+<ul>
+{{- range .ForeignKeys}}
+<li><code>{{.Name}}</code> ({{range $i, $c := .Columns}}{{if $i}}, {{end}}{{$c}}{{end}}) references <a href="#{{.Table}}">{{.Table}}</a> ({{range $i, $c := .References}}{{if $i}}, {{end}}{{$c}}{{end}})</li>
+{{- end}}
+</ul>
+{{- end}}
+{{- end}}
+</body>
+</html>
+`))
+
+// WriteHTML writes the documentation of the tables of the schema as an
+// HTML page, including a mermaid entity relationship diagram if diagram
+// is true.
+func (s *Schema) WriteHTML(w io.Writer, diagram bool) error {
+	var b strings.Builder
+	if diagram {
+		s.mermaid(&b)
+	}
+
+	return docsHTML.Execute(w, struct {
+		Schema  *Schema
+		Diagram bool
+		Mermaid string
+	}{s, diagram, b.String()})
+}
+
+// WriteMermaid writes a mermaid entity relationship diagram of the
+// tables of the schema.
+func (s *Schema) WriteMermaid(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	s.mermaid(bw)
+	return bw.Flush()
+}
+
+// nonWord matches the characters that are not allowed in the names and
+// types of a mermaid diagram.
+var nonWord = regexp.MustCompile(`\W+`)
+
+// mermaid writes a mermaid entity relationship diagram of the schema.
+func (s *Schema) mermaid(w io.Writer) {
+	fmt.Fprintf(w, "erDiagram\n")
+	for _, t := range s.Tables {
+		fmt.Fprintf(w, "  %s {\n", nonWord.ReplaceAllString(t.Name, "_"))
+		for _, c := range t.Columns {
+			fmt.Fprintf(w, "    %s %s\n", nonWord.ReplaceAllString(c.Type, "_"), nonWord.ReplaceAllString(c.Name, "_"))
+		}
+		fmt.Fprintf(w, "  }\n")
+	}
+
+	for _, t := range s.Tables {
+		for _, fk := range t.ForeignKeys {
+			fmt.Fprintf(w, "  %s }o--|| %s : %q\n", nonWord.ReplaceAllString(t.Name, "_"), nonWord.ReplaceAllString(fk.Table, "_"), strings.Join(fk.Columns, ", "))
+		}
+	}
+}
+
+// dotEscaper escapes the special characters of a record label of a DOT
+// graph.
+var dotEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "{", `\{`, "}", `\}`, "|", `\|`, "<", `\<`, ">", `\>`)
+
+// WriteDOT writes a Graphviz DOT entity relationship diagram of the
+// tables of the schema.
+func (s *Schema) WriteDOT(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "digraph schema {\n  rankdir=LR;\n  node [shape=record];\n")
+	for _, t := range s.Tables {
+		var cols []string
+		for _, c := range t.Columns {
+			cols = append(cols, dotEscaper.Replace(c.Name+" : "+c.Type)+`\l`)
+		}
+		fmt.Fprintf(bw, "  %q [label=\"{%s|%s}\"];\n", t.Name, dotEscaper.Replace(t.Name), strings.Join(cols, ""))
+	}
+
+	for _, t := range s.Tables {
+		for _, fk := range t.ForeignKeys {
+			fmt.Fprintf(bw, "  %q -> %q [label=%q];\n", t.Name, fk.Table, strings.Join(fk.Columns, ", "))
+		}
+	}
+
+	fmt.Fprintf(bw, "}\n")
+	return bw.Flush()
+}
@@ -0,0 +1,111 @@
+package migrator
+
+import (
+	"strings"
+	"testing"
+)
+
+// testSchema returns a schema of users and their orders.
+func testSchema() *Schema {
+	return &Schema{
+		Version: "20240101T000000Z",
+		Tables: []*Table{
+			{
+				Name:    "orders",
+				Columns: []*ColumnInfo{{Name: "user_id", Type: "bigint"}},
+				ForeignKeys: []*ForeignKey{
+					{Name: "orders_user_fk", Columns: []string{"user_id"}, Table: "users", References: []string{"id"}},
+				},
+			},
+			{
+				Name:    "users",
+				Comment: "People with accounts.",
+				Columns: []*ColumnInfo{
+					{Name: "id", Type: "bigint"},
+					{Name: "note", Type: "character varying", Nullable: true, Comment: "a | b"},
+				},
+				Indexes: []string{"CREATE INDEX users_id_idx ON users (id)"},
+			},
+		},
+	}
+}
+
+func TestWriteMarkdown(t *testing.T) {
+	var b strings.Builder
+	err := testSchema().WriteMarkdown(&b, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := b.String()
+	for _, want := range []string{
+		"Version `20240101T000000Z`.",
+		"```mermaid\nerDiagram\n",
+		"## users\n\nPeople with accounts.\n\n",
+		"| note | character varying | yes |  | a \\| b |\n",
+		"- `CREATE INDEX users_id_idx ON users (id)`\n",
+		"- `orders_user_fk` ",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("WriteMarkdown missing %q in\n%s", want, out)
+		}
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	var b strings.Builder
+	err := testSchema().WriteHTML(&b, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := b.String()
+	if strings.Contains(out, "mermaid") {
+		t.Error("WriteHTML without a diagram includes mermaid")
+	}
+
+	want := `<li><code>orders_user_fk</code> (user_id) references <a href="#users">users</a> (id)</li>`
+	if !strings.Contains(out, want) || !strings.Contains(out, `<h2 id="users">users</h2>`) {
+		t.Errorf("WriteHTML missing the linked foreign key in\n%s", out)
+	}
+}
+
+func TestWriteMermaid(t *testing.T) {
+	var b strings.Builder
+	err := testSchema().WriteMermaid(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := `erDiagram
+  orders {
+    bigint user_id
+  }
+  users {
+    bigint id
+    character_varying note
+  }
+  orders }o--|| users : "user_id"
+`
+	if b.String() != want {
+		t.Errorf("WriteMermaid = %q, want %q", b.String(), want)
+	}
+}
+
+func TestWriteDOT(t *testing.T) {
+	var b strings.Builder
+	err := testSchema().WriteDOT(&b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := b.String()
+	for _, want := range []string{
+		`"users" [label="{users|id : bigint\lnote : character varying\l}"];`,
+		`"orders" -> "users" [label="user_id"];`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("WriteDOT missing %q in\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package migrator
+
+import "strings"
+
+// downColumn is the definition of the down_sql column by dialect. MySQL
+// does not allow TEXT columns to have a default.
+var downColumn = map[Dialect]string{
+	Postgres: "TEXT NOT NULL DEFAULT ''",
+	MySQL:    "TEXT",
+	SQLite:   "TEXT NOT NULL DEFAULT ''",
+}
+
+// queryVersionsDownSave sets the down SQL of a version.
+var queryVersionsDownSave = `
+UPDATE %[1]s
+  SET down_sql = $1
+  WHERE version = $2 AND reverted_at IS NULL;
+`
+
+// WithDownSQL stores the down SQL of each SQL migration the run applies
+// in the down_sql column of the versions table, adding the column if it
+// is missing, so an operator can revert a migration from a database
+// shell when the migrations that applied it are unavailable.
+func WithDownSQL() Option {
+	return func(o *options) {
+		o.downSQL = true
+	}
+}
+
+// saveDown stores the down SQL of the version timestamp if the run stores
+// the down SQL of migrations.
+func (o *options) saveDown(e Execer, version string) error {
+	if !o.downSQL {
+		return nil
+	}
+
+	_, err := e.ExecContext(o.ctx, o.query(queryVersionsDownSave), migrations[version].script(false), version)
+	return err
+}
+
+// script returns the statements of the migration in the direction as a
+// script of terminated statements, or an empty string if it is not a SQL
+// migration.
+func (m *migration) script(up bool) string {
+	stmts := m.downSQL
+	if up {
+		stmts = m.upSQL
+	}
+
+	var b strings.Builder
+	for _, stmt := range stmts {
+		b.WriteString(stmt)
+		if !strings.HasSuffix(stmt, ";") {
+			b.WriteString(";")
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,84 @@
+package migrator
+
+import "testing"
+
+func TestScript(t *testing.T) {
+	m := &migration{
+		upSQL:   []string{"CREATE TABLE users (id INTEGER)", "CREATE INDEX users_id_idx ON users (id);"},
+		downSQL: []string{"DROP TABLE users;"},
+	}
+
+	want := "CREATE TABLE users (id INTEGER);\nCREATE INDEX users_id_idx ON users (id);\n"
+	if have := m.script(true); have != want {
+		t.Errorf("script(true) = %q, want %q", have, want)
+	}
+
+	want = "DROP TABLE users;\n"
+	if have := m.script(false); have != want {
+		t.Errorf("script(false) = %q, want %q", have, want)
+	}
+
+	if have := (&migration{}).script(false); have != "" {
+		t.Errorf("script of a Go migration = %q, want empty", have)
+	}
+}
+
+func TestWithDownSQL(t *testing.T) {
+	isolate(t)
+	registerSQL("20240101T000000Z", "create_users", []string{"CREATE TABLE users (id INTEGER);"}, []string{"DROP TABLE users;"}, false, nil)
+	registerSQL("20240102T000000Z", "create_posts", []string{"CREATE TABLE posts (id INTEGER);"}, []string{"DROP TABLE posts;"}, true, nil)
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+	err := Migrate(db, "20240101T000000Z", sqlite, WithDownSQL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The column already exists on the second run.
+	err = Migrate(db, "", sqlite, WithDownSQL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{"20240101T000000Z", "DROP TABLE users;\n"},
+		{"20240102T000000Z", "DROP TABLE posts;\n"},
+	}
+
+	for _, tt := range tests {
+		var have string
+		err = db.QueryRow("SELECT down_sql FROM versions WHERE version = ?;", tt.version).Scan(&have)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if have != tt.want {
+			t.Errorf("down_sql of %s = %q, want %q", tt.version, have, tt.want)
+		}
+	}
+}
+
+func TestWithoutDownSQL(t *testing.T) {
+	isolate(t)
+	registerSQL("20240101T000000Z", "create_users", []string{"CREATE TABLE users (id INTEGER);"}, []string{"DROP TABLE users;"}, false, nil)
+
+	db := openTestDB(t)
+	err := Migrate(db, "", WithDialect(SQLite))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	err = db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('versions') WHERE name = 'down_sql';").Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 0 {
+		t.Error("versions table has the down_sql column without WithDownSQL")
+	}
+}
@@ -0,0 +1,122 @@
+package migrator
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"sort"
+)
+
+// WithExpectedSchema sets the schema that CheckDrift expects, usually a
+// snapshot taken after migrating a scratch database to the head version.
+// A schema with only a fingerprint detects drift without describing it.
+func WithExpectedSchema(s *Schema) Option {
+	return func(o *options) {
+		o.expected = s
+	}
+}
+
+// Snapshot returns the schema of the database, excluding the tables of
+// the package such as the versions table, with its version and
+// fingerprint.
+func Snapshot(db *sql.DB, opts ...Option) (*Schema, error) {
+	return snapshot(db, newOptions(opts))
+}
+
+// snapshot returns the schema of the database as Snapshot with the
+// options.
+func snapshot(db *sql.DB, o *options) (*Schema, error) {
+	err := o.createVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := currentVersion(db, o)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := Introspect(db, o.dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	tables := s.Tables[:0]
+	for _, t := range s.Tables {
+		if !o.internal(t.Name) {
+			tables = append(tables, t)
+		}
+	}
+
+	s.Tables = tables
+	s.Version = current
+	s.Fingerprint = s.fingerprint()
+
+	return s, nil
+}
+
+// CheckDrift compares the schema of the database to the schema expected
+// by WithExpectedSchema and returns the objects added, removed or
+// changed outside of migrations, such as by a manual hotfix. The database
+// must be at the version of the expected schema.
+func CheckDrift(db *sql.DB, opts ...Option) ([]string, error) {
+	o := newOptions(opts)
+	if o.expected == nil {
+		return nil, fmt.Errorf("migrator: no expected schema")
+	}
+
+	s, err := Snapshot(db, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if o.expected.Version != "" && s.Version != o.expected.Version {
+		return nil, fmt.Errorf("migrator: database is at version %s but the expected schema is of version %s", s.Version, o.expected.Version)
+	}
+
+	if len(o.expected.Tables) == 0 {
+		if s.Fingerprint == o.expected.Fingerprint {
+			return nil, nil
+		}
+		return []string{fmt.Sprintf("fingerprint changed from %s to %s", o.expected.Fingerprint, s.Fingerprint)}, nil
+	}
+
+	return o.expected.Diff(s), nil
+}
+
+// internal returns true if the table is managed by the package,
+// including the versions tables of the migration sets.
+func (o *options) internal(table string) bool {
+	switch table {
+	case o.table, o.table + "_archive", "repeatables", "seeds", "checkpoints", "instances":
+		return true
+	}
+
+	for _, m := range migrations {
+		if m.set != "" && table == m.set+"_versions" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// fingerprint returns the hex encoded SHA-256 hash of the definitions of
+// the tables, columns and indexes of the schema.
+func (s *Schema) fingerprint() string {
+	objects := s.objects()
+	keys := make([]string, 0, len(objects))
+	for k := range objects {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s\t%s\n", k, objects[k])
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,92 @@
+package migrator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+	err := Migrate(db, "", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.Exec("CREATE TABLE checkpoints (name TEXT);")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := Snapshot(db, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(s.Tables) != 1 || s.Tables[0].Name != "users" {
+		t.Errorf("snapshot tables = %s, want only users", dump(s))
+	}
+
+	if s.Version != "20240101T000000Z" || len(s.Fingerprint) != 64 {
+		t.Errorf("snapshot version %q fingerprint %q", s.Version, s.Fingerprint)
+	}
+}
+
+func TestCheckDrift(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+	err := Migrate(db, "", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = CheckDrift(db, sqlite)
+	if err == nil {
+		t.Error("CheckDrift without an expected schema error = nil")
+	}
+
+	expected, err := Snapshot(db, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fingerprint := &Schema{Version: expected.Version, Fingerprint: expected.Fingerprint}
+	for _, s := range []*Schema{expected, fingerprint} {
+		diff, err := CheckDrift(db, sqlite, WithExpectedSchema(s))
+		if err != nil || len(diff) != 0 {
+			t.Errorf("CheckDrift before the hotfix = %q, %v", diff, err)
+		}
+	}
+
+	_, err = db.Exec("CREATE INDEX users_id_idx ON users (id);")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := CheckDrift(db, sqlite, WithExpectedSchema(expected))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"index users CREATE INDEX users_id_idx ON users (id) added"}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("CheckDrift = %q, want %q", diff, want)
+	}
+
+	diff, err = CheckDrift(db, sqlite, WithExpectedSchema(fingerprint))
+	if err != nil || len(diff) != 1 || !strings.HasPrefix(diff[0], "fingerprint changed from "+expected.Fingerprint) {
+		t.Errorf("CheckDrift of the fingerprint = %q, %v", diff, err)
+	}
+
+	_, err = CheckDrift(db, sqlite, WithExpectedSchema(&Schema{Version: "20250101T000000Z"}))
+	if err == nil || !strings.Contains(err.Error(), "expected schema is of version 20250101T000000Z") {
+		t.Errorf("CheckDrift of another version error = %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package migrator
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// DryRun copies the schema of the database without its data, including
+// the versions table, into a temporary database created on the server of
+// the url with DumpSchema and LoadSchema, migrates the copy to the state
+// of the target version timestamp and returns the run. Unlike a run that
+// is rolled back, migrations outside of transactions and statements that
+// commit implicitly are verified as well. Objects that DumpSchema omits
+// must be created by the migrations. The temporary database is dropped
+// afterwards.
+func DryRun(db *sql.DB, url, target string, opts ...Option) (*Run, error) {
+	o := newOptions(opts)
+
+	var b strings.Builder
+	err := DumpSchema(db, &b, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var rv *Run
+	err = o.scratch(url, "migrator_dryrun_", func(clone *sql.DB, so *options) error {
+		err := LoadSchema(clone, strings.NewReader(b.String()), append(opts[:len(opts):len(opts)], WithDialect(so.dialect))...)
+		if err != nil {
+			return fmt.Errorf("migrator: dry run: loading schema: %w", err)
+		}
+
+		so.run = &Run{Target: target, Started: so.now()}
+		rv = so.run
+		err = migrateDB(clone, target, so)
+		rv.Finished = so.now()
+		if err != nil {
+			rv.Error = err.Error()
+			return fmt.Errorf("migrator: dry run: %w", err)
+		}
+
+		return nil
+	})
+
+	return rv, err
+}
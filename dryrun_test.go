@@ -0,0 +1,74 @@
+package migrator
+
+import (
+	"database/sql"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{
+		"20240101T000000Z": "users",
+		"20240102T000000Z": "posts",
+	})
+	registerSQL("20240103T000000Z", "index_posts", []string{"CREATE INDEX posts_id_idx ON posts (id);"}, []string{"DROP INDEX posts_id_idx;"}, true, nil)
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+	err := Migrate(db, "20240101T000000Z", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run, err := DryRun(db, "sqlite://dryrun", "", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"20240102T000000Z", "20240103T000000Z"}
+	if !reflect.DeepEqual(run.Performed, want) || run.Error != "" || run.Finished.IsZero() {
+		t.Errorf("dry run = %+v, want %v performed", run, want)
+	}
+
+	v, err := Current(db, sqlite)
+	if err != nil || v != "20240101T000000Z" || tableExists(t, db, "posts") {
+		t.Errorf("database after the dry run at %q, %v, want unchanged", v, err)
+	}
+
+	registerSQL("20240104T000000Z", "invalid", []string{"INSERT INTO missing VALUES (1);"}, nil, false, nil)
+	run, err = DryRun(db, "sqlite://dryrun", "", sqlite, WithLogger(&testLogger{}))
+	if err == nil || !strings.HasPrefix(err.Error(), "migrator: dry run:") {
+		t.Fatalf("dry run error = %v, want the failed migration", err)
+	}
+
+	if run == nil || run.Error == "" || len(run.Performed) != 2 {
+		t.Errorf("dry run = %+v, want the run up to the failed migration", run)
+	}
+}
+
+func TestDryRunHooks(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+
+	hook := func(conn *sql.Conn) error {
+		t.Error("hook called by the dry run")
+		return nil
+	}
+
+	_, err := DryRun(openTestDB(t), "sqlite://dryrun", "", WithDialect(SQLite),
+		WithBeforeFunc(hook),
+		WithAfterFunc(hook),
+		WithConfirm(func([]Step) error {
+			t.Error("confirm called by the dry run")
+			return nil
+		}),
+		WithPreflight(func([]Warning) error {
+			t.Error("preflight called by the dry run")
+			return nil
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
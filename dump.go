@@ -0,0 +1,238 @@
+package migrator
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// querySequencesPostgres selects the names of the sequences of the
+// current schema that are not owned by identity columns.
+var querySequencesPostgres = `
+SELECT c.relname FROM pg_class c
+JOIN pg_namespace n ON n.oid = c.relnamespace
+WHERE c.relkind = 'S' AND n.nspname = current_schema()
+  AND NOT EXISTS (SELECT 1 FROM pg_depend d WHERE d.objid = c.oid AND d.deptype = 'i')
+ORDER BY c.relname;
+`
+
+// querySQLiteSchema selects the statements that created the tables,
+// indexes, views and triggers of a SQLite database, tables first.
+var querySQLiteSchema = `
+SELECT sql || ';' FROM sqlite_master
+WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%'
+ORDER BY type <> 'table', rowid;
+`
+
+// autoIncrement matches the next value of the auto increment column of
+// a MySQL table, which is omitted from dumps.
+var autoIncrement = regexp.MustCompile(` AUTO_INCREMENT=\d+`)
+
+// DumpSchema writes the structure of the database as SQL statements
+// followed by the contents of the versions table, so that a new database
+// can be bootstrapped with LoadSchema instead of replaying every
+// migration. The dump of SQLite and MySQL uses the statements recorded
+// by the database. The dump of Postgres is generated from the sequences,
+// tables, columns, primary keys, indexes, foreign keys and comments of
+// the current schema, so other objects such as views, functions and
+// types must be created by migrations that run after loading it.
+func DumpSchema(db *sql.DB, w io.Writer, opts ...Option) error {
+	o := newOptions(opts)
+	ctx := o.ctx
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	err = o.createVersions(conn)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	switch o.dialect {
+	case Postgres:
+		err = dumpPostgres(conn, bw)
+	case MySQL:
+		err = dumpMySQL(ctx, conn, bw)
+	case SQLite:
+		err = dumpQuery(conn, bw, querySQLiteSchema)
+	}
+	if err != nil {
+		return err
+	}
+
+	vs, err := versions(conn, o)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(bw)
+	for _, v := range vs {
+		fmt.Fprintf(bw, "INSERT INTO %s (version, name, skip_reason, checksum, created_at) VALUES (%s, %s, %s, %s, %s);\n",
+			o.table, quote(v.version), quote(v.name), quote(v.skipReason), quote(v.checksum), quote(v.createdAt.UTC().Format("2006-01-02 15:04:05")))
+	}
+
+	return bw.Flush()
+}
+
+// dumpQuery writes the statements selected by the query.
+func dumpQuery(conn Conn, w io.Writer, query string, args ...interface{}) error {
+	return introspectRows(conn, query, func(rows *sql.Rows) error {
+		var stmt string
+		err := rows.Scan(&stmt)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "%s\n\n", stmt)
+		return nil
+	}, args...)
+}
+
+// dumpMySQL writes the statements that create the tables of the MySQL
+// database with foreign key checks disabled.
+func dumpMySQL(ctx context.Context, conn Conn, w io.Writer) error {
+	names, err := Tables(conn, MySQL)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "SET FOREIGN_KEY_CHECKS = 0;\n\n")
+	for _, name := range names {
+		var table, stmt string
+		err := conn.QueryRowContext(ctx, "SHOW CREATE TABLE `"+name+"`").Scan(&table, &stmt)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "%s;\n\n", autoIncrement.ReplaceAllString(stmt, ""))
+	}
+
+	fmt.Fprintf(w, "SET FOREIGN_KEY_CHECKS = 1;\n")
+	return nil
+}
+
+// dumpPostgres writes the statements that create the sequences and
+// tables of the current schema of the Postgres database, then their
+// indexes, foreign keys and comments.
+func dumpPostgres(conn Conn, w io.Writer) error {
+	err := introspectRows(conn, querySequencesPostgres, func(rows *sql.Rows) error {
+		var name string
+		err := rows.Scan(&name)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(w, "CREATE SEQUENCE %s;\n\n", ident(name))
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	s, err := Introspect(conn, Postgres)
+	if err != nil {
+		return err
+	}
+
+	for _, t := range s.Tables {
+		var defs []string
+		for _, c := range t.Columns {
+			def := ident(c.Name) + " " + c.Type
+			if !c.Nullable {
+				def += " NOT NULL"
+			}
+			if c.Default != "" {
+				def += " DEFAULT " + c.Default
+			}
+			defs = append(defs, def)
+		}
+
+		if len(t.PrimaryKey) > 0 {
+			defs = append(defs, "PRIMARY KEY ("+idents(t.PrimaryKey)+")")
+		}
+
+		fmt.Fprintf(w, "CREATE TABLE %s (\n  %s\n);\n\n", ident(t.Name), strings.Join(defs, ",\n  "))
+	}
+
+	for _, t := range s.Tables {
+		for _, idx := range t.Indexes {
+			fmt.Fprintf(w, "%s;\n", idx)
+		}
+	}
+
+	for _, t := range s.Tables {
+		for _, fk := range t.ForeignKeys {
+			fmt.Fprintf(w, "ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s (%s);\n",
+				ident(t.Name), ident(fk.Name), idents(fk.Columns), ident(fk.Table), idents(fk.References))
+		}
+	}
+
+	for _, t := range s.Tables {
+		if t.Comment != "" {
+			fmt.Fprintf(w, "COMMENT ON TABLE %s IS %s;\n", ident(t.Name), quote(t.Comment))
+		}
+		for _, c := range t.Columns {
+			if c.Comment != "" {
+				fmt.Fprintf(w, "COMMENT ON COLUMN %s.%s IS %s;\n", ident(t.Name), ident(c.Name), quote(c.Comment))
+			}
+		}
+	}
+
+	return nil
+}
+
+// idents returns the names as a list of quoted SQL identifiers.
+func idents(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = ident(name)
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
+// LoadSchema executes the statements written by DumpSchema on the
+// database, which should be empty. The statements run in a transaction
+// except on MySQL, whose statements that create tables commit
+// implicitly.
+func LoadSchema(db *sql.DB, r io.Reader, opts ...Option) error {
+	o := newOptions(opts)
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	ctx := o.ctx
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	stmts := splitStatements(string(b))
+	if o.dialect == MySQL {
+		return execStatements(conn, stmts)
+	}
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	err = execStatements(tx, stmts)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
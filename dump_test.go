@@ -0,0 +1,75 @@
+package migrator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDumpSchema(t *testing.T) {
+	isolate(t)
+	registerSQL("20240101T000000Z", "create_users", []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT NOT NULL);",
+		"CREATE INDEX users_email_idx ON users (email);",
+		"CREATE VIEW emails AS SELECT email FROM users;",
+	}, nil, false, nil)
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+	err := Migrate(db, "", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	err = DumpSchema(db, &b, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := b.String()
+	if strings.Index(out, "CREATE TABLE users") > strings.Index(out, "CREATE INDEX users_email_idx") {
+		t.Errorf("dump creates the index before its table:\n%s", out)
+	}
+
+	if !strings.Contains(out, "INSERT INTO versions (version, name, skip_reason, checksum, created_at) VALUES ('20240101T000000Z', 'create_users', '', ") {
+		t.Errorf("dump is missing the applied version:\n%s", out)
+	}
+
+	loaded := openTestDB(t)
+	err = LoadSchema(loaded, strings.NewReader(out), sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := Snapshot(db, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	have, err := Snapshot(loaded, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("loaded schema = %s, want %s", dump(have), dump(want))
+	}
+
+	state, _, err := Check(loaded, sqlite)
+	if err != nil || state != UpToDate {
+		t.Errorf("loaded database is %s, %v, want up to date", state, err)
+	}
+}
+
+func TestLoadSchemaRollback(t *testing.T) {
+	db := openTestDB(t)
+	err := LoadSchema(db, strings.NewReader("CREATE TABLE users (id INTEGER);\nINSERT INTO missing VALUES (1);\n"), WithDialect(SQLite))
+	if err == nil {
+		t.Fatal("LoadSchema error = nil")
+	}
+
+	if tableExists(t, db, "users") {
+		t.Error("LoadSchema left the statements before the failure applied")
+	}
+}
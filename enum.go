@@ -0,0 +1,25 @@
+package migrator
+
+import "fmt"
+
+// queryEnumValueAdd adds a value to an enum type.
+var queryEnumValueAdd = `ALTER TYPE %s ADD VALUE IF NOT EXISTS %s;`
+
+// AddEnumValue adds the value to the Postgres enum type if it does not
+// already exist. Postgres versions before 12 cannot add enum values
+// inside a transaction, so AddEnumValue must be called from a migration
+// registered with RegisterNoTransaction. The type is interpolated into
+// the statement and must be trusted.
+func AddEnumValue(conn Conn, typ, value string) error {
+	return ExecAll(conn, fmt.Sprintf(queryEnumValueAdd, typ, quote(value)))
+}
+
+// RegisterEnumValue makes a migration that adds the value to the Postgres
+// enum type available by the name add_enum_value_<typ>_<value>. The
+// migration runs outside of a transaction. Postgres cannot remove a value
+// from an enum type, so migrating down is a no-op. If RegisterEnumValue
+// is called twice with the same version, it panics.
+func RegisterEnumValue(version, typ, value string, opts ...MigrationOption) {
+	up := []string{fmt.Sprintf(queryEnumValueAdd, typ, quote(value))}
+	registerSQL(version, "add_enum_value_"+typ+"_"+value, up, nil, true, opts)
+}
@@ -0,0 +1,23 @@
+package migrator
+
+import "testing"
+
+func TestRegisterEnumValue(t *testing.T) {
+	isolate(t)
+
+	RegisterEnumValue("20240101T000000Z", "status", "archived")
+
+	m := migrations["20240101T000000Z"]
+	if m == nil || m.name != "add_enum_value_status_archived" {
+		t.Fatalf("migration = %+v, want add_enum_value_status_archived", m)
+	}
+
+	if m.upConn == nil || m.downConn == nil {
+		t.Fatal("enum value migration does not run outside of a transaction")
+	}
+
+	err := m.downConn(nil)
+	if err != nil {
+		t.Errorf("down = %v, want no-op", err)
+	}
+}
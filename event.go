@@ -0,0 +1,69 @@
+package migrator
+
+import "time"
+
+// An Event is an event of a run sent to the channel of WithEvents. It is
+// one of PlanComputed, LockAcquired, MigrationStarted, MigrationApplied
+// or MigrationFailed.
+type Event interface {
+	event()
+}
+
+// PlanComputed is sent once the migrations to be performed by the run
+// are known.
+type PlanComputed struct {
+	Versions []string // version timestamps in the order to be performed
+	Up       bool     // whether or not the migrations are performed up
+}
+
+// LockAcquired is sent once the lock of WithLock is held.
+type LockAcquired struct {
+	Waited time.Duration // time spent waiting for the lock
+}
+
+// MigrationStarted is sent before a migration is performed.
+type MigrationStarted struct {
+	Version string
+	Name    string
+	Up      bool
+}
+
+// MigrationApplied is sent after a migration is performed.
+type MigrationApplied struct {
+	Version string
+	Name    string
+	Up      bool
+	Elapsed time.Duration
+	Rows    int64 // rows affected by statements executed with Exec
+}
+
+// MigrationFailed is sent when a migration fails.
+type MigrationFailed struct {
+	Version string
+	Name    string
+	Up      bool
+	Err     error
+}
+
+func (PlanComputed) event()     {}
+func (LockAcquired) event()     {}
+func (MigrationStarted) event() {}
+func (MigrationApplied) event() {}
+func (MigrationFailed) event()  {}
+
+// WithEvents sends the events of the run to the channel, so callers can
+// build progress displays and logging without parsing log messages. The
+// run blocks on each send, so the channel should be buffered or drained
+// concurrently. The channel is not closed by the run.
+func WithEvents(ch chan<- Event) Option {
+	return func(o *options) {
+		o.events = ch
+	}
+}
+
+// emit sends the event to the channel of the run, if any.
+func (o *options) emit(e Event) {
+	if o.events != nil {
+		o.events <- e
+	}
+}
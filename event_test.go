@@ -0,0 +1,52 @@
+package migrator
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWithEvents(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+	registerSQL("20240102T000000Z", "invalid", []string{"CREATE TABLE"}, nil, false, nil)
+
+	ch := make(chan Event, 16)
+	db := openTestDB(t)
+	err := Migrate(db, "", WithDialect(SQLite), WithEvents(ch), WithLogger(&testLogger{}))
+	if err == nil {
+		t.Fatal("Migrate error = nil")
+	}
+
+	close(ch)
+	var events []Event
+	for e := range ch {
+		switch e := e.(type) {
+		case MigrationApplied:
+			e.Elapsed, e.Rows = 0, 0
+			events = append(events, e)
+		case MigrationFailed:
+			if !errors.Is(err, e.Err) {
+				t.Errorf("failed with %v, want %v", e.Err, err)
+			}
+			e.Err = nil
+			events = append(events, e)
+		default:
+			events = append(events, e)
+		}
+	}
+
+	want := []Event{
+		PlanComputed{Versions: []string{NilVersion, "20240101T000000Z", "20240102T000000Z"}, Up: true},
+		MigrationStarted{Version: NilVersion, Name: "nil", Up: true},
+		MigrationApplied{Version: NilVersion, Name: "nil", Up: true},
+		MigrationStarted{Version: "20240101T000000Z", Name: "create_users", Up: true},
+		MigrationApplied{Version: "20240101T000000Z", Name: "create_users", Up: true},
+		MigrationStarted{Version: "20240102T000000Z", Name: "invalid", Up: true},
+		MigrationFailed{Version: "20240102T000000Z", Name: "invalid", Up: true},
+	}
+
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("events = %+v, want %+v", events, want)
+	}
+}
@@ -0,0 +1,195 @@
+package migrator
+
+import (
+	"database/sql"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// An execution is a migration or repeatable being executed by a run.
+// The version of a repeatable is empty.
+type execution struct {
+	version string
+	name    string
+	o       *options
+	began   time.Time
+
+	mu        sync.Mutex
+	statement string
+}
+
+// A Timing is the elapsed time of a statement executed by a migration.
+type Timing struct {
+	Version   string        // version timestamp of the migration, empty for repeatables
+	Name      string        // name of the migration or repeatable
+	Statement string        // statement that was executed
+	Elapsed   time.Duration // time taken to execute the statement
+}
+
+// executing maps the transaction or connection provided to each executing
+// migration to its execution.
+var executing = struct {
+	sync.Mutex
+	m map[interface{}]*execution
+}{m: make(map[interface{}]*execution)}
+
+// track associates the transaction or connection handle with the
+// migration executing on it until the returned function is called. If
+// the run has a slow threshold, warnings are logged while the migration
+// is executing past it.
+func track(handle interface{}, version string, o *options) func() {
+	e := &execution{version: version, o: o, began: o.now()}
+	if m, ok := migrations[version]; ok {
+		e.name = m.name
+	}
+
+	return e.track(handle)
+}
+
+// trackRepeatable associates the transaction handle with the repeatable
+// executing on it as track does for migrations.
+func trackRepeatable(handle interface{}, name string, o *options) func() {
+	e := &execution{name: name, o: o, began: o.now()}
+	return e.track(handle)
+}
+
+// track associates the handle with the execution until the returned
+// function is called.
+func (e *execution) track(handle interface{}) func() {
+	o := e.o
+	executing.Lock()
+	executing.m[handle] = e
+	executing.Unlock()
+
+	done := make(chan struct{})
+	if o.slow > 0 {
+		go e.watch(done)
+	}
+
+	return func() {
+		close(done)
+		executing.Lock()
+		delete(executing.m, handle)
+		executing.Unlock()
+	}
+}
+
+// label returns the version timestamp of the migration of the execution,
+// or the name of its repeatable, for logging.
+func (e *execution) label() string {
+	if e.version == "" {
+		return "repeatable " + e.name
+	}
+
+	return e.version
+}
+
+// lookup returns the execution associated with the handle or nil if the
+// handle was not provided to an executing migration.
+func lookup(handle interface{}) *execution {
+	executing.Lock()
+	defer executing.Unlock()
+	return executing.m[handle]
+}
+
+// DialectOf returns the dialect of the run executing a migration on the
+// handle, which is the *sql.Tx or *sql.Conn provided to the migration, or
+// Postgres if the handle does not belong to a run.
+func DialectOf(handle interface{}) Dialect {
+	e := lookup(handle)
+	if e == nil {
+		return Postgres
+	}
+
+	return e.o.dialect
+}
+
+// optionsOf returns the options of the run executing a migration on the
+// handle, or the default options if the handle does not belong to a run.
+func optionsOf(handle interface{}) *options {
+	e := lookup(handle)
+	if e == nil {
+		return newOptions(nil)
+	}
+
+	return e.o
+}
+
+// executed records the statement currently executing on the handle and
+// echoes it with its arguments if the run echoes statements, provided the
+// handle belongs to an executing migration. The returned function must be
+// called with the error of the statement when it completes to report its
+// timing and, if it failed, to record it as the failing statement.
+func executed(handle interface{}, stmt string, args []interface{}) func(error) {
+	e := lookup(handle)
+	if e == nil {
+		return func(error) {}
+	}
+
+	if e.o.echo {
+		e.o.logf(LevelDebug, "%s: %s%s", e.label(), strings.TrimSpace(stmt), e.o.formatArgs(args))
+	}
+
+	e.mu.Lock()
+	e.statement = stmt
+	e.mu.Unlock()
+
+	began := e.o.now()
+	return func(err error) {
+		t := Timing{Version: e.version, Name: e.name, Statement: stmt, Elapsed: e.o.since(began)}
+		e.mu.Lock()
+		e.statement = ""
+		e.mu.Unlock()
+
+		e.o.failing.statement, e.o.failing.err = "", err
+		if err != nil {
+			e.o.failing.statement = stmt
+		}
+
+		if e.o.timing != nil {
+			e.o.timing(t)
+		}
+	}
+}
+
+// affected adds the rows affected by the result to the run executing a
+// migration on the handle, if any.
+func affected(handle interface{}, rv sql.Result) {
+	e := lookup(handle)
+	if e == nil {
+		return
+	}
+
+	n, err := rv.RowsAffected()
+	if err == nil && n > 0 {
+		atomic.AddInt64(&e.o.rows, n)
+	}
+}
+
+// watch logs a warning each time the slow threshold elapses until done
+// is closed.
+func (e *execution) watch(done <-chan struct{}) {
+	t := time.NewTicker(e.o.slow)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-t.C:
+			e.mu.Lock()
+			stmt := e.statement
+			e.mu.Unlock()
+
+			elapsed := e.o.since(e.began).Round(time.Second)
+			if stmt == "" {
+				e.o.logf(LevelWarn, "%q still running after %s", e.label(), elapsed)
+				continue
+			}
+
+			e.o.logf(LevelWarn, "%q still running after %s executing %q", e.label(), elapsed, stmt)
+		}
+	}
+}
@@ -0,0 +1,211 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// testLogger records the messages logged by a run.
+type testLogger struct {
+	mu   sync.Mutex
+	msgs []string
+}
+
+func (l *testLogger) Printf(format string, v ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.msgs = append(l.msgs, fmt.Sprintf(format, v...))
+}
+
+// messages returns a copy of the logged messages.
+func (l *testLogger) messages() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.msgs...)
+}
+
+func TestTrack(t *testing.T) {
+	handle := new(sql.Tx)
+	o := newOptions([]Option{WithDialect(SQLite)})
+
+	if lookup(handle) != nil || DialectOf(handle) != Postgres {
+		t.Fatal("untracked handle has an execution")
+	}
+
+	untrack := track(handle, "20240101T000000Z", o)
+	executed(handle, "SELECT 1;", nil)
+
+	e := lookup(handle)
+	if e == nil || e.version != "20240101T000000Z" || e.statement != "SELECT 1;" {
+		t.Fatalf("lookup = %+v, want the tracked execution", e)
+	}
+
+	if DialectOf(handle) != SQLite {
+		t.Errorf("DialectOf = %v, want %v", DialectOf(handle), SQLite)
+	}
+
+	untrack()
+	if lookup(handle) != nil {
+		t.Error("handle has an execution after untrack")
+	}
+}
+
+func TestSlowThreshold(t *testing.T) {
+	l := &testLogger{}
+	o := newOptions([]Option{WithLogger(l), WithSlowThreshold(10 * time.Millisecond)})
+
+	handle := new(sql.Tx)
+	untrack := track(handle, "20240101T000000Z", o)
+	executed(handle, "SELECT pg_sleep(1);", nil)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(l.messages()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	untrack()
+
+	msgs := l.messages()
+	if len(msgs) == 0 {
+		t.Fatal("no slow warning logged")
+	}
+
+	if !strings.Contains(msgs[0], `"20240101T000000Z" still running`) || !strings.Contains(msgs[0], "SELECT pg_sleep(1);") {
+		t.Errorf("slow warning = %q, want the version and executing statement", msgs[0])
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	isolate(t)
+
+	skip := func(tx *sql.Tx) (bool, error) { return false, nil }
+	Register("20240101T000000Z", "skipped", empty, empty, ShouldRun(skip))
+
+	db := openTestDB(t)
+	_, err := db.Exec(queryTestVersionsNew)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tx.Rollback()
+
+	l := &testLogger{}
+	err = migrate(tx, "20240101T000000Z", true, nil, newOptions([]Option{WithLogger(l)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := l.messages()
+	if len(msgs) != 1 || !strings.Contains(msgs[0], "predicate returned false") {
+		t.Errorf("logged %q, want the skipped predicate", msgs)
+	}
+}
+
+func TestEcho(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.Exec("CREATE TABLE users (name TEXT);")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		redact bool
+		want   string
+	}{
+		{false, "debug: 20240101T000000Z: INSERT INTO users (name) VALUES (?); [alice]"},
+		{true, "debug: 20240101T000000Z: INSERT INTO users (name) VALUES (?); [1 redacted]"},
+	}
+
+	for _, tt := range tests {
+		tx, err := db.Begin()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		l := &testLogger{}
+		untrack := track(tx, "20240101T000000Z", newOptions([]Option{WithLogger(l), WithEcho(tt.redact)}))
+		_, err = Exec(tx, "INSERT INTO users (name) VALUES (?);", "alice")
+		untrack()
+		tx.Rollback()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		msgs := l.messages()
+		if len(msgs) != 1 || msgs[0] != tt.want {
+			t.Errorf("echo redact=%t logged %q, want %q", tt.redact, msgs, tt.want)
+		}
+	}
+}
+
+func TestEchoRepeatable(t *testing.T) {
+	handle := new(sql.Tx)
+	l := &testLogger{}
+	untrack := trackRepeatable(handle, "views", newOptions([]Option{WithLogger(l), WithEcho(false)}))
+	executed(handle, "SELECT 1;", nil)
+	untrack()
+
+	msgs := l.messages()
+	if len(msgs) != 1 || msgs[0] != "debug: repeatable views: SELECT 1;" {
+		t.Errorf("logged %q, want the statement labeled by the repeatable", msgs)
+	}
+}
+
+func TestTimings(t *testing.T) {
+	isolate(t)
+	Register("20240101T000000Z", "timed", empty, empty)
+
+	var have []Timing
+	o := newOptions([]Option{WithTimings(func(t Timing) { have = append(have, t) })})
+
+	handle := new(sql.Tx)
+	untrack := track(handle, "20240101T000000Z", o)
+	done := executed(handle, "SELECT 1;", nil)
+	if e := lookup(handle); e.statement != "SELECT 1;" {
+		t.Errorf("statement = %q while executing, want %q", e.statement, "SELECT 1;")
+	}
+
+	done(nil)
+	untrack()
+
+	if len(have) != 1 || have[0].Version != "20240101T000000Z" || have[0].Name != "timed" || have[0].Statement != "SELECT 1;" || have[0].Elapsed < 0 {
+		t.Errorf("timings = %+v, want one timing of the statement", have)
+	}
+
+	executed(handle, "SELECT 2;", nil)(nil)
+	if len(have) != 1 {
+		t.Errorf("statement outside a run timed: %+v", have)
+	}
+}
+
+func TestExecContext(t *testing.T) {
+	db := openTestDB(t)
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tx.Rollback()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	untrack := track(tx, "20240101T000000Z", newOptions([]Option{WithContext(ctx)}))
+	defer untrack()
+
+	_, err = Exec(tx, "SELECT 1;")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Exec error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,127 @@
+package migrator
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// queryVersionsImport inserts a version as it was recorded in another
+// database.
+var queryVersionsImport = `
+INSERT INTO %[1]s (version, name, skip_reason, checksum, created_at)
+  VALUES ($1, $2, $3, $4, $5);
+`
+
+// queryVersionsClear deletes every version.
+var queryVersionsClear = `
+DELETE FROM %[1]s;
+`
+
+// Export writes the applied migrations of the database to w as a JSON
+// array of Info, to be imported into another database with Import.
+func Export(db *sql.DB, w io.Writer, opts ...Option) error {
+	infos, err := Applied(db, opts...)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(infos)
+}
+
+// Import replaces the applied migrations of the database with those read
+// from r as written by Export, such as after restoring a backup or
+// cloning an environment. Archived versions replace those of the archive
+// table, which is created if needed. Every version must be registered in
+// the set of the run with the same name, and with the same checksum if
+// both have one, or nothing is imported.
+func Import(db *sql.DB, r io.Reader, opts ...Option) error {
+	o := newOptions(opts)
+
+	var infos []*Info
+	err := json.NewDecoder(r).Decode(&infos)
+	if err != nil {
+		return fmt.Errorf("migrator: import: %w", err)
+	}
+
+	var problems []string
+	var archives bool
+	for _, info := range infos {
+		archives = archives || info.Archived
+		if !o.inSet(info.Version) {
+			problems = append(problems, fmt.Sprintf("%s is not registered", info.Version))
+			continue
+		}
+
+		m := registered(info.Version)
+		if info.Name != m.Name {
+			problems = append(problems, fmt.Sprintf("%s is named %s, not %s", info.Version, m.Name, info.Name))
+		} else if info.Checksum != "" && m.Checksum != "" && info.Checksum != m.Checksum {
+			problems = append(problems, fmt.Sprintf("%s was edited since it was applied", info.Version))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("migrator: import: %s", strings.Join(problems, "; "))
+	}
+
+	err = o.createVersions(db)
+	if err != nil {
+		return err
+	}
+
+	if archives {
+		_, err = db.ExecContext(o.ctx, o.query(queryArchiveNew))
+		if err != nil {
+			return err
+		}
+	}
+
+	archive, err := o.hasArchive(db)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.BeginTx(o.ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(o.ctx, o.query(queryVersionsClear))
+	if err != nil {
+		return err
+	}
+
+	if archive {
+		_, err = tx.ExecContext(o.ctx, o.query(queryArchiveClear))
+		if err != nil {
+			return err
+		}
+	}
+
+	now := o.now().UTC()
+	for i, info := range infos {
+		appliedAt := now
+		if info.AppliedAt != nil {
+			appliedAt = info.AppliedAt.UTC()
+		}
+
+		if info.Archived {
+			_, err = tx.ExecContext(o.ctx, o.query(queryArchiveImport), i+1, info.Version, info.Name, info.SkipReason, info.Checksum, appliedAt, now)
+		} else {
+			_, err = tx.ExecContext(o.ctx, o.query(queryVersionsImport), info.Version, info.Name, info.SkipReason, info.Checksum, appliedAt)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,151 @@
+package migrator
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExportImport(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{
+		"20240101T000000Z": "users",
+		"20240102T000000Z": "posts",
+	})
+
+	src := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+	err := Migrate(src, "20240101T000000Z", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	err = Export(src, &b, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := openTestDB(t)
+	err = Migrate(dst, "", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Import(dst, strings.NewReader(b.String()), sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := Applied(src, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	have, err := Applied(dst, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(have) != len(want) {
+		t.Fatalf("imported %d versions, want %d", len(have), len(want))
+	}
+
+	for i := range want {
+		if have[i].Version != want[i].Version || have[i].Checksum != want[i].Checksum || !have[i].AppliedAt.Equal(*want[i].AppliedAt) {
+			t.Errorf("imported %+v, want %+v", have[i], want[i])
+		}
+	}
+}
+
+func TestExportImportArchive(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{
+		"20240101T000000Z": "users",
+		"20240102T000000Z": "posts",
+	})
+
+	sqlite := WithDialect(SQLite)
+	src, dst := openTestDB(t), openTestDB(t)
+	for _, db := range []*sql.DB{src, dst} {
+		err := Migrate(db, "", sqlite)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err := Archive(src, "20240102T000000Z", nil, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var b strings.Builder
+	err = Export(src, &b, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Import(dst, strings.NewReader(b.String()), sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	infos, err := Applied(dst, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var have []string
+	for _, info := range infos {
+		have = append(have, fmt.Sprintf("%s %t", info.Version, info.Archived))
+	}
+
+	want := []string{NilVersion + " true", "20240101T000000Z true", "20240102T000000Z false"}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("imported %q, want %q", have, want)
+	}
+
+	// Importing versions without an archive into a database with one
+	// replaces the archived versions rather than duplicating them.
+	err = Import(dst, strings.NewReader("[]"), sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	infos, err = Applied(dst, sqlite)
+	if err != nil || len(infos) != 0 {
+		t.Errorf("applied after importing none = %d, %v, want none", len(infos), err)
+	}
+}
+
+func TestImportInvalid(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"malformed", `{`, "migrator: import:"},
+		{"unregistered", `[{"version": "20240102T000000Z", "name": "create_posts"}]`, "20240102T000000Z is not registered"},
+		{"renamed", `[{"version": "20240101T000000Z", "name": "create_accounts"}]`, "is named create_users, not create_accounts"},
+		{"edited", `[{"version": "20240101T000000Z", "name": "create_users", "checksum": "abc"}]`, "was edited since it was applied"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := openTestDB(t)
+			err := Import(db, strings.NewReader(tt.in), WithDialect(SQLite))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Import error = %v, want %q", err, tt.want)
+			}
+
+			if tableExists(t, db, "versions") {
+				t.Error("invalid import created the versions table")
+			}
+		})
+	}
+}
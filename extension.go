@@ -0,0 +1,119 @@
+package migrator
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// An Extension is a Postgres extension. The name is interpolated into
+// statements and must be a trusted identifier.
+type Extension struct {
+	Name    string // name of the extension
+	Version string // version to pin the extension to, or empty for the default
+
+	// SkipUnprivileged skips creating or dropping the extension with a
+	// warning, rather than failing, when the migrating role lacks the
+	// privileges to do so. The extension must then be managed by a
+	// superuser outside of the migrations.
+	SkipUnprivileged bool
+}
+
+// queryExtensionVersion selects the installed version of an extension.
+var queryExtensionVersion = `
+SELECT extversion
+  FROM pg_extension
+  WHERE extname = $1;
+`
+
+// CreateExtension creates the extension if it does not exist. If the
+// extension exists at a version other than the pinned version, it is
+// updated to the pinned version.
+func CreateExtension(tx *sql.Tx, ext Extension) error {
+	return unprivileged(tx, ext, func() error {
+		var installed string
+		err := tx.QueryRow(queryExtensionVersion, ext.Name).Scan(&installed)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+
+		if err == sql.ErrNoRows {
+			stmt := "CREATE EXTENSION IF NOT EXISTS " + ext.Name
+			if ext.Version != "" {
+				stmt += " VERSION " + quote(ext.Version)
+			}
+			_, err = tx.Exec(stmt + ";")
+			return err
+		}
+
+		if ext.Version == "" || ext.Version == installed {
+			return nil
+		}
+
+		_, err = tx.Exec(fmt.Sprintf("ALTER EXTENSION %s UPDATE TO %s;", ext.Name, quote(ext.Version)))
+		return err
+	})
+}
+
+// DropExtension drops the extension if it exists.
+func DropExtension(tx *sql.Tx, ext Extension) error {
+	return unprivileged(tx, ext, func() error {
+		_, err := tx.Exec(fmt.Sprintf("DROP EXTENSION IF EXISTS %s;", ext.Name))
+		return err
+	})
+}
+
+// RegisterExtension makes a migration that creates the extension
+// available by the name create_extension_<name>. Migrating down drops
+// the extension. If RegisterExtension is called twice with the same
+// version, it panics.
+func RegisterExtension(version string, ext Extension, opts ...MigrationOption) {
+	up := func(tx *sql.Tx) error {
+		return CreateExtension(tx, ext)
+	}
+
+	down := func(tx *sql.Tx) error {
+		return DropExtension(tx, ext)
+	}
+
+	Register(version, "create_extension_"+ext.Name, up, down, opts...)
+}
+
+// unprivileged calls fn within a savepoint if the extension skips
+// permission errors. A permission error is then rolled back to the
+// savepoint and logged instead of aborting the transaction.
+func unprivileged(tx *sql.Tx, ext Extension, fn func() error) error {
+	if !ext.SkipUnprivileged {
+		return fn()
+	}
+
+	_, err := tx.Exec("SAVEPOINT migrator_extension;")
+	if err != nil {
+		return err
+	}
+
+	err = fn()
+	if err == nil || !isPermissionDenied(err) {
+		return err
+	}
+
+	_, err = tx.Exec("ROLLBACK TO SAVEPOINT migrator_extension;")
+	if err != nil {
+		return err
+	}
+
+	optionsOf(tx).logf(LevelWarn, "skipping extension %q: insufficient privilege", ext.Name)
+	return nil
+}
+
+// isPermissionDenied returns true if the error is a Postgres
+// insufficient_privilege error.
+func isPermissionDenied(err error) bool {
+	if e, ok := err.(interface{ SQLState() string }); ok {
+		return e.SQLState() == "42501"
+	}
+
+	msg := err.Error()
+	return strings.Contains(msg, "permission denied") || strings.Contains(msg, "must be superuser") ||
+		strings.Contains(msg, "must be owner")
+}
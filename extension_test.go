@@ -0,0 +1,103 @@
+package migrator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// sqlStateError is an error with a SQLSTATE code.
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "sql error " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+func TestIsPermissionDenied(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{sqlStateError("42501"), true},
+		{sqlStateError("42P01"), false},
+		{errors.New(`pq: permission denied to create extension "pg_trgm"`), true},
+		{errors.New(`pq: must be superuser to create this extension`), true},
+		{errors.New(`pq: must be owner of extension pg_trgm`), true},
+		{errors.New(`pq: extension "missing" is not available`), false},
+	}
+
+	for _, tt := range tests {
+		have := isPermissionDenied(tt.err)
+		if have != tt.want {
+			t.Errorf("isPermissionDenied(%q) = %t, want %t", tt.err, have, tt.want)
+		}
+	}
+}
+
+func TestUnprivileged(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.Exec("CREATE TABLE calls (name TEXT);")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tx.Rollback()
+
+	l := &testLogger{}
+	untrack := track(tx, "20240101T000000Z", newOptions([]Option{WithLogger(l)}))
+	defer untrack()
+
+	denied := func() error {
+		_, err := tx.Exec("INSERT INTO calls (name) VALUES ('denied');")
+		if err != nil {
+			return err
+		}
+		return sqlStateError("42501")
+	}
+
+	err = unprivileged(tx, Extension{Name: "pg_trgm"}, denied)
+	if err == nil {
+		t.Fatal("unprivileged error = nil without SkipUnprivileged")
+	}
+
+	err = unprivileged(tx, Extension{Name: "pg_trgm", SkipUnprivileged: true}, denied)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	err = tx.QueryRow("SELECT COUNT(*) FROM calls;").Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 1 {
+		t.Errorf("%d calls, want the skipped call rolled back to the savepoint", n)
+	}
+
+	msgs := l.messages()
+	if len(msgs) != 1 || !strings.Contains(msgs[0], `skipping extension "pg_trgm"`) {
+		t.Errorf("logged %q, want the skipped extension", msgs)
+	}
+
+	fail := errors.New("fail")
+	err = unprivileged(tx, Extension{Name: "pg_trgm", SkipUnprivileged: true}, func() error { return fail })
+	if err != fail {
+		t.Errorf("unprivileged error = %v, want %v", err, fail)
+	}
+}
+
+func TestRegisterExtension(t *testing.T) {
+	isolate(t)
+
+	RegisterExtension("20240101T000000Z", Extension{Name: "pg_trgm"})
+
+	m := migrations["20240101T000000Z"]
+	if m == nil || m.name != "create_extension_pg_trgm" || m.up == nil || m.down == nil {
+		t.Fatalf("registered %+v, want create_extension_pg_trgm", m)
+	}
+}
@@ -0,0 +1,413 @@
+// Package fixture loads fixture files into a migrated database for
+// integration tests and local development.
+//
+// A fixture directory contains a file per table named after the table,
+// either <table>.csv with a header row of column names or <table>.yml
+// with a list of rows mapping column names to values, and any number of
+// <name>.sql files. CSV values of \N are loaded as NULL. Tables are loaded
+// first and SQL files are executed afterwards in order of name, such as
+// to reset sequences.
+package fixture
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pnelson/migrator"
+	"gopkg.in/yaml.v3"
+)
+
+// An Option configures the loading of fixtures.
+type Option func(*options)
+
+// options is the configuration of the loading of fixtures.
+type options struct {
+	dialect     migrator.Dialect
+	truncate    bool
+	order       []string
+	foreignKeys bool
+}
+
+// WithDialect sets the dialect of the database. The default dialect is
+// Postgres.
+func WithDialect(d migrator.Dialect) Option {
+	return func(o *options) {
+		o.dialect = d
+	}
+}
+
+// WithTruncate empties the tables before loading their fixtures. Tables
+// without fixtures are left untouched, so loading fails if one of them
+// references a table with fixtures.
+func WithTruncate() Option {
+	return func(o *options) {
+		o.truncate = true
+	}
+}
+
+// WithOrder loads the tables in the provided order before the remaining
+// tables, which are loaded in order of name. Referenced tables must be
+// loaded before the tables that reference them.
+func WithOrder(tables ...string) Option {
+	return func(o *options) {
+		o.order = append(o.order, tables...)
+	}
+}
+
+// WithForeignKeyOrder loads referenced tables before the tables that
+// reference them, based on the foreign keys of the Postgres schema.
+func WithForeignKeyOrder() Option {
+	return func(o *options) {
+		o.foreignKeys = true
+	}
+}
+
+// A table is the rows of a fixture file.
+type table struct {
+	name    string
+	columns []string
+	rows    [][]interface{}
+}
+
+// Load loads the fixtures in dir into the database in a single
+// transaction.
+func Load(db *sql.DB, dir string, opts ...Option) error {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	tables, scripts, err := read(dir)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(tables))
+	for name := range tables {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	if o.foreignKeys {
+		names, err = foreignKeyOrder(db, names)
+		if err != nil {
+			return err
+		}
+	}
+
+	names = order(o.order, names)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	err = load(tx, o, tables, names, scripts)
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return err
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// load truncates and inserts the tables in order and executes the SQL
+// scripts within the transaction.
+func load(tx *sql.Tx, o *options, tables map[string]*table, names, scripts []string) error {
+	if o.truncate {
+		for _, stmt := range truncate(o.dialect, names) {
+			_, err := tx.Exec(stmt)
+			if err != nil {
+				return fmt.Errorf("fixture: truncate: %w", err)
+			}
+		}
+	}
+
+	for _, name := range names {
+		t := tables[name]
+		query := insert(o.dialect, t)
+		for _, row := range t.rows {
+			_, err := tx.Exec(query, row...)
+			if err != nil {
+				return fmt.Errorf("fixture: insert %s: %w", name, err)
+			}
+		}
+	}
+
+	for _, path := range scripts {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(string(b))
+		if err != nil {
+			return fmt.Errorf("fixture: %s: %w", filepath.Base(path), err)
+		}
+	}
+
+	return nil
+}
+
+// read reads the table fixtures and the paths of the SQL scripts in dir.
+func read(dir string) (map[string]*table, []string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tables := make(map[string]*table)
+	var scripts []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		ext := filepath.Ext(entry.Name())
+		name := strings.TrimSuffix(entry.Name(), ext)
+
+		var t *table
+		switch ext {
+		case ".csv":
+			t, err = readCSV(path)
+		case ".yml", ".yaml":
+			t, err = readYAML(path)
+		case ".sql":
+			scripts = append(scripts, path)
+			continue
+		default:
+			continue
+		}
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("fixture: %s: %w", entry.Name(), err)
+		}
+
+		if _, ok := tables[name]; ok {
+			return nil, nil, fmt.Errorf("fixture: more than one file for table %s", name)
+		}
+
+		t.name = name
+		tables[name] = t
+	}
+
+	sort.Strings(scripts)
+
+	return tables, scripts, nil
+}
+
+// readCSV reads a table from a CSV file with a header row.
+func readCSV(path string) (*table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) == 0 {
+		return nil, fmt.Errorf("missing header row")
+	}
+
+	t := &table{columns: records[0]}
+	for _, record := range records[1:] {
+		row := make([]interface{}, len(record))
+		for i, v := range record {
+			if v == `\N` {
+				continue
+			}
+			row[i] = v
+		}
+
+		t.rows = append(t.rows, row)
+	}
+
+	return t, nil
+}
+
+// readYAML reads a table from a YAML file containing a list of rows.
+func readYAML(path string) (*table, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []map[string]interface{}
+	err = yaml.Unmarshal(b, &records)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	t := &table{}
+	for _, record := range records {
+		for column := range record {
+			if !seen[column] {
+				seen[column] = true
+				t.columns = append(t.columns, column)
+			}
+		}
+	}
+
+	sort.Strings(t.columns)
+
+	for _, record := range records {
+		row := make([]interface{}, len(t.columns))
+		for i, column := range t.columns {
+			row[i] = record[column]
+		}
+
+		t.rows = append(t.rows, row)
+	}
+
+	return t, nil
+}
+
+// insert returns the statement that inserts a row into the table in the
+// dialect.
+func insert(d migrator.Dialect, t *table) string {
+	params := make([]string, len(t.columns))
+	for i := range params {
+		params[i] = "?"
+		if d == migrator.Postgres {
+			params[i] = fmt.Sprintf("$%d", i+1)
+		}
+	}
+
+	return fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s);",
+		t.name, strings.Join(t.columns, ", "), strings.Join(params, ", "))
+}
+
+// truncate returns the statements that empty the tables, given in load
+// order, in the dialect. Postgres truncates the tables together so that
+// they may reference each other, but not tables without fixtures, whose
+// foreign keys fail the truncation rather than cascading to them. Other
+// dialects delete from the tables in reverse load order.
+func truncate(d migrator.Dialect, names []string) []string {
+	if len(names) == 0 {
+		return nil
+	}
+
+	if d == migrator.Postgres {
+		return []string{fmt.Sprintf("TRUNCATE %s RESTART IDENTITY;", strings.Join(names, ", "))}
+	}
+
+	var rv []string
+	for i := len(names) - 1; i >= 0; i-- {
+		rv = append(rv, fmt.Sprintf("DELETE FROM %s;", names[i]))
+	}
+
+	return rv
+}
+
+// order returns the names with the ordered names that are present first.
+func order(ordered, names []string) []string {
+	present := make(map[string]bool)
+	for _, name := range names {
+		present[name] = true
+	}
+
+	var rv []string
+	for _, name := range ordered {
+		if present[name] {
+			rv = append(rv, name)
+			delete(present, name)
+		}
+	}
+
+	for _, name := range names {
+		if present[name] {
+			rv = append(rv, name)
+		}
+	}
+
+	return rv
+}
+
+// queryForeignKeys selects the referencing and referenced tables of the
+// foreign keys in the current Postgres schema.
+var queryForeignKeys = `
+SELECT tc.table_name, ccu.table_name
+  FROM information_schema.table_constraints tc
+  JOIN information_schema.constraint_column_usage ccu
+    ON ccu.constraint_name = tc.constraint_name
+   AND ccu.constraint_schema = tc.constraint_schema
+  WHERE tc.constraint_type = 'FOREIGN KEY'
+    AND tc.table_schema = current_schema();
+`
+
+// foreignKeyOrder returns the names sorted so that referenced tables
+// come before the tables that reference them.
+func foreignKeyOrder(db *sql.DB, names []string) ([]string, error) {
+	rows, err := db.Query(queryForeignKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	deps := make(map[string][]string)
+	for rows.Next() {
+		var from, to string
+		err := rows.Scan(&from, &to)
+		if err != nil {
+			return nil, err
+		}
+
+		if from != to {
+			deps[from] = append(deps[from], to)
+		}
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	var rv []string
+	state := make(map[string]int)
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case 1:
+			return fmt.Errorf("fixture: foreign key cycle at table %s", name)
+		case 2:
+			return nil
+		}
+
+		state[name] = 1
+		for _, dep := range deps[name] {
+			err := visit(dep)
+			if err != nil {
+				return err
+			}
+		}
+
+		state[name] = 2
+		rv = append(rv, name)
+		return nil
+	}
+
+	for _, name := range names {
+		err := visit(name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return order(rv, names), nil
+}
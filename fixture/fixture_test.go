@@ -0,0 +1,213 @@
+package fixture
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pnelson/migrator"
+)
+
+// openTestDB opens a SQLite database with the fixture tables in a
+// temporary directory that is closed when the test completes.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(`
+CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, email TEXT);
+CREATE TABLE posts (id INTEGER PRIMARY KEY, user_id INTEGER, title TEXT);
+CREATE TABLE counters (name TEXT);
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+// writeFiles writes the files into a temporary directory and returns it.
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, data := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return dir
+}
+
+func TestLoad(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"users.csv":    "id,name,email\n1,Alice,alice@example.com\n2,Bob,\\N\n",
+		"posts.yml":    "- id: 1\n  user_id: 1\n  title: Hello\n- id: 2\n  user_id: 2\n",
+		"10_count.sql": "INSERT INTO counters (name) SELECT 'users:' || COUNT(*) FROM users;",
+		"README.md":    "ignored",
+	})
+
+	db := openTestDB(t)
+	err := Load(db, dir, WithDialect(migrator.SQLite))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var email sql.NullString
+	err = db.QueryRow("SELECT email FROM users WHERE id = 2;").Scan(&email)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if email.Valid {
+		t.Errorf("email = %q, want NULL for \\N", email.String)
+	}
+
+	var title sql.NullString
+	err = db.QueryRow("SELECT title FROM posts WHERE id = 2;").Scan(&title)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if title.Valid {
+		t.Errorf("title = %q, want NULL for a missing key", title.String)
+	}
+
+	var counter string
+	err = db.QueryRow("SELECT name FROM counters;").Scan(&counter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if counter != "users:2" {
+		t.Errorf("counter = %q, want the script executed after the tables", counter)
+	}
+}
+
+func TestLoadTruncate(t *testing.T) {
+	dir := writeFiles(t, map[string]string{"users.csv": "id,name\n1,Alice\n"})
+
+	db := openTestDB(t)
+	err := Load(db, dir, WithDialect(migrator.SQLite))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Load(db, dir, WithDialect(migrator.SQLite))
+	if err == nil {
+		t.Fatal("loading twice without truncating did not fail on the primary key")
+	}
+
+	err = Load(db, dir, WithDialect(migrator.SQLite), WithTruncate())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	err = db.QueryRow("SELECT COUNT(*) FROM users;").Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 1 {
+		t.Errorf("%d users, want 1", n)
+	}
+}
+
+func TestLoadRollback(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"users.csv": "id,name\n1,Alice\n",
+		"posts.csv": "id,missing\n1,x\n",
+	})
+
+	db := openTestDB(t)
+	err := Load(db, dir, WithDialect(migrator.SQLite), WithOrder("users"))
+	if err == nil {
+		t.Fatal("Load error = nil, want the missing column")
+	}
+
+	var n int
+	err = db.QueryRow("SELECT COUNT(*) FROM users;").Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 0 {
+		t.Errorf("%d users, want the load rolled back", n)
+	}
+}
+
+func TestReadDuplicate(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"users.csv": "id\n1\n",
+		"users.yml": "- id: 2\n",
+	})
+
+	_, _, err := read(dir)
+	if err == nil {
+		t.Error("read error = nil, want more than one file for users")
+	}
+}
+
+func TestInsert(t *testing.T) {
+	tb := &table{name: "users", columns: []string{"id", "name"}}
+
+	tests := []struct {
+		d    migrator.Dialect
+		want string
+	}{
+		{migrator.Postgres, "INSERT INTO users (id, name) VALUES ($1, $2);"},
+		{migrator.MySQL, "INSERT INTO users (id, name) VALUES (?, ?);"},
+		{migrator.SQLite, "INSERT INTO users (id, name) VALUES (?, ?);"},
+	}
+
+	for _, tt := range tests {
+		have := insert(tt.d, tb)
+		if have != tt.want {
+			t.Errorf("insert(%s) = %q, want %q", tt.d, have, tt.want)
+		}
+	}
+}
+
+func TestOrder(t *testing.T) {
+	have := order([]string{"users", "missing", "posts"}, []string{"comments", "posts", "tags", "users"})
+	want := []string{"users", "posts", "comments", "tags"}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("order = %q, want %q", have, want)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	names := []string{"users", "posts"}
+
+	tests := []struct {
+		d    migrator.Dialect
+		want []string
+	}{
+		{migrator.Postgres, []string{"TRUNCATE users, posts RESTART IDENTITY;"}},
+		{migrator.MySQL, []string{"DELETE FROM posts;", "DELETE FROM users;"}},
+		{migrator.SQLite, []string{"DELETE FROM posts;", "DELETE FROM users;"}},
+	}
+
+	for _, tt := range tests {
+		have := truncate(tt.d, names)
+		if !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("truncate(%s) = %q, want %q", tt.d, have, tt.want)
+		}
+	}
+
+	if have := truncate(migrator.Postgres, nil); have != nil {
+		t.Errorf("truncate of no tables = %q, want none", have)
+	}
+}
@@ -0,0 +1,65 @@
+package migrator
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// WithRoles maps logical role names used by migrations to the concrete
+// roles of the environment the run targets, so that the same migrations
+// grant privileges to differently named roles in each environment.
+// Logical roles without a mapping are used as is.
+func WithRoles(roles map[string]string) Option {
+	return func(o *options) {
+		o.roles = roles
+	}
+}
+
+// Role returns the concrete role mapped to the logical role by the run
+// executing a migration on the handle, which is the *sql.Tx or *sql.Conn
+// provided to the migration. The logical role is returned if it is not
+// mapped.
+func Role(handle interface{}, logical string) string {
+	e := lookup(handle)
+	if e == nil {
+		return logical
+	}
+
+	if role, ok := e.o.roles[logical]; ok {
+		return role
+	}
+
+	return logical
+}
+
+// Grant grants the privileges on the object to the concrete role mapped
+// to the logical role. The privileges, object and role are interpolated
+// into the statement and must be trusted.
+func Grant(tx *sql.Tx, privileges, object, role string) error {
+	_, err := tx.Exec(fmt.Sprintf("GRANT %s ON %s TO %s;", privileges, object, Role(tx, role)))
+	return err
+}
+
+// Revoke revokes the privileges on the object from the concrete role
+// mapped to the logical role. The privileges, object and role are
+// interpolated into the statement and must be trusted.
+func Revoke(tx *sql.Tx, privileges, object, role string) error {
+	_, err := tx.Exec(fmt.Sprintf("REVOKE %s ON %s FROM %s;", privileges, object, Role(tx, role)))
+	return err
+}
+
+// RegisterGrant makes a migration that grants the privileges on the
+// object to the logical role available by the name grant_<role>.
+// Migrating down revokes the privileges. If RegisterGrant is called
+// twice with the same version, it panics.
+func RegisterGrant(version, privileges, object, role string, opts ...MigrationOption) {
+	up := func(tx *sql.Tx) error {
+		return Grant(tx, privileges, object, role)
+	}
+
+	down := func(tx *sql.Tx) error {
+		return Revoke(tx, privileges, object, role)
+	}
+
+	Register(version, "grant_"+role, up, down, opts...)
+}
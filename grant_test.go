@@ -0,0 +1,43 @@
+package migrator
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestRole(t *testing.T) {
+	handle := new(sql.Tx)
+	if have := Role(handle, "app"); have != "app" {
+		t.Errorf("Role outside a run = %q, want %q", have, "app")
+	}
+
+	o := newOptions([]Option{WithRoles(map[string]string{"app": "app_production"})})
+	untrack := track(handle, "20240101T000000Z", o)
+	defer untrack()
+
+	tests := []struct {
+		logical string
+		want    string
+	}{
+		{"app", "app_production"},
+		{"readonly", "readonly"},
+	}
+
+	for _, tt := range tests {
+		have := Role(handle, tt.logical)
+		if have != tt.want {
+			t.Errorf("Role(%q) = %q, want %q", tt.logical, have, tt.want)
+		}
+	}
+}
+
+func TestRegisterGrant(t *testing.T) {
+	isolate(t)
+
+	RegisterGrant("20240101T000000Z", "SELECT", "users", "readonly")
+
+	m := migrations["20240101T000000Z"]
+	if m == nil || m.name != "grant_readonly" || m.up == nil || m.down == nil {
+		t.Fatalf("registered %+v, want grant_readonly", m)
+	}
+}
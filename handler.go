@@ -0,0 +1,57 @@
+package migrator
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+)
+
+// A Report is the status of the migrations of a database.
+type Report struct {
+	Current    string  `json:"current"`
+	Pending    int     `json:"pending"`
+	Migrations []*Info `json:"migrations"`
+	LastRun    *Run    `json:"last_run,omitempty"`
+}
+
+// NewReport returns the status of the migrations of the database along
+// with the most recently finished run of the process.
+func NewReport(db *sql.DB, opts ...Option) (*Report, error) {
+	o := newOptions(opts)
+
+	infos, err := Inspect(db, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := currentVersion(db, o)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &Report{Current: current, Migrations: infos, LastRun: LastRun()}
+	for _, info := range infos {
+		if !info.Applied {
+			rv.Pending++
+		}
+	}
+
+	return rv, nil
+}
+
+// Handler returns an http.Handler that serves the report of the
+// migrations of the database as JSON, so dashboards and load balancer
+// checks can see the state of the schema without access to the
+// database.
+func Handler(db *sql.DB, opts ...Option) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report, err := NewReport(db, opts...)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	})
+}
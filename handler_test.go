@@ -0,0 +1,92 @@
+package migrator
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestHandler(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{
+		"20240101T000000Z": "users",
+		"20240102T000000Z": "posts",
+	})
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+	err := Migrate(db, "20240101T000000Z", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	Handler(db, sqlite).ServeHTTP(rec, httptest.NewRequest("GET", "/migrations", nil))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/json" {
+		t.Fatalf("status %d with content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	var report Report
+	err = json.NewDecoder(rec.Body).Decode(&report)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if report.Current != "20240101T000000Z" || report.Pending != 1 || len(report.Migrations) != 3 {
+		t.Errorf("report = %+v, want 20240101T000000Z current with 1 pending of 3", report)
+	}
+
+	r := report.LastRun
+	want := []string{NilVersion, "20240101T000000Z"}
+	if r == nil || r.Target != "20240101T000000Z" || !reflect.DeepEqual(r.Performed, want) || r.Error != "" || r.Finished.Before(r.Started) {
+		t.Errorf("last run = %+v, want %v performed", r, want)
+	}
+}
+
+func TestLastRunError(t *testing.T) {
+	isolate(t)
+	registerSQL("20240101T000000Z", "invalid", []string{"CREATE TABLE"}, nil, false, nil)
+
+	db := openTestDB(t)
+	err := Migrate(db, "", WithDialect(SQLite), WithLogger(&testLogger{}))
+	if err == nil {
+		t.Fatal("Migrate error = nil")
+	}
+
+	r := LastRun()
+	if r.Error != err.Error() || !reflect.DeepEqual(r.Performed, []string{NilVersion}) {
+		t.Errorf("last run = %+v, want the error after %s", r, NilVersion)
+	}
+
+	r.Performed = nil
+	if LastRun().Performed == nil {
+		t.Error("LastRun returned the recorded run rather than a copy")
+	}
+}
+
+func TestWithRun(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users", "20240102T000000Z": "posts"})
+
+	var run Run
+	err := Migrate(openTestDB(t), "20240101T000000Z", WithDialect(SQLite), WithRun(&run))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Migrate(openTestDB(t), "", WithDialect(SQLite))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{NilVersion, "20240101T000000Z"}
+	if run.Target != "20240101T000000Z" || !reflect.DeepEqual(run.Performed, want) || run.Finished.IsZero() {
+		t.Errorf("run = %+v, want %v performed", run, want)
+	}
+
+	if r := LastRun(); r.Target != "" {
+		t.Errorf("last run target = %q, want the later run", r.Target)
+	}
+}
@@ -0,0 +1,70 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+)
+
+// A hook is a statement or function executed on the connection of a run.
+type hook struct {
+	query string
+	fn    func(conn *sql.Conn) error
+}
+
+// WithBefore executes the statements on the connection of the run before
+// any migration is performed, such as SET ROLE or SET lock_timeout, so
+// that they apply uniformly to every migration of the run.
+func WithBefore(stmts ...string) Option {
+	return func(o *options) {
+		for _, stmt := range stmts {
+			o.before = append(o.before, hook{query: stmt})
+		}
+	}
+}
+
+// WithBeforeFunc calls fn with the connection of the run before any
+// migration is performed.
+func WithBeforeFunc(fn func(conn *sql.Conn) error) Option {
+	return func(o *options) {
+		o.before = append(o.before, hook{fn: fn})
+	}
+}
+
+// WithAfter executes the statements on the connection of the run after
+// the migrations are performed, such as RESET ROLE, even if the run
+// fails. The connection is returned to the pool afterwards, so session
+// settings made by WithBefore should be reset.
+func WithAfter(stmts ...string) Option {
+	return func(o *options) {
+		for _, stmt := range stmts {
+			o.after = append(o.after, hook{query: stmt})
+		}
+	}
+}
+
+// WithAfterFunc calls fn with the connection of the run after the
+// migrations are performed, even if the run fails.
+func WithAfterFunc(fn func(conn *sql.Conn) error) Option {
+	return func(o *options) {
+		o.after = append(o.after, hook{fn: fn})
+	}
+}
+
+// hook executes the hooks on the connection in order with the context,
+// stopping at the first error.
+func (o *options) hook(ctx context.Context, conn *sql.Conn, hooks []hook) error {
+	for _, h := range hooks {
+		var err error
+		if h.fn != nil {
+			err = h.fn(conn)
+		} else {
+			_, err = conn.ExecContext(ctx, h.query)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,98 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestHook(t *testing.T) {
+	db := openTestDB(t)
+	_, err := db.Exec("CREATE TABLE calls (name TEXT);")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var calls []string
+	fn := func(name string) func(conn *sql.Conn) error {
+		return func(conn *sql.Conn) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+
+	o := newOptions([]Option{
+		WithBefore("INSERT INTO calls (name) VALUES ('before');"),
+		WithBeforeFunc(fn("before func")),
+		WithAfterFunc(fn("after func")),
+		WithAfter("INSERT INTO calls (name) VALUES ('after');"),
+	})
+
+	conn := testConn(t, db)
+	err = o.hook(o.ctx, conn, o.before)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = o.hook(o.ctx, conn, o.after)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(calls, []string{"before func", "after func"}) {
+		t.Errorf("calls = %q, want before func then after func", calls)
+	}
+
+	var n int
+	err = db.QueryRow("SELECT COUNT(*) FROM calls;").Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 2 {
+		t.Errorf("%d hook statements executed, want 2", n)
+	}
+}
+
+func TestHookError(t *testing.T) {
+	fail := errors.New("fail")
+	o := newOptions([]Option{
+		WithBeforeFunc(func(conn *sql.Conn) error { return fail }),
+		WithBeforeFunc(func(conn *sql.Conn) error {
+			t.Error("hook called after a failure")
+			return nil
+		}),
+	})
+
+	err := o.hook(o.ctx, testConn(t, openTestDB(t)), o.before)
+	if err != fail {
+		t.Errorf("hook error = %v, want %v", err, fail)
+	}
+}
+
+func TestHookCanceled(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	db := openTestDB(t)
+	err := Migrate(db, "", WithDialect(SQLite), WithContext(ctx),
+		WithBeforeFunc(func(conn *sql.Conn) error {
+			cancel()
+			return nil
+		}),
+		WithAfter("CREATE TABLE after_hook (id INTEGER);"))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Migrate error = %v, want %v", err, context.Canceled)
+	}
+
+	if tableExists(t, db, "users") {
+		t.Error("migration performed after the run was canceled")
+	}
+
+	if !tableExists(t, db, "after_hook") {
+		t.Error("after hook not run after the run was canceled")
+	}
+}
@@ -0,0 +1,111 @@
+package migrator
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// An Index is an index on a table. The identifiers and columns are
+// interpolated into statements and must be trusted.
+type Index struct {
+	Name    string // name of the index
+	Table   string // table to index
+	Columns string // comma separated columns or expressions
+	Unique  bool   // whether or not the index is unique
+}
+
+// queryIndexValid selects whether a Postgres index is valid.
+var queryIndexValid = `
+SELECT i.indisvalid
+  FROM pg_index i
+  JOIN pg_class c ON c.oid = i.indexrelid
+  WHERE c.relname = $1;
+`
+
+// indexAttempts is the number of times a concurrent index build is
+// attempted before giving up.
+const indexAttempts = 3
+
+// CreateIndexConcurrently builds the index without blocking writes to
+// the table. On Postgres the index is built with CREATE INDEX
+// CONCURRENTLY, which cannot run inside a transaction. A failed build
+// leaves an invalid index behind, so invalid indexes are dropped and the
+// build is retried. On dialects that cannot build indexes concurrently,
+// a normal index is created.
+//
+// CreateIndexConcurrently must be called from a migration registered
+// with RegisterNoTransaction. See also RegisterIndex.
+func CreateIndexConcurrently(conn Conn, d Dialect, idx Index) error {
+	if d != Postgres {
+		ifNotExists := ""
+		if d == SQLite {
+			ifNotExists = "IF NOT EXISTS "
+		}
+		return ExecAll(conn, fmt.Sprintf("CREATE %sINDEX %s%s ON %s (%s);",
+			unique(idx), ifNotExists, idx.Name, idx.Table, idx.Columns))
+	}
+
+	var err error
+	for i := 0; i < indexAttempts; i++ {
+		var valid bool
+		err = conn.QueryRowContext(optionsOf(conn).ctx, queryIndexValid, idx.Name).Scan(&valid)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+
+		if err == nil && valid {
+			return nil
+		}
+
+		if err == nil {
+			err = ExecAll(conn, fmt.Sprintf("DROP INDEX CONCURRENTLY IF EXISTS %s;", idx.Name))
+			if err != nil {
+				return err
+			}
+		}
+
+		err = ExecAll(conn, fmt.Sprintf("CREATE %sINDEX CONCURRENTLY %s ON %s (%s);",
+			unique(idx), idx.Name, idx.Table, idx.Columns))
+	}
+
+	return err
+}
+
+// DropIndexConcurrently drops the index without blocking access to the
+// table. On dialects that cannot drop indexes concurrently, the index is
+// dropped normally.
+func DropIndexConcurrently(conn Conn, d Dialect, idx Index) error {
+	switch d {
+	case Postgres:
+		return ExecAll(conn, fmt.Sprintf("DROP INDEX CONCURRENTLY IF EXISTS %s;", idx.Name))
+	case MySQL:
+		return ExecAll(conn, fmt.Sprintf("DROP INDEX %s ON %s;", idx.Name, idx.Table))
+	}
+
+	return ExecAll(conn, fmt.Sprintf("DROP INDEX IF EXISTS %s;", idx.Name))
+}
+
+// RegisterIndex makes a migration that builds the index concurrently
+// available by the name create_index_<name>. The migration runs outside
+// of a transaction in the dialect of the run. If RegisterIndex is called
+// twice with the same version, it panics.
+func RegisterIndex(version string, idx Index, opts ...MigrationOption) {
+	up := func(conn *sql.Conn) error {
+		return CreateIndexConcurrently(conn, DialectOf(conn), idx)
+	}
+
+	down := func(conn *sql.Conn) error {
+		return DropIndexConcurrently(conn, DialectOf(conn), idx)
+	}
+
+	RegisterNoTransaction(version, "create_index_"+idx.Name, up, down, opts...)
+}
+
+// unique returns the UNIQUE keyword if the index is unique.
+func unique(idx Index) string {
+	if idx.Unique {
+		return "UNIQUE "
+	}
+
+	return ""
+}
@@ -0,0 +1,80 @@
+package migrator
+
+import (
+	"context"
+	"testing"
+)
+
+// indexExists returns whether the index exists in the SQLite database.
+func indexExists(t *testing.T, conn Conn, name string) bool {
+	t.Helper()
+
+	var n int
+	err := conn.QueryRowContext(context.Background(), "SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND name = ?", name).Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return n > 0
+}
+
+func TestCreateIndexConcurrently(t *testing.T) {
+	db := backfillTestDB(t, 3)
+	idx := Index{Name: "items_done_idx", Table: "items", Columns: "id, done", Unique: true}
+
+	for i := 0; i < 2; i++ {
+		err := CreateIndexConcurrently(db, SQLite, idx)
+		if err != nil {
+			t.Fatalf("attempt %d: %v", i+1, err)
+		}
+	}
+
+	if !indexExists(t, db, idx.Name) {
+		t.Fatal("index not created")
+	}
+
+	err := DropIndexConcurrently(db, SQLite, idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if indexExists(t, db, idx.Name) {
+		t.Error("index not dropped")
+	}
+}
+
+func TestRegisterIndex(t *testing.T) {
+	isolate(t)
+
+	db := backfillTestDB(t, 3)
+	_, err := db.Exec(queryTestVersionsNew)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	idx := Index{Name: "items_done_idx", Table: "items", Columns: "done"}
+	RegisterIndex("20240101T000000Z", idx)
+	if name := migrations["20240101T000000Z"].name; name != "create_index_items_done_idx" {
+		t.Errorf("name = %q, want create_index_items_done_idx", name)
+	}
+
+	o := newOptions([]Option{WithDialect(SQLite)})
+	conn := testConn(t, db)
+	err = migrateConn(conn, "20240101T000000Z", true, nil, o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !indexExists(t, db, idx.Name) {
+		t.Fatal("index not created")
+	}
+
+	err = migrateConn(conn, "20240101T000000Z", false, &version{version: "20240101T000000Z"}, o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if indexExists(t, db, idx.Name) {
+		t.Error("index not dropped")
+	}
+}
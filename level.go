@@ -0,0 +1,71 @@
+package migrator
+
+// A Level is the severity of a logged message.
+type Level int
+
+// Levels of logged messages in increasing severity.
+const (
+	LevelDebug Level = iota // statements echoed by WithEcho
+	LevelInfo               // migrations skipped or excluded
+	LevelWarn               // slow migrations and risky statements
+	LevelError              // failures
+)
+
+// String returns the name of the level.
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "debug"
+	case LevelInfo:
+		return "info"
+	case LevelWarn:
+		return "warn"
+	case LevelError:
+		return "error"
+	}
+
+	return "unknown"
+}
+
+// A LevelLogger is a Logger that records the level of each message, such
+// as an adapter to a structured logger. Messages are passed to Logf
+// without the "debug: " or "warning: " prefix written by Printf.
+type LevelLogger interface {
+	Logger
+	Logf(level Level, format string, v ...interface{})
+}
+
+// WithLogLevel discards messages less severe than the level. By default,
+// every message is logged.
+func WithLogLevel(l Level) Option {
+	return func(o *options) {
+		o.level = l
+	}
+}
+
+// WithQuiet discards every message except failures, for programs that
+// embed migrations and keep their own structured logs.
+func WithQuiet() Option {
+	return WithLogLevel(LevelError)
+}
+
+// prefixes are the prefixes of messages by level written with Printf.
+var prefixes = map[Level]string{
+	LevelDebug: "debug: ",
+	LevelWarn:  "warning: ",
+}
+
+// logf logs the message at the level unless it is less severe than the
+// level of the run.
+func (o *options) logf(level Level, format string, v ...interface{}) {
+	if level < o.level {
+		return
+	}
+
+	if l, ok := o.logger.(LevelLogger); ok {
+		l.Logf(level, format, v...)
+		return
+	}
+
+	o.logger.Printf(prefixes[level]+format, v...)
+}
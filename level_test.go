@@ -0,0 +1,79 @@
+package migrator
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// levelLogger records the messages logged by a run with their levels.
+type levelLogger struct {
+	testLogger
+	levels []Level
+}
+
+func (l *levelLogger) Logf(level Level, format string, v ...interface{}) {
+	l.levels = append(l.levels, level)
+	l.Printf(format, v...)
+}
+
+func TestLogf(t *testing.T) {
+	tests := []struct {
+		level Level
+		opts  []Option
+		want  []string
+	}{
+		{LevelDebug, nil, []string{"debug: message"}},
+		{LevelInfo, nil, []string{"message"}},
+		{LevelWarn, nil, []string{"warning: message"}},
+		{LevelError, nil, []string{"message"}},
+		{LevelInfo, []Option{WithLogLevel(LevelWarn)}, nil},
+		{LevelWarn, []Option{WithLogLevel(LevelWarn)}, []string{"warning: message"}},
+		{LevelWarn, []Option{WithQuiet()}, nil},
+		{LevelError, []Option{WithQuiet()}, []string{"message"}},
+	}
+
+	for _, tt := range tests {
+		l := &testLogger{}
+		o := newOptions(append([]Option{WithLogger(l)}, tt.opts...))
+		o.logf(tt.level, "%s", "message")
+
+		have := l.messages()
+		if !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("logf(%s) with level %s logged %q, want %q", tt.level, o.level, have, tt.want)
+		}
+	}
+}
+
+func TestLevelLogger(t *testing.T) {
+	isolate(t)
+
+	skip := func(tx *sql.Tx) (bool, error) { return false, nil }
+	Register("20240101T000000Z", "skipped", empty, empty, ShouldRun(skip))
+	Register("20240102T000000Z", "failing", func(tx *sql.Tx) error { return fmt.Errorf("failed") }, empty)
+
+	l := &levelLogger{}
+	db := openTestDB(t)
+	err := Migrate(db, "", WithDialect(SQLite), WithLogger(l))
+	if err == nil {
+		t.Fatal("Migrate error = nil")
+	}
+
+	want := []string{
+		`skipping "20240101T000000Z": predicate returned false`,
+		`error migrating "20240102T000000Z": failed`,
+	}
+
+	if !reflect.DeepEqual(l.messages(), want) || !reflect.DeepEqual(l.levels, []Level{LevelInfo, LevelError}) {
+		t.Errorf("logged %q at %v, want %q at [info error]", l.messages(), l.levels, want)
+	}
+}
+
+func TestLevelString(t *testing.T) {
+	for l, want := range map[Level]string{LevelDebug: "debug", LevelInfo: "info", LevelWarn: "warn", LevelError: "error", Level(9): "unknown"} {
+		if l.String() != want {
+			t.Errorf("Level(%d).String() = %q, want %q", l, l.String(), want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package migrator
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"strings"
+)
+
+// A Finding is a problem of a registered migration reported by Lint.
+type Finding struct {
+	Version string `json:"version"`
+	Name    string `json:"name"`
+	Problem string `json:"problem"`
+}
+
+// String returns the version timestamp, name and problem of the finding.
+func (f Finding) String() string {
+	return fmt.Sprintf("%s %s: %s", f.Version, f.Name, f.Problem)
+}
+
+// Lint returns the problems of the down migrations of the registered
+// migrations in ascending order by version timestamp, so that an
+// irreversible migration is a deliberate decision marked with the
+// Irreversible option rather than an accident discovered during a
+// rollback. A down migration is reported if it does nothing, is the same
+// as its up migration or is marked TODO. The bodies of Go migrations are
+// read from their source files when they are available.
+func Lint() []Finding {
+	var rv []Finding
+	for _, v := range sorted() {
+		m := migrations[v]
+		if v == NilVersion || m.irreversible {
+			continue
+		}
+
+		problem := m.lint()
+		if problem != "" {
+			rv = append(rv, Finding{Version: v, Name: m.name, Problem: problem})
+		}
+	}
+
+	return rv
+}
+
+// lint returns the problem of the down migration, if any.
+func (m *migration) lint() string {
+	if m.sql {
+		switch {
+		case len(m.downSQL) == 0:
+			return "down migration is missing or empty"
+		case reflect.DeepEqual(m.downSQL, m.upSQL):
+			return "down migration is the same as up"
+		case todo(strings.Join(m.downSQL, "\n")):
+			return "down migration is marked TODO"
+		}
+
+		return ""
+	}
+
+	var up, down interface{} = m.up, m.down
+	if m.upConn != nil {
+		up, down = m.upConn, m.downConn
+	}
+
+	if reflect.ValueOf(up).Pointer() == reflect.ValueOf(down).Pointer() {
+		return "down migration is the same as up"
+	}
+
+	body, ok := funcBody(down)
+	if !ok {
+		return ""
+	}
+
+	switch {
+	case body == "" || body == "return nil":
+		return "down migration does nothing"
+	case todo(body):
+		return "down migration is marked TODO"
+	}
+
+	return ""
+}
+
+// todo returns true if the text contains a TODO marker.
+func todo(s string) bool {
+	return strings.Contains(s, "TODO") || strings.Contains(s, "FIXME")
+}
+
+// funcBody returns the statements of the body of the function, read from
+// its source file, trimmed of blank lines, line comments other than
+// TODO markers and surrounding space. It returns false if the source is
+// not available.
+func funcBody(fn interface{}) (string, bool) {
+	file, line := funcLine(fn)
+	if file == "" {
+		return "", false
+	}
+
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return "", false
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, file, src, parser.ParseComments)
+	if err != nil {
+		return "", false
+	}
+
+	// The line is that of the declaration or, for functions without a
+	// frame, of the first statement, so the innermost function whose
+	// span includes it is the one declared there.
+	var body *ast.BlockStmt
+	ast.Inspect(f, func(n ast.Node) bool {
+		var b *ast.BlockStmt
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			b = n.Body
+		case *ast.FuncLit:
+			b = n.Body
+		}
+
+		if b != nil && fset.Position(n.Pos()).Line <= line && line <= fset.Position(b.Rbrace).Line {
+			body = b
+		}
+		return true
+	})
+
+	if body == nil {
+		return "", false
+	}
+
+	text := string(src[fset.Position(body.Lbrace).Offset+1 : fset.Position(body.Rbrace).Offset])
+
+	var stmts []string
+	for _, l := range strings.Split(text, "\n") {
+		l = strings.TrimSpace(l)
+		if l == "" || strings.HasPrefix(l, "//") && !todo(l) {
+			continue
+		}
+		stmts = append(stmts, l)
+	}
+
+	return strings.Join(stmts, "\n"), true
+}
@@ -0,0 +1,94 @@
+package migrator
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func lintUp(tx *sql.Tx) error {
+	_, err := tx.Exec("CREATE TABLE users (id INTEGER);")
+	return err
+}
+
+func lintDown(tx *sql.Tx) error {
+	_, err := tx.Exec("DROP TABLE users;")
+	return err
+}
+
+func lintNothing(tx *sql.Tx) error {
+	// Nothing to revert.
+	return nil
+}
+
+func lintTODO(tx *sql.Tx) error {
+	// TODO: drop the users table.
+	return nil
+}
+
+func lintConn(conn *sql.Conn) error { return nil }
+
+func TestLint(t *testing.T) {
+	isolate(t)
+
+	create := []string{"CREATE TABLE users (id INTEGER);"}
+	registerSQL("20240101T000000Z", "sql_ok", create, []string{"DROP TABLE users;"}, false, nil)
+	registerSQL("20240102T000000Z", "sql_missing", create, nil, false, nil)
+	registerSQL("20240103T000000Z", "sql_same", create, create, false, nil)
+	registerSQL("20240104T000000Z", "sql_todo", create, []string{"-- TODO\nSELECT 1;"}, false, nil)
+	registerSQL("20240105T000000Z", "sql_irreversible", create, nil, false, []MigrationOption{Irreversible()})
+	Register("20240106T000000Z", "go_ok", lintUp, lintDown)
+	Register("20240107T000000Z", "go_nothing", lintUp, lintNothing)
+	Register("20240108T000000Z", "go_todo", lintUp, lintTODO)
+	Register("20240109T000000Z", "go_same", lintUp, lintUp)
+	RegisterNoTransaction("20240110T000000Z", "conn_nothing", lintConn, lintConn)
+
+	want := []Finding{
+		{"20240102T000000Z", "sql_missing", "down migration is missing or empty"},
+		{"20240103T000000Z", "sql_same", "down migration is the same as up"},
+		{"20240104T000000Z", "sql_todo", "down migration is marked TODO"},
+		{"20240107T000000Z", "go_nothing", "down migration does nothing"},
+		{"20240108T000000Z", "go_todo", "down migration is marked TODO"},
+		{"20240109T000000Z", "go_same", "down migration is the same as up"},
+		{"20240110T000000Z", "conn_nothing", "down migration is the same as up"},
+	}
+
+	have := Lint()
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("Lint = %v, want %v", have, want)
+	}
+}
+
+func TestFuncBody(t *testing.T) {
+	tests := []struct {
+		fn   interface{}
+		want string
+	}{
+		{lintDown, "_, err := tx.Exec(\"DROP TABLE users;\")\nreturn err"},
+		{lintNothing, "return nil"},
+		{lintTODO, "// TODO: drop the users table.\nreturn nil"},
+		{lintConn, "return nil"},
+	}
+
+	for _, tt := range tests {
+		have, ok := funcBody(tt.fn)
+		if !ok || have != tt.want {
+			t.Errorf("funcBody = %q, %t, want %q", have, ok, tt.want)
+		}
+	}
+}
+
+func TestRoundtripIrreversibleOption(t *testing.T) {
+	isolate(t)
+	registerSQL("20240101T000000Z", "seed", []string{"CREATE TABLE users (id INTEGER);"}, nil, false, []MigrationOption{Irreversible()})
+
+	db := openTestDB(t)
+	err := Roundtrip(db, WithDialect(SQLite))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !tableExists(t, db, "users") {
+		t.Error("Roundtrip reverted the irreversible migration")
+	}
+}
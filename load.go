@@ -0,0 +1,256 @@
+package migrator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// sqlFile matches the name of a SQL migration file.
+var sqlFile = regexp.MustCompile(`^(\d{8}T\d{6}Z)_(\w+)\.(up|down)\.sql$`)
+
+// noTransaction is the comment that marks a SQL migration file to run
+// outside of a transaction.
+const noTransaction = "-- migrator:no-transaction"
+
+// setPrefix is the prefix of the comment that assigns a SQL migration
+// file to a migration set.
+const setPrefix = "-- migrator:set "
+
+// irreversible is the comment that marks a SQL migration file as
+// deliberately irreversible.
+const irreversible = "-- migrator:irreversible"
+
+// LoadDir registers the SQL migrations in dir. Each migration is a pair
+// of files named <version>_<name>.up.sql and <version>_<name>.down.sql,
+// where the down file may be omitted for irreversible migrations. The
+// statements of each file are executed in order in a transaction, unless
+// the up file contains the line:
+//
+//	-- migrator:no-transaction
+//
+// Migrations whose up file contains the following line are registered
+// with the Irreversible option:
+//
+//	-- migrator:irreversible
+//
+// Migrations whose up file contains a line naming a set are registered
+// in the set with the InSet option:
+//
+//	-- migrator:set analytics
+//
+// Files named <name>.repeatable.sql are registered as repeatable
+// migrations, such as views and functions, that are executed whenever
+// their content changes. Other files are ignored.
+func LoadDir(dir string) error {
+	ms, rs, err := parseDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var vs []string
+	for v := range ms {
+		vs = append(vs, v)
+	}
+
+	sort.Strings(vs)
+
+	for _, v := range vs {
+		register(v, ms[v], nil)
+	}
+
+	var names []string
+	for name := range rs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		r := rs[name]
+		registerRepeatable(name, r.fn, r.checksum)
+		repeatables[name].source = r.source
+	}
+
+	return nil
+}
+
+// parseDir returns the SQL migrations in dir by version and the
+// repeatables in dir by name without registering them.
+func parseDir(dir string) (map[string]*migration, map[string]*repeatable, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	type pair struct {
+		name     string
+		up, down string
+		hasUp    bool
+	}
+
+	pairs := make(map[string]*pair)
+	rs := make(map[string]*repeatable)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		b, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if name := strings.TrimSuffix(entry.Name(), ".repeatable.sql"); name != entry.Name() {
+			rs[name] = sqlRepeatable(name, string(b))
+			rs[name].source = filepath.Join(dir, entry.Name())
+			continue
+		}
+
+		m := sqlFile.FindStringSubmatch(entry.Name())
+		if m == nil {
+			continue
+		}
+
+		p, ok := pairs[m[1]]
+		if !ok {
+			p = &pair{name: m[2]}
+			pairs[m[1]] = p
+		}
+
+		if p.name != m[2] {
+			return nil, nil, fmt.Errorf("migrator: %s: name does not match %s", entry.Name(), p.name)
+		}
+
+		if m[3] == "up" {
+			p.up, p.hasUp = string(b), true
+		} else {
+			p.down = string(b)
+		}
+	}
+
+	var vs []string
+	for v := range pairs {
+		vs = append(vs, v)
+	}
+
+	sort.Strings(vs)
+
+	ms := make(map[string]*migration)
+	for _, v := range vs {
+		p := pairs[v]
+		if !p.hasUp {
+			return nil, nil, fmt.Errorf("migrator: %s_%s: missing up file", v, p.name)
+		}
+
+		noTx := false
+		var opts []MigrationOption
+		for _, line := range strings.Split(p.up, "\n") {
+			line = strings.TrimSpace(line)
+			switch {
+			case line == noTransaction:
+				noTx = true
+			case line == irreversible:
+				opts = append(opts, Irreversible())
+			case strings.HasPrefix(line, setPrefix):
+				opts = append(opts, InSet(strings.TrimSpace(strings.TrimPrefix(line, setPrefix))))
+			}
+		}
+
+		m := sqlMigration(p.name, splitStatements(p.up), splitStatements(p.down), noTx)
+		for _, opt := range opts {
+			opt(m)
+		}
+
+		m.source = filepath.Join(dir, fmt.Sprintf("%s_%s.up.sql", v, p.name))
+		ms[v] = m
+	}
+
+	return ms, rs, nil
+}
+
+// splitStatements splits the SQL into its statements terminated by
+// semicolons, ignoring semicolons within comments, quoted strings,
+// quoted identifiers and dollar-quoted strings. Statements that are
+// empty or only comments are omitted.
+func splitStatements(query string) []string {
+	var rv []string
+	start := 0
+	meaningful := false
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '-' && strings.HasPrefix(query[i:], "--"):
+			end := strings.IndexByte(query[i:], '\n')
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end
+			}
+		case c == '/' && strings.HasPrefix(query[i:], "/*"):
+			end := strings.Index(query[i+2:], "*/")
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += end + 3
+			}
+		case c == '\'' || c == '"':
+			meaningful = true
+			for i++; i < len(query); i++ {
+				if query[i] == c {
+					if i+1 < len(query) && query[i+1] == c {
+						i++
+						continue
+					}
+					break
+				}
+			}
+		case c == '$':
+			meaningful = true
+			tag := dollarTag(query[i:])
+			if tag == "" {
+				continue
+			}
+
+			end := strings.Index(query[i+len(tag):], tag)
+			if end < 0 {
+				i = len(query)
+			} else {
+				i += len(tag) + end + len(tag) - 1
+			}
+		case c == ';':
+			if meaningful {
+				rv = append(rv, strings.TrimSpace(query[start:i+1]))
+			}
+			start, meaningful = i+1, false
+		case c != ' ' && c != '\t' && c != '\n' && c != '\r':
+			meaningful = true
+		}
+	}
+
+	if meaningful {
+		rv = append(rv, strings.TrimSpace(query[start:]))
+	}
+
+	return rv
+}
+
+// dollarTag returns the dollar quote tag, such as $$ or $body$, at the
+// beginning of s, or an empty string if s does not begin with one.
+func dollarTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		if c == '$' {
+			return s[:i+1]
+		}
+
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || i > 1 && c >= '0' && c <= '9') {
+			return ""
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,143 @@
+package migrator
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"empty", "", nil},
+		{"single", "SELECT 1;", []string{"SELECT 1;"}},
+		{"multiple", "SELECT 1;\nSELECT 2;\n", []string{"SELECT 1;", "SELECT 2;"}},
+		{"unterminated", "SELECT 1;\nSELECT 2", []string{"SELECT 1;", "SELECT 2"}},
+		{"empty statements", ";\n;SELECT 1;;", []string{"SELECT 1;"}},
+		{"quoted semicolon", "SELECT ';';SELECT 2;", []string{"SELECT ';';", "SELECT 2;"}},
+		{"escaped quote", "SELECT 'it''s;';", []string{"SELECT 'it''s;';"}},
+		{"quoted identifier", `SELECT 1 AS "a;b";`, []string{`SELECT 1 AS "a;b";`}},
+		{"line comment", "-- a; b\nSELECT 1;", []string{"-- a; b\nSELECT 1;"}},
+		{"block comment", "/* a; b */ SELECT 1;", []string{"/* a; b */ SELECT 1;"}},
+		{"only comments", "SELECT 1;\n-- done;\n/* done; */", []string{"SELECT 1;"}},
+		{"dollar quote", "CREATE FUNCTION f() AS $$ BEGIN; END; $$;SELECT 1;", []string{"CREATE FUNCTION f() AS $$ BEGIN; END; $$;", "SELECT 1;"}},
+		{"tagged dollar quote", "SELECT $body$ $$; $body$;", []string{"SELECT $body$ $$; $body$;"}},
+		{"parameter", "SELECT $1;SELECT $2;", []string{"SELECT $1;", "SELECT $2;"}},
+		{"unterminated dollar quote", "SELECT $$ a; b", []string{"SELECT $$ a; b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitStatements(tt.query)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitStatements(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDollarTag(t *testing.T) {
+	tests := []struct {
+		s    string
+		want string
+	}{
+		{"$$", "$$"},
+		{"$$ BEGIN", "$$"},
+		{"$body$ BEGIN", "$body$"},
+		{"$_a1$", "$_a1$"},
+		{"$1", ""},
+		{"$1$", ""},
+		{"$a b$", ""},
+		{"$body", ""},
+		{"$", ""},
+	}
+
+	for _, tt := range tests {
+		got := dollarTag(tt.s)
+		if got != tt.want {
+			t.Errorf("dollarTag(%q) = %q, want %q", tt.s, got, tt.want)
+		}
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	isolate(t)
+	isolateRepeatables(t)
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"20240101T000000Z_users.up.sql":    "CREATE TABLE users (id INTEGER);\nCREATE INDEX users_id_idx ON users (id);\n",
+		"20240101T000000Z_users.down.sql":  "DROP TABLE users;\n",
+		"20240102T000000Z_index.up.sql":    "-- migrator:no-transaction\nCREATE INDEX CONCURRENTLY users_id ON users (id);\n",
+		"20240103T000000Z_seed.up.sql":     "-- migrator:irreversible\n-- migrator:set fixtures\nINSERT INTO users (id) VALUES (1);\n",
+		"active_users.repeatable.sql":      "CREATE OR REPLACE VIEW active_users AS SELECT id FROM users;\n",
+		"README.md":                        "ignored",
+		"20240104T000000Z_users.up.sql.sw": "ignored",
+	}
+
+	for name, data := range files {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(migrations) != 4 {
+		t.Fatalf("%d migrations registered, want 3 and the empty migration", len(migrations))
+	}
+
+	m := migrations["20240101T000000Z"]
+	if m.name != "users" || m.up == nil || len(m.upSQL) != 2 || !reflect.DeepEqual(m.downSQL, []string{"DROP TABLE users;"}) {
+		t.Errorf("users migration = %+v", m)
+	}
+
+	if m := migrations["20240102T000000Z"]; m.upConn == nil {
+		t.Error("no-transaction migration registered in a transaction")
+	}
+
+	if m := migrations["20240103T000000Z"]; m.downSQL != nil || !m.irreversible || m.set != "fixtures" {
+		t.Errorf("irreversible migration has down statements %q or is not marked in the fixtures set", m.downSQL)
+	}
+
+	if _, ok := repeatables["active_users"]; !ok {
+		t.Error("repeatable not registered")
+	}
+}
+
+func TestLoadDirErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []string
+	}{
+		{"missing up file", []string{"20240101T000000Z_users.down.sql"}},
+		{"name mismatch", []string{"20240101T000000Z_users.up.sql", "20240101T000000Z_accounts.down.sql"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t)
+
+			dir := t.TempDir()
+			for _, name := range tt.files {
+				err := os.WriteFile(filepath.Join(dir, name), []byte("SELECT 1;"), 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := LoadDir(dir)
+			if err == nil {
+				t.Errorf("LoadDir(%q) error = nil", tt.files)
+			}
+		})
+	}
+}
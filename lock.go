@@ -0,0 +1,180 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+)
+
+// lockPrefix is the prefix of the name of the lock held by runs with
+// WithLock, followed by the namespace of the run.
+const lockPrefix = "migrator:"
+
+// Queries that acquire and release the lock of a run by dialect.
+var (
+	queryLockPostgres   = `SELECT pg_advisory_lock($1);`
+	queryUnlockPostgres = `SELECT pg_advisory_unlock($1);`
+	queryLockMySQL      = `SELECT GET_LOCK(?, -1);`
+	queryUnlockMySQL    = `SELECT RELEASE_LOCK(?);`
+)
+
+// queryLockHolderPostgres terminates the sessions holding an advisory
+// lock by the high and low 32 bits of its key.
+var queryLockHolderPostgres = `
+SELECT pg_terminate_backend(pid)
+  FROM pg_locks
+  WHERE locktype = 'advisory' AND granted
+    AND classid::bigint = $1 AND objid::bigint = $2 AND objsubid = 1;
+`
+
+// queryLockHolderMySQL selects the connection holding a named lock.
+var queryLockHolderMySQL = `SELECT IS_USED_LOCK(?);`
+
+// WithLock holds a lock on the database for the duration of the run so
+// that concurrent runs, such as those of several instances of an
+// application migrating on startup, are performed one at a time. The
+// lock is a session advisory lock on Postgres and a named lock on MySQL,
+// named after the namespace of the run. SQLite serializes writers itself
+// and takes no lock.
+func WithLock() Option {
+	return func(o *options) {
+		o.lock = true
+	}
+}
+
+// WithLockNamespace sets the namespace of the lock of WithLock, so that
+// the runs of unrelated applications sharing a database server do not
+// wait on each other. The default namespace is the table name of the run.
+func WithLockNamespace(ns string) Option {
+	return func(o *options) {
+		o.namespace = ns
+	}
+}
+
+// lockName returns the name of the lock held by the run with WithLock.
+func (o *options) lockName() string {
+	if o.namespace == "" {
+		return lockPrefix + o.table
+	}
+
+	return lockPrefix + o.namespace
+}
+
+// A Lock is held for the duration of a run so that concurrent runs are
+// performed one at a time, such as a lock held in a store outside of the
+// database. Acquire blocks until the lock is held or the context is done
+// and returns a function that releases it.
+type Lock interface {
+	Acquire(ctx context.Context) (release func(), err error)
+}
+
+// WithExternalLock holds the lock for the duration of the run instead of
+// the lock of WithLock, for databases without reliable locks of their
+// own, such as some managed MySQL flavors. ForceUnlock does not release
+// it.
+func WithExternalLock(l Lock) Option {
+	return func(o *options) {
+		o.lock = true
+		o.locker = l
+	}
+}
+
+// acquire blocks until the lock of the run is held on the connection, or
+// the external lock of the run is held, and returns a function that
+// releases it. It is a no-op unless the run has WithLock or
+// WithExternalLock set.
+func (o *options) acquire(conn *sql.Conn) (func(), error) {
+	if !o.lock {
+		return func() {}, nil
+	}
+
+	if o.locker != nil {
+		return o.acquireExternal()
+	}
+
+	var lock, unlock string
+	var key interface{}
+	switch o.dialect {
+	case Postgres:
+		lock, unlock, key = queryLockPostgres, queryUnlockPostgres, lockKey(o.lockName())
+	case MySQL:
+		lock, unlock, key = queryLockMySQL, queryUnlockMySQL, o.lockName()
+	default:
+		return func() {}, nil
+	}
+
+	ctx, cancel := o.waitContext()
+	defer cancel()
+
+	began := o.now()
+	var granted sql.NullString
+	err := conn.QueryRowContext(ctx, lock, key).Scan(&granted)
+	if err != nil {
+		return nil, fmt.Errorf("migrator: acquiring lock: %w", err)
+	}
+
+	if o.dialect == MySQL && granted.String != "1" {
+		return nil, fmt.Errorf("migrator: acquiring lock: not granted")
+	}
+
+	o.emit(LockAcquired{Waited: o.since(began)})
+
+	// The lock is released even if the run is canceled.
+	return func() {
+		err := conn.QueryRowContext(context.WithoutCancel(o.ctx), unlock, key).Scan(&granted)
+		if err != nil {
+			o.logf(LevelError, "error releasing lock: %v", err)
+		}
+	}, nil
+}
+
+// acquireExternal blocks until the external lock of the run is held and
+// returns a function that releases it.
+func (o *options) acquireExternal() (func(), error) {
+	ctx, cancel := o.waitContext()
+	defer cancel()
+
+	began := o.now()
+	release, err := o.locker.Acquire(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("migrator: acquiring lock: %w", err)
+	}
+
+	o.emit(LockAcquired{Waited: o.since(began)})
+
+	return release, nil
+}
+
+// lockKey returns the advisory lock key of the name.
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte(name))
+	return int64(h.Sum64())
+}
+
+// ForceUnlock releases the lock of WithLock held by another session,
+// such as one left behind by a crashed run, by terminating the session
+// that holds it. The migration that session was performing is rolled
+// back by the database.
+func ForceUnlock(db *sql.DB, opts ...Option) error {
+	o := newOptions(opts)
+
+	switch o.dialect {
+	case Postgres:
+		key := uint64(lockKey(o.lockName()))
+		_, err := db.ExecContext(o.ctx, queryLockHolderPostgres, int64(key>>32), int64(key&0xffffffff))
+		return err
+	case MySQL:
+		var id sql.NullInt64
+		err := db.QueryRowContext(o.ctx, queryLockHolderMySQL, o.lockName()).Scan(&id)
+		if err != nil || !id.Valid {
+			return err
+		}
+
+		_, err = db.ExecContext(o.ctx, fmt.Sprintf("KILL %d", id.Int64))
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package migrator
+
+import "context"
+
+// WithMaintenance calls enter before the first migration of the run is
+// performed and exit after the last, such as to put an application into
+// maintenance mode, pause consumers or drain traffic. Neither is called
+// when the run has no migrations to perform. If enter returns an error,
+// the run is aborted with it without performing any migration. Once enter
+// succeeds, exit is called even if the run fails or its context is
+// cancelled, with a context that is not.
+func WithMaintenance(enter, exit func(ctx context.Context) error) Option {
+	return func(o *options) {
+		o.enter = enter
+		o.exit = exit
+	}
+}
+
+// enterMaintenance calls the enter callback of the run if it performs
+// migrations and returns a function that calls the exit callback.
+func (o *options) enterMaintenance(vs []string) (func() error, error) {
+	if len(vs) == 0 || (o.enter == nil && o.exit == nil) {
+		return func() error { return nil }, nil
+	}
+
+	if o.enter != nil {
+		o.logf(LevelInfo, "entering maintenance")
+		err := o.enter(o.ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return func() error {
+		if o.exit == nil {
+			return nil
+		}
+
+		o.logf(LevelInfo, "exiting maintenance")
+		return o.exit(context.WithoutCancel(o.ctx))
+	}, nil
+}
@@ -0,0 +1,89 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestWithMaintenance(t *testing.T) {
+	isolate(t)
+
+	var calls []string
+	up := func(tx *sql.Tx) error {
+		calls = append(calls, "up")
+		return nil
+	}
+
+	Register("20240101T000000Z", "first", up, empty)
+	Register("20240102T000000Z", "second", up, empty)
+
+	enter := func(ctx context.Context) error {
+		calls = append(calls, "enter")
+		return nil
+	}
+
+	exit := func(ctx context.Context) error {
+		calls = append(calls, "exit")
+		return nil
+	}
+
+	db := openTestDB(t)
+	opts := []Option{WithDialect(SQLite), WithMaintenance(enter, exit)}
+	err := Migrate(db, "", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	have := strings.Join(calls, ",")
+	if have != "enter,up,up,exit" {
+		t.Errorf("calls = %s, want the migrations within maintenance", have)
+	}
+
+	calls = nil
+	err = Migrate(db, "", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(calls) != 0 {
+		t.Errorf("calls = %v without migrations to perform, want none", calls)
+	}
+}
+
+func TestWithMaintenanceErrors(t *testing.T) {
+	isolate(t)
+
+	failed := errors.New("failed")
+	performed := false
+	Register("20240101T000000Z", "fails", func(tx *sql.Tx) error {
+		performed = true
+		return failed
+	}, empty)
+
+	db := openTestDB(t)
+	refused := errors.New("refused")
+	exited := false
+	exit := func(ctx context.Context) error {
+		exited = true
+		return ctx.Err()
+	}
+
+	err := Migrate(db, "", WithDialect(SQLite), WithMaintenance(func(ctx context.Context) error { return refused }, exit))
+	if !errors.Is(err, refused) || performed || exited {
+		t.Errorf("enter error = %v, performed %t, exited %t, want the run aborted", err, performed, exited)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	enter := func(context.Context) error {
+		cancel()
+		return nil
+	}
+
+	err = Migrate(db, "", WithDialect(SQLite), WithContext(ctx), WithMaintenance(enter, exit))
+	if err == nil || !exited {
+		t.Errorf("run error = %v, exited %t, want exit called after the cancelled run", err, exited)
+	}
+}
@@ -0,0 +1,166 @@
+package migrator
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ShardErrors are the errors of the shards that failed to migrate, or
+// were not migrated after an earlier failure, keyed by shard.
+type ShardErrors map[string]error
+
+// Error returns the errors of each shard in order of shard.
+func (e ShardErrors) Error() string {
+	var shards []string
+	for shard := range e {
+		shards = append(shards, shard)
+	}
+
+	sort.Strings(shards)
+
+	msgs := make([]string, len(shards))
+	for i, shard := range shards {
+		msgs[i] = fmt.Sprintf("%s: %v", shard, e[shard])
+	}
+
+	return fmt.Sprintf("migrator: %d shards not migrated: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// WithParallelism sets the number of shards MigrateMany migrates at
+// once. By default, shards are migrated one at a time.
+func WithParallelism(n int) Option {
+	return func(o *options) {
+		o.parallel = n
+	}
+}
+
+// WithFailFast stops MigrateMany from starting more shards after a shard
+// fails. Shards already in progress finish. By default, the remaining
+// shards are migrated regardless of failures.
+func WithFailFast() Option {
+	return func(o *options) {
+		o.failFast = true
+	}
+}
+
+// A Result is the outcome of migrating one shard of MigrateMany or one
+// tenant schema of MigrateAll.
+type Result struct {
+	Name    string   `json:"name"`              // shard or tenant schema
+	Applied []string `json:"applied,omitempty"` // version timestamps performed in order
+	Failed  string   `json:"failed,omitempty"`  // version timestamp that failed, if any
+	Skipped bool     `json:"skipped,omitempty"` // true if not migrated after an earlier failure
+	Error   string   `json:"error,omitempty"`   // error of the target, if any
+	Err     error    `json:"-"`                 // error of the target, if any
+}
+
+// result returns the result of the named target from the run and error.
+func result(name string, run *Run, err error) *Result {
+	r := &Result{Name: name, Err: err}
+	if run != nil {
+		r.Applied = run.Performed
+		r.Failed = run.Failed
+	}
+
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	return r
+}
+
+// Results are the outcomes of migrating each target.
+type Results []*Result
+
+// OK returns true if every target was migrated successfully.
+func (rs Results) OK() bool {
+	return len(rs.Failed()) == 0
+}
+
+// Failed returns the names of the targets that failed or were skipped,
+// whose schemas may be behind the others or partially migrated.
+func (rs Results) Failed() []string {
+	var rv []string
+	for _, r := range rs {
+		if r.Err != nil {
+			rv = append(rv, r.Name)
+		}
+	}
+
+	return rv
+}
+
+// MigrateMany performs the database migrations on each shard to bring
+// it to the state of the target version timestamp, in order of shard
+// name. The result of every shard is returned, and the shards that
+// failed or were not started after a failure with WithFailFast are also
+// returned as ShardErrors.
+func MigrateMany(dbs map[string]*sql.DB, target string, opts ...Option) (Results, error) {
+	var shards []string
+	for shard := range dbs {
+		shards = append(shards, shard)
+	}
+
+	sort.Strings(shards)
+
+	o := newOptions(opts)
+	n := o.parallel
+	if n < 1 {
+		n = 1
+	}
+
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		failed string
+		sem    = make(chan struct{}, n)
+		rv     = make(Results, len(shards))
+		errs   = make(ShardErrors)
+	)
+	for i, shard := range shards {
+		sem <- struct{}{}
+		mu.Lock()
+		if failed != "" && o.failFast {
+			err := fmt.Errorf("migrator: not migrated after shard %q failed", failed)
+			rv[i] = result(shard, nil, err)
+			rv[i].Skipped = true
+			errs[shard] = err
+			mu.Unlock()
+			<-sem
+			continue
+		}
+		mu.Unlock()
+
+		wg.Add(1)
+		go func(i int, shard string) {
+			defer func() { <-sem }()
+			defer wg.Done()
+
+			so := newOptions(opts)
+			so.result = nil
+			err := migrateRun(dbs[shard], target, so)
+
+			mu.Lock()
+			defer mu.Unlock()
+			rv[i] = result(shard, so.run, err)
+			if err != nil {
+				o.logf(LevelError, "error migrating shard %q: %v", shard, err)
+				errs[shard] = err
+				if failed == "" {
+					failed = shard
+				}
+			}
+		}(i, shard)
+	}
+
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return rv, errs
+	}
+
+	return rv, nil
+}
@@ -0,0 +1,92 @@
+package migrator
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testShards returns SQLite databases keyed by shard name, of which
+// shard b already has a users table so that creating it fails.
+func testShards(t *testing.T) map[string]*sql.DB {
+	t.Helper()
+
+	dbs := map[string]*sql.DB{"a": openTestDB(t), "b": openTestDB(t), "c": openTestDB(t)}
+	_, err := dbs["b"].Exec("CREATE TABLE users (id INTEGER);")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return dbs
+}
+
+func TestMigrateMany(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+
+	dbs := testShards(t)
+	rs, err := MigrateMany(dbs, "", WithDialect(SQLite), WithParallelism(2))
+
+	var errs ShardErrors
+	if !errors.As(err, &errs) || len(errs) != 1 || errs["b"] == nil {
+		t.Fatalf("MigrateMany error = %v, want shard b failed", err)
+	}
+
+	if rs.OK() || !reflect.DeepEqual(rs.Failed(), []string{"b"}) {
+		t.Errorf("MigrateMany failed %q, want b", rs.Failed())
+	}
+
+	want := Results{
+		{Name: "a", Applied: []string{NilVersion, "20240101T000000Z"}},
+		{Name: "b", Applied: []string{NilVersion}, Failed: "20240101T000000Z", Error: errs["b"].Error(), Err: errs["b"]},
+		{Name: "c", Applied: []string{NilVersion, "20240101T000000Z"}},
+	}
+
+	if !reflect.DeepEqual(rs, want) {
+		t.Errorf("MigrateMany = %+v, want %+v", rs, want)
+	}
+
+	for _, shard := range []string{"a", "c"} {
+		if !tableExists(t, dbs[shard], "versions") {
+			t.Errorf("shard %s not migrated", shard)
+		}
+	}
+}
+
+func TestMigrateManyFailFast(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+
+	dbs := testShards(t)
+	rs, err := MigrateMany(dbs, "", WithDialect(SQLite), WithFailFast())
+
+	var errs ShardErrors
+	if !errors.As(err, &errs) || len(errs) != 2 || !strings.Contains(errs["c"].Error(), `not migrated after shard "b" failed`) {
+		t.Fatalf("MigrateMany error = %v, want shard c not migrated after b", err)
+	}
+
+	if !rs[2].Skipped || rs[2].Applied != nil || tableExists(t, dbs["c"], "users") {
+		t.Error("shard c migrated after shard b failed")
+	}
+}
+
+func TestShardErrors(t *testing.T) {
+	err := ShardErrors{"b": errors.New("two"), "a": errors.New("one")}
+	want := "migrator: 2 shards not migrated: a: one; b: two"
+	if err.Error() != want {
+		t.Errorf("Error = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestResults(t *testing.T) {
+	rs := Results{{Name: "a"}, {Name: "b", Err: errors.New("failed")}}
+	if rs.OK() || !reflect.DeepEqual(rs.Failed(), []string{"b"}) {
+		t.Errorf("Failed = %q, want b", rs.Failed())
+	}
+
+	if !rs[:1].OK() {
+		t.Error("OK = false without failures")
+	}
+}
@@ -2,134 +2,719 @@
 package migrator
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"os"
+	"reflect"
+	"runtime"
 	"sort"
+	"strings"
+	"text/template"
 )
 
-// A migration is a named pair of migrationFunc.
+// A migration is a named pair of migrationFunc or, for migrations that
+// run outside of a transaction, a named pair of connFunc.
 type migration struct {
-	name string
-	up   migrationFunc
-	down migrationFunc
+	name         string
+	up           migrationFunc
+	down         migrationFunc
+	upConn       connFunc
+	downConn     connFunc
+	upSQL        []string
+	downSQL      []string
+	shouldRun    predicateFunc
+	tags         []string
+	phase        Phase
+	settings     settings
+	source       string
+	test         migrationFunc
+	sql          bool
+	irreversible bool
+	origin       string
+	set          string
+	expand       bool
+	contracts    string
 }
 
 // A migrationFunc is a function that performs operations on a
 // SQL transaction and returns an error.
 type migrationFunc func(tx *sql.Tx) error
 
+// A connFunc is a function that performs operations on a dedicated
+// SQL connection outside of a transaction and returns an error.
+type connFunc func(conn *sql.Conn) error
+
+// A predicateFunc is a function that inspects the database state within
+// a SQL transaction and reports whether a migration should run.
+type predicateFunc func(tx *sql.Tx) (bool, error)
+
+// A MigrationOption configures a registered migration.
+type MigrationOption func(*migration)
+
+// ShouldRun sets a predicate that is consulted before the up migration
+// runs. If the predicate returns false, the up migration is skipped and
+// the version is recorded as skipped rather than applied. Migrating down
+// past a skipped version only removes the record.
+func ShouldRun(fn func(tx *sql.Tx) (bool, error)) MigrationOption {
+	return func(m *migration) {
+		m.shouldRun = fn
+	}
+}
+
+// Test sets a function that Roundtrip runs after applying the migration
+// to assert its effect, such as that a backfill populated a new column.
+// The test runs in a transaction that is rolled back.
+func Test(fn func(tx *sql.Tx) error) MigrationOption {
+	return func(m *migration) {
+		m.test = fn
+	}
+}
+
+// Irreversible marks the migration as deliberately irreversible, so Lint
+// does not report that its down migration does nothing and Roundtrip
+// does not revert it.
+func Irreversible() MigrationOption {
+	return func(m *migration) {
+		m.irreversible = true
+	}
+}
+
+// Tags labels the migration so that runs can select or exclude it with
+// the WithTags and WithoutTags options.
+func Tags(tags ...string) MigrationOption {
+	return func(m *migration) {
+		m.tags = append(m.tags, tags...)
+	}
+}
+
+// hasTag returns true if the migration is labeled with any of tags.
+func (m *migration) hasTag(tags []string) bool {
+	for _, t := range m.tags {
+		for _, tag := range tags {
+			if t == tag {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// NilVersion is the version timestamp of the empty migration that
+// represents the state of the database before any migration. Migrate to
+// NilVersion to revert every migration.
+const NilVersion = "00010101T000000Z"
+
+// VersionLayout is the time layout of version timestamps.
+const VersionLayout = "20060102T150405Z"
+
 // migrations is a map of migration keyed by version timestamp.
 var migrations = make(map[string]*migration)
 
 // Register makes a migration available by the provided name.
-// If Register is called twice with the same name or if a
-// migrationFunc is nil, it panics.
-func Register(version, name string, up, down migrationFunc) {
+// If Register is called twice with the same version, it panics with
+// the file and line of both registrations. If a migrationFunc is nil,
+// it panics.
+func Register(version, name string, up, down migrationFunc, opts ...MigrationOption) {
 	if up == nil || down == nil {
 		panic("migrator: Register up and down are both required")
 	}
 
-	if _, ok := migrations[version]; ok {
-		panic("migrator: Register called twice for migrator " + version)
+	register(version, &migration{name: name, up: up, down: down}, opts)
+}
+
+// RegisterNoTransaction makes a migration that runs outside of a
+// transaction available by the provided name. This is required for
+// statements that cannot run inside a transaction and for migrations
+// that manage their own transactions, such as those using Backfill.
+// The version is recorded only after the migrationFunc succeeds.
+// If RegisterNoTransaction is called twice with the same name or if a
+// migrationFunc is nil, it panics.
+func RegisterNoTransaction(version, name string, up, down connFunc, opts ...MigrationOption) {
+	if up == nil || down == nil {
+		panic("migrator: RegisterNoTransaction up and down are both required")
+	}
+
+	register(version, &migration{name: name, upConn: up, downConn: down}, opts)
+}
+
+// RegisterSQL makes a migration that executes the statements of the up
+// and down SQL in order in a transaction available by the provided name,
+// splitting each on the semicolons that terminate its statements as
+// LoadDir does. An empty down SQL reverts nothing. If RegisterSQL is
+// called twice with the same version, it panics.
+func RegisterSQL(version, name, upSQL, downSQL string, opts ...MigrationOption) {
+	registerSQL(version, name, splitStatements(upSQL), splitStatements(downSQL), false, opts)
+}
+
+// registerSQL makes a migration that executes the up and down SQL
+// statements in order available by the provided name. The statements run
+// in a transaction unless noTx is true. Unlike a migrationFunc, the
+// statements of a SQL migration can be analyzed before they run.
+func registerSQL(version, name string, up, down []string, noTx bool, opts []MigrationOption) {
+	register(version, sqlMigration(name, up, down, noTx), opts)
+}
+
+// sqlMigration returns a migration that executes the up and down SQL
+// statements in order, in a transaction unless noTx is true.
+func sqlMigration(name string, up, down []string, noTx bool) *migration {
+	m := &migration{name: name, upSQL: up, downSQL: down, sql: true}
+	if noTx {
+		m.upConn = func(conn *sql.Conn) error { return execStatements(conn, up) }
+		m.downConn = func(conn *sql.Conn) error { return execStatements(conn, down) }
+	} else {
+		m.up = func(tx *sql.Tx) error { return execStatements(tx, up) }
+		m.down = func(tx *sql.Tx) error { return execStatements(tx, down) }
+	}
+
+	return m
+}
+
+// An Execer is a transaction or connection that executes statements.
+// *sql.Tx, *sql.Conn and *sql.DB satisfy the interface.
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Exec executes the statement with the arguments on the *sql.Tx or
+// *sql.Conn provided to a migration. Unlike calling Exec on the handle
+// directly, the statement is echoed when the run echoes statements, is
+// reported by slow migration warnings, is timed, counts towards the rows
+// affected by the migration and is canceled with the context of the run.
+func Exec(e Execer, query string, args ...interface{}) (sql.Result, error) {
+	done := executed(e, query, args)
+	rv, err := e.ExecContext(optionsOf(e).ctx, query, args...)
+	done(err)
+	if err == nil {
+		affected(e, rv)
+	}
+	return rv, err
+}
+
+// ExecAll executes the statements in order with Exec on the *sql.Tx or
+// *sql.Conn provided to a migration, stopping at the first error, which
+// names the statement that failed.
+func ExecAll(e Execer, stmts ...string) error {
+	return execStatements(e, stmts)
+}
+
+// ExecTemplate executes each statement as a text/template with the data
+// and executes the results in order as ExecAll does, such as to name the
+// tables of a migration registered for several schemas. No statement is
+// executed if a template fails.
+func ExecTemplate(e Execer, data interface{}, stmts ...string) error {
+	rv := make([]string, len(stmts))
+	for i, stmt := range stmts {
+		t, err := template.New("statement").Parse(stmt)
+		if err != nil {
+			return fmt.Errorf("migrator: %q: %w", stmt, err)
+		}
+
+		var b strings.Builder
+		err = t.Execute(&b, data)
+		if err != nil {
+			return fmt.Errorf("migrator: %q: %w", stmt, err)
+		}
+
+		rv[i] = b.String()
+	}
+
+	return execStatements(e, rv)
+}
+
+// execStatements executes the statements in order on the transaction or
+// connection, stopping at the first error.
+func execStatements(e Execer, stmts []string) error {
+	for _, stmt := range stmts {
+		_, err := Exec(e, stmt)
+		if err != nil {
+			return fmt.Errorf("migrator: %q: %w", stmt, err)
+		}
 	}
 
-	migrations[version] = &migration{name: name, up: up, down: down}
+	return nil
+}
+
+// register applies the options to the migration and makes it available
+// by version timestamp.
+func register(version string, m *migration, opts []MigrationOption) {
+	m.origin = caller()
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	if prev, ok := migrations[version]; ok {
+		panic(fmt.Sprintf("migrator: version %s registered twice, by %s and %s", version, prev.registeredAt(), m.registeredAt()))
+	}
+
+	migrations[version] = m
+}
+
+// registeredAt returns the file of a migration loaded from a file or the
+// file and line of the code outside of the package that registered it.
+func (m *migration) registeredAt() string {
+	if m.source != "" {
+		return m.source
+	}
+
+	if m.origin != "" {
+		return m.origin
+	}
+
+	return "unknown"
+}
+
+// caller returns the file and line of the innermost caller outside of
+// the package, or an empty string if there is none.
+func caller() string {
+	self := runtime.FuncForPC(reflect.ValueOf(caller).Pointer()).Name()
+	prefix := strings.TrimSuffix(self, "caller")
+
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		if !strings.HasPrefix(f.Function, prefix) {
+			return fmt.Sprintf("%s:%d", f.File, f.Line)
+		}
+
+		if !more {
+			return ""
+		}
+	}
 }
 
 // Migrate performs the database migrations to bring the database
 // to the state of the target version timestamp. Use an empty target
-// to represent the most recent migration.
-func Migrate(db *sql.DB, target string) error {
-	vs := sorted()
-	if target == "" {
-		target = vs[len(vs)-1]
+// to represent the most recent migration. Options may narrow the set
+// of migrations that are performed.
+//
+// The migrations are performed on a single connection for the duration
+// of the run, so session settings made by the WithBefore hooks apply to
+// every migration.
+func Migrate(db *sql.DB, target string, opts ...Option) error {
+	return migrateRun(db, target, newOptions(opts))
+}
+
+// migrateRun performs the database migrations of the run and records its
+// outcome.
+func migrateRun(db *sql.DB, target string, o *options) error {
+	o.run = &Run{Target: target, Started: o.now()}
+	o.failure = nil
+	o.startDeadline()
+	o.notify(Notification{Kind: NotifyStarted})
+	err := o.elect(db, target)
+	o.run.finish(o.now(), err)
+	if o.result != nil {
+		*o.result = *o.run
 	}
 
-	_, err := db.Exec(queryVersionsNew)
+	if err != nil {
+		o.notifyFailed(err)
+	} else {
+		o.notify(Notification{Kind: NotifyFinished})
+	}
+
+	return err
+}
+
+// migrateDB performs the database migrations of the run on a single
+// connection of the database between the hooks of the run.
+func migrateDB(db *sql.DB, target string, o *options) error {
+	err := o.waitForDB(db)
 	if err != nil {
 		return err
 	}
 
-	current, err := currentVersion(db)
+	err = o.checkPrimary(db)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "error querying latest migration version: %v", err)
 		return err
 	}
 
-	up := true
-	if current > target {
-		sort.Sort(sort.Reverse(sort.StringSlice(vs)))
-		up = false
+	err = o.verifyShadow(db, target)
+	if err != nil {
+		return err
+	}
+
+	err = o.migrateCanary(target)
+	if err != nil {
+		return err
+	}
+
+	conn, err := db.Conn(o.ctx)
+	if err != nil {
+		return err
+	}
+
+	defer conn.Close()
+
+	release, err := o.acquire(conn)
+	if err != nil {
+		return err
+	}
+
+	defer release()
+
+	err = o.hook(o.ctx, conn, o.before)
+	if err != nil {
+		return err
+	}
+
+	err = run(conn, target, o)
+	if err == nil {
+		err = o.pgNotify(conn)
+	}
+
+	// The after hooks run even if the run is canceled.
+	after := o.hook(context.WithoutCancel(o.ctx), conn, o.after)
+	if err != nil {
+		if after != nil {
+			o.logf(LevelError, "error running after hooks: %v", after)
+		}
+		return err
+	}
+
+	return after
+}
+
+// run performs the database migrations on the connection to bring the
+// database to the state of the target version timestamp.
+func run(conn *sql.Conn, target string, o *options) error {
+	err := o.createVersions(conn)
+	if err != nil {
+		return err
+	}
+
+	vs, done, up, err := plan(conn, target, o)
+	if err != nil {
+		return err
+	}
+
+	o.emit(PlanComputed{Versions: vs, Up: up})
+	err = o.guardProduction(conn, vs, up)
+	if err != nil {
+		return err
+	}
+
+	err = preflight(conn, vs, up, o)
+	if err != nil {
+		return err
+	}
+
+	err = confirm(conn, vs, up, o)
+	if err != nil {
+		return err
+	}
+
+	err = approve(conn, target, vs, up, o)
+	if err != nil {
+		return err
+	}
+
+	err = o.markRecoveryPoint(conn, vs)
+	if err != nil {
+		return err
+	}
+
+	exit, err := o.enterMaintenance(vs)
+	if err != nil {
+		return err
+	}
+
+	err = perform(conn, vs, done, up, o)
+	if xerr := exit(); err == nil {
+		err = xerr
+	}
+
+	return err
+}
+
+// perform performs the planned migrations on the connection in order
+// and, when migrating up, the repeatable migrations.
+func perform(conn *sql.Conn, vs []string, done []*version, up bool, o *options) error {
+	ctx := o.ctx
+	backups, err := o.backups(conn, vs, up)
+	if err != nil {
+		return err
 	}
 
 	for _, v := range vs {
-		if !shouldMigrate(v, current, target, up) {
-			continue
+		err = ctx.Err()
+		if err != nil {
+			return err
 		}
 
-		tx, err := db.Begin()
+		err = o.checkDeadline()
 		if err != nil {
 			return err
 		}
 
-		err = migrate(tx, v, up)
+		if o.excluded(v) {
+			o.logf(LevelInfo, "excluding %q", v)
+			if up && o.skipExcluded {
+				_, err = conn.ExecContext(ctx, o.query(queryVersionsSkip), v, migrations[v].name, "excluded", o.now().UTC())
+				if err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		s, ok := backups[v]
+		err = o.takeBackup(s, ok)
 		if err != nil {
-			fmt.Fprintf(os.Stderr, "error migrating %q: %v\n", v, err)
-			if err := tx.Rollback(); err != nil {
-				return err
+			return err
+		}
+
+		began := o.migrating(v, up)
+		if migrations[v].upConn != nil {
+			err = migrateConn(conn, v, up, find(v, done), o)
+			if err != nil {
+				o.failed(v, up, began, err)
+				return &MigrationError{Version: v, Name: migrations[v].name, Up: up, Err: err}
 			}
+			o.migrated(v, up, began)
+			continue
+		}
+
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
 			return err
 		}
 
+		untrack := track(tx, v, o)
+		err = migrate(tx, v, up, find(v, done), o)
+		untrack()
+		if err != nil {
+			o.failed(v, up, began, err)
+			rollbackErr := tx.Rollback()
+			if rollbackErr != nil {
+				err = errors.Join(err, rollbackErr)
+			}
+			return &MigrationError{Version: v, Name: migrations[v].name, Up: up, Err: err}
+		}
+
 		err = tx.Commit()
+		if err == sql.ErrTxDone && ctx.Err() != nil {
+			// The transaction was rolled back as the context was done.
+			err = ctx.Err()
+		}
+
 		if err != nil {
-			return err
+			return &MigrationError{Version: v, Name: migrations[v].name, Up: up, Err: err}
 		}
+
+		o.migrated(v, up, began)
 	}
 
-	return nil
+	if !up {
+		return nil
+	}
+
+	err = o.checkDeadline()
+	if err != nil {
+		return err
+	}
+
+	return repeat(conn, o)
+}
+
+// Rollback reverts the most recently applied n migrations.
+func Rollback(db *sql.DB, n int, opts ...Option) error {
+	o := newOptions(opts)
+
+	err := o.waitForDB(db)
+	if err != nil {
+		return err
+	}
+
+	err = o.createVersions(db)
+	if err != nil {
+		return err
+	}
+
+	vs, err := versions(db, o)
+	if err != nil {
+		return err
+	}
+
+	target := NilVersion
+	if i := len(vs) - 1 - n; i > 0 {
+		target = vs[i].version
+	}
+
+	return Migrate(db, target, opts...)
+}
+
+// Current returns the version timestamp most recently applied to the
+// database, or an empty string if no migration has been applied.
+func Current(db *sql.DB, opts ...Option) (string, error) {
+	o := newOptions(opts)
+
+	err := o.createVersions(db)
+	if err != nil {
+		return "", err
+	}
+
+	return currentVersion(db, o)
+}
+
+// plan returns the version timestamps to migrate to bring the database
+// to the state of the target version timestamp in the order they are
+// to be performed, the applied versions and whether or not the
+// migrations are performed up.
+func plan(conn Conn, target string, o *options) ([]string, []*version, bool, error) {
+	vs := o.sorted()
+	if target == "" {
+		target = vs[len(vs)-1]
+	}
+
+	current, err := currentVersion(conn, o)
+	if err != nil {
+		o.logf(LevelError, "error querying latest migration version: %v", err)
+		return nil, nil, false, err
+	}
+
+	done, err := versions(conn, o)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	up := true
+	if current > target {
+		sort.Sort(sort.Reverse(sort.StringSlice(vs)))
+		up = false
+	}
+
+	var rv []string
+	for _, v := range vs {
+		applied := find(v, done)
+		if shouldMigrate(v, target, applied, up) && o.selected(v) {
+			if applied != nil && applied.archived {
+				return nil, nil, false, fmt.Errorf("migrator: cannot revert archived version %s", v)
+			}
+
+			if o.deferred(v) {
+				continue
+			}
+
+			rv = append(rv, v)
+		}
+	}
+
+	return rv, done, up, nil
 }
 
 // Status prints the sorted list of migrations and whether or not
 // they have been applied to the database.
-func Status(db *sql.DB) error {
-	vs, err := versions(db)
+func Status(db *sql.DB, opts ...Option) error {
+	infos, err := Inspect(db, opts...)
 	if err != nil {
 		return err
 	}
 
-	for _, v := range sorted() {
-		s := " "
-		if applied(v, vs) {
+	for _, info := range infos {
+		s, note := " ", ""
+		if info.Applied {
 			s = "x"
+			if info.SkipReason != "" {
+				s, note = "-", fmt.Sprintf(" (skipped: %s)", info.SkipReason)
+			}
 		}
-		fmt.Printf("[%s] %s %s\n", s, v, migrations[v].name)
+		fmt.Printf("[%s] %s %s (%s)%s\n", s, info.Version, info.Name, info.Phase, note)
 	}
 
 	return nil
 }
 
-// applied returns true if version if found in the slice of versions.
-func applied(version string, vs []*version) bool {
+// Source returns the location of the migration of the version timestamp,
+// which is the up file of migrations loaded by LoadDir or the file and
+// line of the up function of Go migrations, or an empty string if it is
+// not known.
+func Source(version string) string {
+	m, ok := migrations[version]
+	if !ok {
+		return ""
+	}
+
+	if m.source != "" || m.sql {
+		return m.source
+	}
+
+	var fn interface{} = m.up
+	if m.upConn != nil {
+		fn = m.upConn
+	}
+
+	file, line := funcLine(fn)
+	if file == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("%s:%d", file, line)
+}
+
+// funcLine returns the file and line of the declaration of the function,
+// or an empty file if it is not known.
+func funcLine(fn interface{}) (string, int) {
+	f := runtime.FuncForPC(reflect.ValueOf(fn).Pointer())
+	if f == nil {
+		return "", 0
+	}
+
+	return f.FileLine(f.Entry())
+}
+
+// sum returns the checksum of the up statements of a SQL migration, or an
+// empty string if it is not a SQL migration.
+func (m *migration) sum() string {
+	if len(m.upSQL) == 0 {
+		return ""
+	}
+
+	return checksum(strings.Join(m.upSQL, ";\n"))
+}
+
+// Statements returns the SQL statements of the migration of the version
+// timestamp in the direction, or nil if it is not a SQL migration.
+func Statements(version string, up bool) []string {
+	m, ok := migrations[version]
+	if !ok {
+		return nil
+	}
+
+	if up {
+		return m.upSQL
+	}
+
+	return m.downSQL
+}
+
+// find returns the applied version matching the version timestamp or nil
+// if it is not found in the slice of versions.
+func find(version string, vs []*version) *version {
 	for _, v := range vs {
 		if v.version == version {
-			return true
+			return v
 		}
 	}
 
-	return false
+	return nil
 }
 
 // shouldMigrate returns whether the migration for version needs to be
-// performed based on the current and target version timestamps and
-// the direction of migrations.
-func shouldMigrate(version, current, target string, up bool) bool {
+// performed based on the target version timestamp, whether or not it
+// has been applied and the direction of migrations. Versions older than
+// the current version that were never applied, such as those deferred
+// by a previous run, are performed when migrating up.
+func shouldMigrate(version, target string, applied *version, up bool) bool {
 	if !up {
-		return version <= current && version > target
+		return applied != nil && version > target
 	}
 
-	return version > current && version <= target
+	return applied == nil && version <= target
 }
 
 // sorted returns a slice of version timestamps in ascending order.
@@ -144,28 +729,152 @@ func sorted() []string {
 	return rv
 }
 
+// Perform executes the up or down migration of the version timestamp on
+// the transaction without recording it in the versions table, such as to
+// unit test the migration in isolation. Migrations that run outside of a
+// transaction cannot be performed on a transaction.
+func Perform(tx *sql.Tx, version string, up bool) error {
+	m, ok := migrations[version]
+	if !ok {
+		return fmt.Errorf("migrator: unknown version %s", version)
+	}
+
+	fn := m.up
+	if !up {
+		fn = m.down
+	}
+
+	if fn == nil {
+		return fmt.Errorf("migrator: %s runs outside of a transaction", version)
+	}
+
+	untrack := track(tx, version, newOptions(nil))
+	defer untrack()
+
+	return fn(tx)
+}
+
 // migrate executes the appropriate migrationFunc within the transaction
-// and records the migration in the versions table.
-func migrate(tx *sql.Tx, version string, up bool) error {
+// and records the migration in the versions table. The applied version
+// is nil when migrating up.
+func migrate(tx *sql.Tx, version string, up bool, applied *version, o *options) error {
 	var err error
 
+	m := migrations[version]
+	err = m.settings.configure(o.ctx, tx, true)
+	if err != nil {
+		return err
+	}
+
 	if !up {
-		err = migrations[version].down(tx)
+		if applied == nil || applied.skipReason == "" {
+			err = m.down(tx)
+			if err != nil {
+				return err
+			}
+		}
+
+		return o.unrecord(tx, version)
+	}
+
+	if m.shouldRun != nil {
+		ok, err := m.shouldRun(tx)
 		if err != nil {
 			return err
 		}
 
-		_, err = tx.Exec(queryVersionsDelete, version)
+		if !ok {
+			o.logf(LevelInfo, "skipping %q: predicate returned false", version)
+			_, err = tx.Exec(o.query(queryVersionsSkip), version, m.name, "predicate", o.now().UTC())
+			return err
+		}
+	}
+
+	err = m.up(tx)
+	if err != nil {
 		return err
 	}
 
-	err = migrations[version].up(tx)
+	_, err = tx.Exec(o.query(queryVersionsInsert), version, m.name, m.sum(), o.now().UTC())
 	if err != nil {
 		return err
 	}
 
-	_, err = tx.Exec(queryVersionsInsert, version, migrations[version].name)
-	return err
+	return o.saveDown(tx, version)
+}
+
+// migrateConn executes the appropriate connFunc on the connection outside
+// of a transaction and records the migration in the versions table once
+// it succeeds. The applied version is nil when migrating up.
+func migrateConn(conn *sql.Conn, version string, up bool, applied *version, o *options) error {
+	var err error
+
+	ctx := o.ctx
+	defer track(conn, version, o)()
+
+	m := migrations[version]
+	restore, err := m.settingsOf(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	err = m.settings.configure(ctx, conn, false)
+	if err != nil {
+		return err
+	}
+
+	// The settings are restored even if the run is canceled, as the
+	// connection may be reused.
+	defer func() {
+		if err := restore.configure(context.WithoutCancel(ctx), conn, false); err != nil {
+			o.logf(LevelError, "error restoring settings after %q: %v", version, err)
+		}
+	}()
+
+	if !up {
+		if applied == nil || applied.skipReason == "" {
+			err = m.downConn(conn)
+			if err != nil {
+				return err
+			}
+		}
+
+		return o.unrecord(conn, version)
+	}
+
+	if m.shouldRun != nil {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		ok, err := m.shouldRun(tx)
+		if err := tx.Rollback(); err != nil {
+			return err
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			o.logf(LevelInfo, "skipping %q: predicate returned false", version)
+			_, err = conn.ExecContext(ctx, o.query(queryVersionsSkip), version, m.name, "predicate", o.now().UTC())
+			return err
+		}
+	}
+
+	err = m.upConn(conn)
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.ExecContext(ctx, o.query(queryVersionsInsert), version, m.name, m.sum(), o.now().UTC())
+	if err != nil {
+		return err
+	}
+
+	return o.saveDown(conn, version)
 }
 
 // empty is a nil migratorFunc for the purpose of having an empty state
@@ -175,5 +884,5 @@ func empty(tx *sql.Tx) error {
 }
 
 func init() {
-	Register("00010101T000000Z", "nil", empty, empty)
+	Register(NilVersion, "nil", empty, empty)
 }
@@ -0,0 +1,711 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// queryTestVersionsNew creates the versions table in SQLite.
+var queryTestVersionsNew = `
+CREATE TABLE versions (
+  id          INTEGER PRIMARY KEY AUTOINCREMENT,
+  version     TEXT NOT NULL,
+  name        TEXT NOT NULL,
+  skip_reason TEXT NOT NULL DEFAULT '',
+  checksum    TEXT NOT NULL DEFAULT '',
+  created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  reverted_at TIMESTAMP NULL
+);
+`
+
+// openTestDB opens a SQLite database in a temporary directory that is
+// closed when the test completes.
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+// testConn returns a connection of the database that is closed when the
+// test completes.
+func testConn(t *testing.T, db *sql.DB) *sql.Conn {
+	t.Helper()
+
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// isolate replaces the registered migrations with the empty migration
+// until the test completes.
+func isolate(t *testing.T) {
+	t.Helper()
+
+	saved := migrations
+	migrations = map[string]*migration{"00010101T000000Z": saved["00010101T000000Z"]}
+	t.Cleanup(func() { migrations = saved })
+}
+
+func TestMigrateShouldRun(t *testing.T) {
+	isolate(t)
+
+	performed := false
+	up := func(tx *sql.Tx) error {
+		performed = true
+		return nil
+	}
+
+	skip := func(tx *sql.Tx) (bool, error) { return false, nil }
+	Register("20240101T000000Z", "skipped", up, empty, ShouldRun(skip))
+
+	db := openTestDB(t)
+	_, err := db.Exec(queryTestVersionsNew)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tx.Rollback()
+
+	err = migrate(tx, "20240101T000000Z", true, nil, newOptions(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if performed {
+		t.Error("up migration performed, want skipped")
+	}
+
+	vs := recorded(t, tx)
+	if len(vs) != 1 || vs[0].skipReason != "predicate" {
+		t.Fatalf("versions = %+v, want one skipped by predicate", vs)
+	}
+
+	err = migrate(tx, "20240101T000000Z", false, vs[0], newOptions(nil))
+	if err != nil {
+		t.Fatalf("down past skipped version: %v", err)
+	}
+
+	vs = recorded(t, tx)
+	if len(vs) != 0 {
+		t.Errorf("versions = %+v, want none after migrating down", vs)
+	}
+}
+
+func TestMigrateConn(t *testing.T) {
+	isolate(t)
+
+	var calls []string
+	up := func(conn *sql.Conn) error {
+		calls = append(calls, "up")
+		return nil
+	}
+
+	down := func(conn *sql.Conn) error {
+		calls = append(calls, "down")
+		return nil
+	}
+
+	RegisterNoTransaction("20240101T000000Z", "no_transaction", up, down)
+
+	db := openTestDB(t)
+	_, err := db.Exec(queryTestVersionsNew)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conn := testConn(t, db)
+	err = migrateConn(conn, "20240101T000000Z", true, nil, newOptions(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	err = db.QueryRow("SELECT COUNT(*) FROM versions WHERE version = '20240101T000000Z'").Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 1 {
+		t.Fatalf("%d versions recorded, want 1", n)
+	}
+
+	err = migrateConn(conn, "20240101T000000Z", false, &version{version: "20240101T000000Z"}, newOptions(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.QueryRow("SELECT COUNT(*) FROM versions").Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 0 {
+		t.Errorf("%d versions recorded after migrating down, want 0", n)
+	}
+
+	if !reflect.DeepEqual(calls, []string{"up", "down"}) {
+		t.Errorf("calls = %q, want up then down", calls)
+	}
+}
+
+func TestRegisterSQL(t *testing.T) {
+	isolate(t)
+
+	up := []string{"CREATE TABLE users (id INTEGER);", "INSERT INTO users (id) VALUES (1);"}
+	down := []string{"DROP TABLE users;"}
+	registerSQL("20240101T000000Z", "sql", up, down, false, nil)
+	registerSQL("20240102T000000Z", "sql_no_transaction", []string{"INSERT INTO users (id) VALUES (2);"}, nil, true, nil)
+
+	m := migrations["20240101T000000Z"]
+	if m.up == nil || m.upConn != nil || !reflect.DeepEqual(m.upSQL, up) || !reflect.DeepEqual(m.downSQL, down) {
+		t.Fatalf("transactional SQL migration registered as %+v", m)
+	}
+
+	if migrations["20240102T000000Z"].upConn == nil {
+		t.Fatal("SQL migration without a transaction registered without upConn")
+	}
+
+	db := openTestDB(t)
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tx.Rollback()
+
+	err = m.up(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	err = tx.QueryRow("SELECT COUNT(*) FROM users").Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 1 {
+		t.Errorf("%d users inserted, want 1", n)
+	}
+
+	err = execStatements(tx, []string{"INSERT INTO missing (id) VALUES (1);", "DROP TABLE users;"})
+	if err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("execStatements error = %v, want failure on the missing table", err)
+	}
+
+	err = tx.QueryRow("SELECT COUNT(*) FROM users").Scan(&n)
+	if err != nil {
+		t.Errorf("statements after the failure were executed: %v", err)
+	}
+}
+
+func TestShouldMigrate(t *testing.T) {
+	applied := &version{version: "20240102T000000Z"}
+	tests := []struct {
+		name    string
+		version string
+		target  string
+		applied *version
+		up      bool
+		want    bool
+	}{
+		{"up pending before target", "20240101T000000Z", "20240102T000000Z", nil, true, true},
+		{"up pending at target", "20240102T000000Z", "20240102T000000Z", nil, true, true},
+		{"up pending after target", "20240103T000000Z", "20240102T000000Z", nil, true, false},
+		{"up applied", "20240102T000000Z", "20240103T000000Z", applied, true, false},
+		{"down applied after target", "20240102T000000Z", "20240101T000000Z", applied, false, true},
+		{"down applied at target", "20240102T000000Z", "20240102T000000Z", applied, false, false},
+		{"down pending after target", "20240103T000000Z", "20240101T000000Z", nil, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := shouldMigrate(tt.version, tt.target, tt.applied, tt.up)
+			if got != tt.want {
+				t.Errorf("shouldMigrate(%q, %q, %v, %t) = %t, want %t", tt.version, tt.target, tt.applied != nil, tt.up, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	vs := []*version{{version: "20240101T000000Z"}, {version: "20240102T000000Z"}}
+	tests := []struct {
+		version string
+		want    *version
+	}{
+		{"20240101T000000Z", vs[0]},
+		{"20240102T000000Z", vs[1]},
+		{"20240103T000000Z", nil},
+	}
+
+	for _, tt := range tests {
+		got := find(tt.version, vs)
+		if got != tt.want {
+			t.Errorf("find(%q) = %v, want %v", tt.version, got, tt.want)
+		}
+	}
+}
+
+// recorded returns the versions recorded within the transaction.
+func recorded(t *testing.T, tx *sql.Tx) []*version {
+	t.Helper()
+
+	var rv []*version
+	rows, err := tx.Query("SELECT version, skip_reason FROM versions ORDER BY version")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		v := new(version)
+		err := rows.Scan(&v.version, &v.skipReason)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rv = append(rv, v)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rv
+}
+
+// registerTables registers a migration per table that creates it.
+func registerTables(t *testing.T, tables map[string]string) {
+	t.Helper()
+
+	for v, table := range tables {
+		registerSQL(v, "create_"+table, []string{"CREATE TABLE " + table + " (id INTEGER);"}, []string{"DROP TABLE " + table + ";"}, false, nil)
+	}
+}
+
+// tableExists returns true if the SQLite table exists.
+func tableExists(t *testing.T, db *sql.DB, name string) bool {
+	t.Helper()
+
+	var n int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?;", name).Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return n > 0
+}
+
+func TestMigrate(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{
+		"20240101T000000Z": "users",
+		"20240102T000000Z": "posts",
+		"20240103T000000Z": "tags",
+	})
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+
+	err := Migrate(db, "20240102T000000Z", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !tableExists(t, db, "posts") || tableExists(t, db, "tags") {
+		t.Fatal("migrating to 20240102T000000Z did not create exactly users and posts")
+	}
+
+	err = Migrate(db, "", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := Current(db, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if current != "20240103T000000Z" {
+		t.Fatalf("current = %q, want the latest version", current)
+	}
+
+	err = Migrate(db, NilVersion, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range []string{"users", "posts", "tags"} {
+		if tableExists(t, db, table) {
+			t.Errorf("table %s exists after migrating to NilVersion", table)
+		}
+	}
+}
+
+func TestRollback(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{
+		"20240101T000000Z": "users",
+		"20240102T000000Z": "posts",
+		"20240103T000000Z": "tags",
+	})
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+
+	err := Migrate(db, "", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{1, "20240102T000000Z"},
+		{1, "20240101T000000Z"},
+		{5, NilVersion},
+	}
+
+	for _, tt := range tests {
+		err = Rollback(db, tt.n, sqlite)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		current, err := Current(db, sqlite)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if current != tt.want {
+			t.Errorf("current after rolling back %d = %q, want %q", tt.n, current, tt.want)
+		}
+	}
+
+	if tableExists(t, db, "users") {
+		t.Error("table users exists after rolling back every migration")
+	}
+}
+
+func TestStatements(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+	Register("20240102T000000Z", "go", empty, empty)
+
+	tests := []struct {
+		version string
+		up      bool
+		want    []string
+	}{
+		{"20240101T000000Z", true, []string{"CREATE TABLE users (id INTEGER);"}},
+		{"20240101T000000Z", false, []string{"DROP TABLE users;"}},
+		{"20240102T000000Z", true, nil},
+		{"20240103T000000Z", true, nil},
+	}
+
+	for _, tt := range tests {
+		have := Statements(tt.version, tt.up)
+		if !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("Statements(%q, %t) = %q, want %q", tt.version, tt.up, have, tt.want)
+		}
+	}
+}
+
+func TestWithContext(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240102T000000Z": "posts"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	Register("20240101T000000Z", "cancel", func(tx *sql.Tx) error {
+		cancel()
+		return nil
+	}, empty)
+
+	db := openTestDB(t)
+	err := Migrate(db, "", WithDialect(SQLite), WithContext(ctx))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Migrate error = %v, want %v", err, context.Canceled)
+	}
+
+	if tableExists(t, db, "posts") {
+		t.Error("migration performed after the context was cancelled")
+	}
+}
+
+func TestSource(t *testing.T) {
+	isolate(t)
+	up := func(tx *sql.Tx) error { return nil }
+	Register("20240101T000000Z", "go", up, empty)
+	registerTables(t, map[string]string{"20240102T000000Z": "users"})
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"20240103T000000Z_posts.up.sql":   "CREATE TABLE posts (id INTEGER);",
+		"20240103T000000Z_posts.down.sql": "DROP TABLE posts;",
+	} {
+		err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if src := Source("20240101T000000Z"); !strings.Contains(src, "migrator_test.go:") {
+		t.Errorf("Source of a Go migration = %q, want the file and line of the up function", src)
+	}
+
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{"20240102T000000Z", ""},
+		{"20240103T000000Z", filepath.Join(dir, "20240103T000000Z_posts.up.sql")},
+		{"20240104T000000Z", ""},
+	}
+
+	for _, tt := range tests {
+		have := Source(tt.version)
+		if have != tt.want {
+			t.Errorf("Source(%q) = %q, want %q", tt.version, have, tt.want)
+		}
+	}
+}
+
+func TestRegisterTwice(t *testing.T) {
+	isolate(t)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "20240101T000000Z_users.up.sql")
+	err := os.WriteFile(path, []byte("CREATE TABLE users (id INTEGER);"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer func() {
+		want := "migrator: version 20240101T000000Z registered twice, by " + path + " and "
+		msg, _ := recover().(string)
+		if !strings.HasPrefix(msg, want) || strings.HasSuffix(msg, "unknown") {
+			t.Errorf("panic = %q, want both origins prefixed by %q", msg, want)
+		}
+	}()
+
+	Register("20240101T000000Z", "users", empty, empty)
+}
+
+func TestRegisteredAt(t *testing.T) {
+	tests := []struct {
+		m    *migration
+		want string
+	}{
+		{&migration{source: "a.up.sql", origin: "b.go:1"}, "a.up.sql"},
+		{&migration{origin: "b.go:1"}, "b.go:1"},
+		{&migration{}, "unknown"},
+	}
+
+	for _, tt := range tests {
+		have := tt.m.registeredAt()
+		if have != tt.want {
+			t.Errorf("registeredAt = %q, want %q", have, tt.want)
+		}
+	}
+}
+
+func TestPerform(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+	RegisterNoTransaction("20240102T000000Z", "conn", func(conn *sql.Conn) error { return nil }, func(conn *sql.Conn) error { return nil })
+
+	db := openTestDB(t)
+	_, err := db.Exec(queryTestVersionsNew)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tx.Rollback()
+
+	err = Perform(tx, "20240101T000000Z", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	err = tx.QueryRow("SELECT COUNT(*) FROM versions;").Scan(&n)
+	if err != nil || n != 0 {
+		t.Errorf("%d versions recorded, %v, want none", n, err)
+	}
+
+	err = Perform(tx, "20240101T000000Z", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, v := range []string{"20240102T000000Z", "20240103T000000Z"} {
+		err = Perform(tx, v, true)
+		if err == nil {
+			t.Errorf("Perform(%s) error = nil", v)
+		}
+	}
+}
+
+func TestRegisterPlainSQL(t *testing.T) {
+	isolate(t)
+	RegisterSQL("20240101T000000Z", "users", `
+CREATE TABLE users (id INTEGER);
+CREATE INDEX users_id_idx ON users (id);
+`, "DROP TABLE users;")
+	RegisterSQL("20240102T000000Z", "seed", "INSERT INTO users (id) VALUES (1);", "")
+
+	want := []string{"CREATE TABLE users (id INTEGER);", "CREATE INDEX users_id_idx ON users (id);"}
+	if have := Statements("20240101T000000Z", true); !reflect.DeepEqual(have, want) {
+		t.Errorf("Statements = %q, want %q", have, want)
+	}
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+	err := Migrate(db, "", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var n int
+	err = db.QueryRow("SELECT COUNT(*) FROM users;").Scan(&n)
+	if err != nil || n != 1 {
+		t.Errorf("users has %d rows, %v, want the seeded row", n, err)
+	}
+
+	err = Migrate(db, NilVersion, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tableExists(t, db, "users") {
+		t.Error("down SQL not executed")
+	}
+}
+
+func TestExecAll(t *testing.T) {
+	db := openTestDB(t)
+	err := ExecAll(db, "CREATE TABLE users (id INTEGER);", "INSERT INTO users (id) VALUES (1);")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ExecAll(db, "INSERT INTO users (id) VALUES (2);", "INSERT INTO missing (id) VALUES (1);", "INSERT INTO users (id) VALUES (3);")
+	if err == nil || !strings.Contains(err.Error(), `"INSERT INTO missing (id) VALUES (1);"`) {
+		t.Errorf("ExecAll error = %v, want the failed statement named", err)
+	}
+
+	var n int
+	err = db.QueryRow("SELECT COUNT(*) FROM users;").Scan(&n)
+	if err != nil || n != 2 {
+		t.Errorf("users has %d rows, %v, want execution stopped at the error", n, err)
+	}
+}
+
+func TestExecTemplate(t *testing.T) {
+	db := openTestDB(t)
+	data := struct{ Schema string }{"tenant_a"}
+	err := ExecTemplate(db, data, "CREATE TABLE {{.Schema}}_users (id INTEGER);", "INSERT INTO {{.Schema}}_users (id) VALUES (1);")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !tableExists(t, db, "tenant_a_users") {
+		t.Error("templated table not created")
+	}
+
+	tests := []string{"{{.Schema", "{{.Missing}}"}
+	for _, bad := range tests {
+		err = ExecTemplate(db, data, "CREATE TABLE executed (id INTEGER);", "CREATE TABLE "+bad+" (id INTEGER);")
+		if err == nil {
+			t.Errorf("ExecTemplate with %q error = nil", bad)
+		}
+	}
+
+	if tableExists(t, db, "executed") {
+		t.Error("statement executed before a template failed")
+	}
+}
+
+func TestMigrationError(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+	registerSQL("20240102T000000Z", "seed", []string{"INSERT INTO missing (id) VALUES (1);"}, nil, false, nil)
+
+	db := openTestDB(t)
+	err := Migrate(db, "", WithDialect(SQLite), WithLogger(&testLogger{}))
+
+	var merr *MigrationError
+	if !errors.As(err, &merr) {
+		t.Fatalf("Migrate error = %#v, want a *MigrationError", err)
+	}
+
+	if merr.Version != "20240102T000000Z" || merr.Name != "seed" || !merr.Up {
+		t.Errorf("MigrationError = %+v, want the failed migration", merr)
+	}
+
+	want := `migrator: up 20240102T000000Z seed: "INSERT INTO missing (id) VALUES (1);": `
+	if !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("Error = %q, want prefix %q", err, want)
+	}
+
+	failed := errors.New("failed")
+	Register("20240103T000000Z", "fails", empty, func(tx *sql.Tx) error { return failed })
+	err = Migrate(db, "20240101T000000Z", WithDialect(SQLite), WithLogger(&testLogger{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	delete(migrations, "20240102T000000Z")
+	registerSQL("20240102T000000Z", "seed", nil, nil, false, nil)
+	err = Migrate(db, "", WithDialect(SQLite))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Migrate(db, "20240101T000000Z", WithDialect(SQLite), WithLogger(&testLogger{}))
+	if !errors.Is(err, failed) || err.Error() != "migrator: down 20240103T000000Z fails: failed" {
+		t.Errorf("down error = %v, want the MigrationError wrapping %v", err, failed)
+	}
+}
@@ -0,0 +1,97 @@
+// Package migratoretcd elects the instance that performs migration runs
+// and serializes runs with locks held in etcd.
+//
+// A Coordinator elects the instance that holds its mutex:
+//
+//	c := migratoretcd.New(client, "/app/migrator", 30)
+//	migrator.Migrate(db, "", migrator.WithCoordinator(c))
+//
+// A Lock performs runs one at a time, waiting to hold its mutex:
+//
+//	l := migratoretcd.NewLock(client, "/app/migrator/lock", 30)
+//	migrator.Migrate(db, "", migrator.WithExternalLock(l))
+package migratoretcd
+
+import (
+	"context"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+// A Coordinator elects the instance that holds a mutex in etcd. The
+// mutex is held by a session whose lease is kept alive by the leader, so
+// a failed leader is replaced after at most the ttl of the lease.
+type Coordinator struct {
+	client *clientv3.Client
+	prefix string
+	ttl    int
+}
+
+// New returns a coordinator of the mutex with the key prefix whose lease
+// expires after ttl seconds.
+func New(client *clientv3.Client, prefix string, ttl int) *Coordinator {
+	return &Coordinator{client: client, prefix: prefix, ttl: ttl}
+}
+
+// Lead tries to lock the mutex in a new session held until resigning.
+func (c *Coordinator) Lead(ctx context.Context) (func(), bool, error) {
+	s, err := concurrency.NewSession(c.client, concurrency.WithTTL(c.ttl))
+	if err != nil {
+		return nil, false, err
+	}
+
+	m := concurrency.NewMutex(s, c.prefix)
+	err = m.TryLock(ctx)
+	if err != nil {
+		s.Close()
+		if err == concurrency.ErrLocked {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	return unlock(s, m), true, nil
+}
+
+// A Lock is held by the instance that holds a mutex in etcd. The mutex is
+// held by a session whose lease is kept alive by the holder, so the lock
+// of a failed holder is released after at most the ttl of the lease.
+type Lock struct {
+	client *clientv3.Client
+	prefix string
+	ttl    int
+}
+
+// NewLock returns a lock of the mutex with the key prefix whose lease
+// expires after ttl seconds.
+func NewLock(client *clientv3.Client, prefix string, ttl int) *Lock {
+	return &Lock{client: client, prefix: prefix, ttl: ttl}
+}
+
+// Acquire locks the mutex in a new session, waiting until it is unlocked
+// or the context is done, and holds it until released.
+func (l *Lock) Acquire(ctx context.Context) (func(), error) {
+	s, err := concurrency.NewSession(l.client, concurrency.WithTTL(l.ttl))
+	if err != nil {
+		return nil, err
+	}
+
+	m := concurrency.NewMutex(s, l.prefix)
+	err = m.Lock(ctx)
+	if err != nil {
+		s.Close()
+		return nil, err
+	}
+
+	return unlock(s, m), nil
+}
+
+// unlock returns a function that unlocks the mutex and closes its
+// session.
+func unlock(s *concurrency.Session, m *concurrency.Mutex) func() {
+	return func() {
+		m.Unlock(context.Background())
+		s.Close()
+	}
+}
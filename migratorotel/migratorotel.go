@@ -0,0 +1,112 @@
+// Package migratorotel traces migration runs with OpenTelemetry.
+//
+// A Notifier creates a span for each run and a child span for each
+// migration it performs:
+//
+//	migrator.Migrate(db, "", migrator.WithNotifier(migratorotel.New(ctx)))
+package migratorotel
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/pnelson/migrator"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// name is the instrumentation name of the tracer.
+const name = "github.com/pnelson/migrator"
+
+// A Notifier traces the runs it is notified of. A Notifier traces one
+// run at a time and must not be shared by concurrent runs.
+type Notifier struct {
+	ctx    context.Context
+	tracer trace.Tracer
+
+	mu        sync.Mutex
+	run       context.Context
+	runSpan   trace.Span
+	migration trace.Span
+}
+
+// An Option configures a Notifier.
+type Option func(*Notifier)
+
+// WithTracerProvider sets the tracer provider of the notifier. The
+// default is the global tracer provider.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(n *Notifier) {
+		n.tracer = tp.Tracer(name)
+	}
+}
+
+// New returns a notifier whose run spans are children of the span of
+// the context, such as the span of a deploy or application startup.
+func New(ctx context.Context, opts ...Option) *Notifier {
+	n := &Notifier{ctx: ctx, tracer: otel.Tracer(name)}
+	for _, opt := range opts {
+		opt(n)
+	}
+
+	return n
+}
+
+// Notify starts and ends the spans of the notification.
+func (n *Notifier) Notify(note migrator.Notification) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	switch note.Kind {
+	case migrator.NotifyStarted:
+		n.run, n.runSpan = n.tracer.Start(n.ctx, "migrator.run",
+			trace.WithTimestamp(note.Time),
+			trace.WithAttributes(attribute.String("migrator.target", note.Target)),
+		)
+	case migrator.NotifyMigrating:
+		if n.run == nil {
+			return nil
+		}
+		direction := "up"
+		if !note.Up {
+			direction = "down"
+		}
+		_, n.migration = n.tracer.Start(n.run, "migrator.migration "+note.Version,
+			trace.WithTimestamp(note.Time),
+			trace.WithAttributes(
+				attribute.String("migrator.version", note.Version),
+				attribute.String("migrator.name", note.Name),
+				attribute.String("migrator.direction", direction),
+			),
+		)
+	case migrator.NotifyMigrated:
+		if n.migration == nil {
+			return nil
+		}
+		if note.Rows > 0 {
+			n.migration.SetAttributes(attribute.Int64("migrator.rows_affected", note.Rows))
+		}
+		n.migration.End(trace.WithTimestamp(note.Time))
+		n.migration = nil
+	case migrator.NotifyFailed:
+		err := errors.New(note.Error)
+		for _, span := range []trace.Span{n.migration, n.runSpan} {
+			if span != nil {
+				span.RecordError(err)
+				span.SetStatus(codes.Error, note.Error)
+				span.End(trace.WithTimestamp(note.Time))
+			}
+		}
+		n.run, n.runSpan, n.migration = nil, nil, nil
+	case migrator.NotifyFinished:
+		if n.runSpan != nil {
+			n.runSpan.End(trace.WithTimestamp(note.Time))
+		}
+		n.run, n.runSpan = nil, nil
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package migratorotel
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pnelson/migrator"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestNotify(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	n := New(context.Background(), WithTracerProvider(tp))
+
+	now := time.Now()
+	for _, note := range []migrator.Notification{
+		{Kind: migrator.NotifyStarted, Target: "20240102T000000Z", Time: now},
+		{Kind: migrator.NotifyMigrating, Version: "20240101T000000Z", Name: "users", Up: true, Time: now},
+		{Kind: migrator.NotifyMigrated, Version: "20240101T000000Z", Rows: 3, Time: now},
+		{Kind: migrator.NotifyMigrating, Version: "20240102T000000Z", Name: "posts", Up: true, Time: now},
+		{Kind: migrator.NotifyFailed, Version: "20240102T000000Z", Error: "failed", Time: now},
+	} {
+		err := n.Notify(note)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	spans := rec.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("%d spans ended, want 3", len(spans))
+	}
+
+	users, posts, run := spans[0], spans[1], spans[2]
+	if users.Name() != "migrator.migration 20240101T000000Z" || users.Status().Code == codes.Error {
+		t.Errorf("users span %q with status %v", users.Name(), users.Status())
+	}
+
+	if posts.Status().Code != codes.Error || run.Status().Code != codes.Error || run.Name() != "migrator.run" {
+		t.Errorf("spans %q and %q not failed", posts.Name(), run.Name())
+	}
+
+	for _, span := range []sdktrace.ReadOnlySpan{users, posts} {
+		if span.Parent().SpanID() != run.SpanContext().SpanID() {
+			t.Errorf("span %q is not a child of the run", span.Name())
+		}
+	}
+
+	attrs := make(map[string]string)
+	for _, kv := range users.Attributes() {
+		attrs[string(kv.Key)] = kv.Value.Emit()
+	}
+
+	if attrs["migrator.direction"] != "up" || attrs["migrator.name"] != "users" || attrs["migrator.rows_affected"] != "3" {
+		t.Errorf("users span attributes = %v", attrs)
+	}
+}
+
+func TestNotifyWithoutRun(t *testing.T) {
+	rec := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec))
+	n := New(context.Background(), WithTracerProvider(tp))
+
+	for _, kind := range []migrator.NotificationKind{migrator.NotifyMigrating, migrator.NotifyMigrated, migrator.NotifyFinished} {
+		err := n.Notify(migrator.Notification{Kind: kind})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(rec.Started()) != 0 {
+		t.Errorf("%d spans started without a run", len(rec.Started()))
+	}
+}
@@ -0,0 +1,111 @@
+// Package migratorprom exports Prometheus metrics of migration runs.
+//
+// A Collector is both a prometheus.Collector and a migrator.Notifier:
+//
+//	c := migratorprom.New(db)
+//	prometheus.MustRegister(c)
+//	migrator.Migrate(db, "", migrator.WithNotifier(c))
+package migratorprom
+
+import (
+	"database/sql"
+
+	"github.com/pnelson/migrator"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// A Collector collects metrics of the migration runs it is notified of
+// and the number of pending migrations of the database.
+type Collector struct {
+	db       *sql.DB
+	opts     []migrator.Option
+	applied  *prometheus.CounterVec
+	failed   prometheus.Counter
+	duration *prometheus.HistogramVec
+	lastRun  *prometheus.GaugeVec
+	pending  *prometheus.Desc
+}
+
+// New returns a collector of the database. The options apply to the
+// query of pending migrations, such as migrator.WithTable.
+func New(db *sql.DB, opts ...migrator.Option) *Collector {
+	return &Collector{
+		db:   db,
+		opts: opts,
+		applied: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "migrator_migrations_applied_total",
+			Help: "Number of migrations performed by direction.",
+		}, []string{"direction"}),
+		failed: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "migrator_migrations_failed_total",
+			Help: "Number of runs that failed.",
+		}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "migrator_migration_duration_seconds",
+			Help:    "Time taken to perform each migration.",
+			Buckets: prometheus.ExponentialBuckets(0.01, 4, 10),
+		}, []string{"version", "direction"}),
+		lastRun: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "migrator_last_run_timestamp_seconds",
+			Help: "Time the most recent run finished by result.",
+		}, []string{"result"}),
+		pending: prometheus.NewDesc(
+			"migrator_migrations_pending",
+			"Number of registered migrations that are not applied.",
+			nil, nil,
+		),
+	}
+}
+
+// Notify records the metrics of the notification.
+func (c *Collector) Notify(n migrator.Notification) error {
+	switch n.Kind {
+	case migrator.NotifyMigrated:
+		direction := "up"
+		if !n.Up {
+			direction = "down"
+		}
+		c.applied.WithLabelValues(direction).Inc()
+		c.duration.WithLabelValues(n.Version, direction).Observe(n.Elapsed.Seconds())
+	case migrator.NotifyFailed:
+		c.failed.Inc()
+		c.lastRun.WithLabelValues("failure").Set(float64(n.Time.Unix()))
+	case migrator.NotifyFinished:
+		c.lastRun.WithLabelValues("success").Set(float64(n.Time.Unix()))
+	}
+
+	return nil
+}
+
+// Describe sends the descriptors of the metrics to the channel.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	c.applied.Describe(ch)
+	c.failed.Describe(ch)
+	c.duration.Describe(ch)
+	c.lastRun.Describe(ch)
+	ch <- c.pending
+}
+
+// Collect sends the metrics to the channel, querying the database for
+// the number of pending migrations.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	c.applied.Collect(ch)
+	c.failed.Collect(ch)
+	c.duration.Collect(ch)
+	c.lastRun.Collect(ch)
+
+	infos, err := migrator.Inspect(c.db, c.opts...)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.pending, err)
+		return
+	}
+
+	pending := 0
+	for _, info := range infos {
+		if !info.Applied {
+			pending++
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.pending, prometheus.GaugeValue, float64(pending))
+}
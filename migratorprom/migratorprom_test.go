@@ -0,0 +1,98 @@
+package migratorprom
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pnelson/migrator"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNotify(t *testing.T) {
+	c := New(nil)
+	now := time.Unix(1700000000, 0)
+
+	for _, n := range []migrator.Notification{
+		{Kind: migrator.NotifyStarted},
+		{Kind: migrator.NotifyMigrated, Version: "20240101T000000Z", Up: true, Elapsed: time.Second},
+		{Kind: migrator.NotifyMigrated, Version: "20240102T000000Z", Up: true},
+		{Kind: migrator.NotifyMigrated, Version: "20240102T000000Z"},
+		{Kind: migrator.NotifyFailed, Time: now},
+		{Kind: migrator.NotifyFinished, Time: now.Add(time.Minute)},
+	} {
+		err := c.Notify(n)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		have float64
+		want float64
+	}{
+		{"applied up", testutil.ToFloat64(c.applied.WithLabelValues("up")), 2},
+		{"applied down", testutil.ToFloat64(c.applied.WithLabelValues("down")), 1},
+		{"failed", testutil.ToFloat64(c.failed), 1},
+		{"last failure", testutil.ToFloat64(c.lastRun.WithLabelValues("failure")), 1700000000},
+		{"last success", testutil.ToFloat64(c.lastRun.WithLabelValues("success")), 1700000060},
+	}
+
+	for _, tt := range tests {
+		if tt.have != tt.want {
+			t.Errorf("%s = %v, want %v", tt.name, tt.have, tt.want)
+		}
+	}
+
+	if n := testutil.CollectAndCount(c.duration); n != 3 {
+		t.Errorf("%d duration series, want 3", n)
+	}
+}
+
+// pending returns the exposition of the pending metric with the value.
+func pending(n int) string {
+	return fmt.Sprintf(`# HELP migrator_migrations_pending Number of registered migrations that are not applied.
+# TYPE migrator_migrations_pending gauge
+migrator_migrations_pending %d
+`, n)
+}
+
+func TestCollectPending(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	sqlite := migrator.WithDialect(migrator.SQLite)
+	_, err = migrator.Current(db, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := New(db, sqlite)
+	err = testutil.CollectAndCompare(c, strings.NewReader(pending(1)), "migrator_migrations_pending")
+	if err != nil {
+		t.Error(err)
+	}
+
+	err = migrator.Migrate(db, "", sqlite, migrator.WithNotifier(c))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = testutil.CollectAndCompare(c, strings.NewReader(pending(0)), "migrator_migrations_pending")
+	if err != nil {
+		t.Error(err)
+	}
+
+	if have := testutil.ToFloat64(c.applied.WithLabelValues("up")); have != 1 {
+		t.Errorf("applied up = %v after migrating, want 1", have)
+	}
+}
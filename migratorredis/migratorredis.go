@@ -0,0 +1,134 @@
+// Package migratorredis elects the instance that performs migration runs
+// and serializes runs with locks held in Redis.
+//
+// A Coordinator elects the instance that sets its key:
+//
+//	c := migratorredis.New(client, "app:migrator", 30*time.Second)
+//	migrator.Migrate(db, "", migrator.WithCoordinator(c))
+//
+// A Lock performs runs one at a time, waiting to set its key:
+//
+//	l := migratorredis.NewLock(client, "app:migrator:lock", 30*time.Second)
+//	migrator.Migrate(db, "", migrator.WithExternalLock(l))
+package migratorredis
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// retryInterval is the delay between the attempts of a Lock to set its
+// key while another instance holds it.
+const retryInterval = time.Second
+
+// extend extends the expiry of the key if it is still held by the token.
+var extend = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+  return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// release deletes the key if it is still held by the token.
+var release = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+  return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// A Coordinator elects the instance that sets a key in Redis. The key
+// expires after the ttl unless the leader extends it, so a failed leader
+// is replaced after at most the ttl.
+type Coordinator struct {
+	client redis.Cmdable
+	key    string
+	ttl    time.Duration
+}
+
+// New returns a coordinator of the key with the ttl.
+func New(client redis.Cmdable, key string, ttl time.Duration) *Coordinator {
+	return &Coordinator{client: client, key: key, ttl: ttl}
+}
+
+// Lead sets the key if it is not set and extends it every third of the
+// ttl until resigning.
+func (c *Coordinator) Lead(ctx context.Context) (func(), bool, error) {
+	return hold(ctx, c.client, c.key, c.ttl)
+}
+
+// A Lock is held by the instance that sets a key in Redis. The key
+// expires after the ttl unless the holder extends it, so the lock of a
+// failed holder is released after at most the ttl.
+type Lock struct {
+	client redis.Cmdable
+	key    string
+	ttl    time.Duration
+}
+
+// NewLock returns a lock of the key with the ttl.
+func NewLock(client redis.Cmdable, key string, ttl time.Duration) *Lock {
+	return &Lock{client: client, key: key, ttl: ttl}
+}
+
+// Acquire sets the key once it is not set, trying every second until the
+// context is done, and extends it every third of the ttl until released.
+func (l *Lock) Acquire(ctx context.Context) (func(), error) {
+	for {
+		unlock, ok, err := hold(ctx, l.client, l.key, l.ttl)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			return unlock, nil
+		}
+
+		t := time.NewTimer(retryInterval)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// hold sets the key with the ttl if it is not set and extends it every
+// third of the ttl until the returned function deletes it.
+func hold(ctx context.Context, client redis.Cmdable, key string, ttl time.Duration) (func(), bool, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return nil, false, err
+	}
+
+	token := hex.EncodeToString(b)
+	ok, err := client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil || !ok {
+		return nil, false, err
+	}
+
+	done := make(chan struct{})
+	go func() {
+		t := time.NewTicker(ttl / 3)
+		defer t.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-t.C:
+				extend.Run(context.Background(), client, []string{key}, token, ttl.Milliseconds())
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		release.Run(context.Background(), client, []string{key}, token)
+	}, true, nil
+}
@@ -0,0 +1,96 @@
+package migratorredis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestLead(t *testing.T) {
+	s := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	defer client.Close()
+
+	ctx := context.Background()
+	a := New(client, "app:migrator", time.Minute)
+	resign, ok, err := a.Lead(ctx)
+	if err != nil || !ok {
+		t.Fatalf("Lead = %t, %v, want elected", ok, err)
+	}
+
+	b := New(client, "app:migrator", time.Minute)
+	_, ok, err = b.Lead(ctx)
+	if err != nil || ok {
+		t.Errorf("Lead while another leads = %t, %v, want not elected", ok, err)
+	}
+
+	resign()
+	if s.Exists("app:migrator") {
+		t.Error("key held after resigning")
+	}
+
+	resign, ok, err = b.Lead(ctx)
+	if err != nil || !ok {
+		t.Fatalf("Lead after resigning = %t, %v, want elected", ok, err)
+	}
+
+	resign()
+}
+
+func TestLeadExpired(t *testing.T) {
+	s := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	defer client.Close()
+
+	ctx := context.Background()
+	err := client.Set(ctx, "app:migrator", "failed leader", time.Second).Err()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := New(client, "app:migrator", time.Minute)
+	_, ok, _ := c.Lead(ctx)
+	if ok {
+		t.Fatal("Lead elected while the failed leader holds the key")
+	}
+
+	s.FastForward(2 * time.Second)
+	resign, ok, err := c.Lead(ctx)
+	if err != nil || !ok {
+		t.Fatalf("Lead after the key expired = %t, %v, want elected", ok, err)
+	}
+
+	resign()
+}
+
+func TestLock(t *testing.T) {
+	s := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: s.Addr()})
+	defer client.Close()
+
+	a := NewLock(client, "app:migrator:lock", time.Minute)
+	release, err := a.Acquire(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	b := NewLock(client, "app:migrator:lock", time.Minute)
+	_, err = b.Acquire(ctx)
+	if err != context.DeadlineExceeded {
+		t.Errorf("Acquire while held error = %v, want %v", err, context.DeadlineExceeded)
+	}
+
+	release()
+	release, err = b.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("Acquire after release error = %v", err)
+	}
+
+	release()
+}
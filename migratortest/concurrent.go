@@ -0,0 +1,97 @@
+package migratortest
+
+import (
+	"database/sql"
+	"sync"
+	"testing"
+
+	"github.com/pnelson/migrator"
+)
+
+// performed counts the migrations performed up by version timestamp.
+type performed struct {
+	mu sync.Mutex
+	m  map[string]int
+}
+
+// Notify counts the migration if it was performed up.
+func (p *performed) Notify(n migrator.Notification) error {
+	if n.Kind != migrator.NotifyMigrated || !n.Up {
+		return nil
+	}
+
+	p.mu.Lock()
+	p.m[n.Version]++
+	p.mu.Unlock()
+	return nil
+}
+
+// Concurrent runs n migrations of the database to the latest version at
+// the same time and reports with t.Error any run that fails, any version
+// performed or recorded in the versions table more than once and any
+// version left pending, so a test can validate the locking of its
+// configuration, such as migrator.WithLock passed to WithMigrateOptions.
+// The database should have no migrations applied.
+func Concurrent(t testing.TB, db *sql.DB, n int, opts ...Option) {
+	t.Helper()
+
+	o := &options{table: "versions"}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	p := &performed{m: make(map[string]int)}
+	migrate := append(append([]migrator.Option{}, o.migrate...), migrator.WithNotifier(p))
+
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	start := make(chan struct{})
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs[i] = migrator.Migrate(db, "", migrate...)
+		}(i)
+	}
+
+	close(start)
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			t.Errorf("migratortest: run %d: %v", i, err)
+		}
+	}
+
+	for v, count := range p.m {
+		if count > 1 {
+			t.Errorf("migratortest: %s performed %d times", v, count)
+		}
+	}
+
+	applied, err := migrator.Applied(db, o.migrate...)
+	if err != nil {
+		t.Fatalf("migratortest: applied: %v", err)
+	}
+
+	recorded := make(map[string]int)
+	for _, info := range applied {
+		recorded[info.Version]++
+	}
+
+	for v, count := range recorded {
+		if count > 1 {
+			t.Errorf("migratortest: %s recorded %d times in the versions table", v, count)
+		}
+	}
+
+	state, vs, err := migrator.Check(db, o.migrate...)
+	if err != nil {
+		t.Fatalf("migratortest: check: %v", err)
+	}
+
+	if state != migrator.UpToDate {
+		t.Errorf("migratortest: %s after concurrent runs: %v", state, vs)
+	}
+}
@@ -0,0 +1,42 @@
+package migratortest
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/pnelson/migrator"
+)
+
+func TestConcurrent(t *testing.T) {
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	// A single connection serializes the runs as a lock would.
+	db.SetMaxOpenConns(1)
+	Concurrent(t, db, 4, WithMigrateOptions(migrator.WithDialect(migrator.SQLite)))
+
+	if n := count(t, db, "users"); n != 0 {
+		t.Errorf("%d users after concurrent runs, want 0", n)
+	}
+}
+
+func TestPerformed(t *testing.T) {
+	p := &performed{m: make(map[string]int)}
+	for _, n := range []migrator.Notification{
+		{Kind: migrator.NotifyMigrated, Version: "20240101T000000Z", Up: true},
+		{Kind: migrator.NotifyMigrated, Version: "20240101T000000Z", Up: true},
+		{Kind: migrator.NotifyMigrated, Version: "20240102T000000Z"},
+		{Kind: migrator.NotifyStarted},
+	} {
+		p.Notify(n)
+	}
+
+	if len(p.m) != 1 || p.m["20240101T000000Z"] != 2 {
+		t.Errorf("performed = %v, want 20240101T000000Z twice", p.m)
+	}
+}
@@ -0,0 +1,128 @@
+package migratortest
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/pnelson/migrator"
+)
+
+// unsafe matches the characters of a test name that are not allowed in a
+// database name.
+var unsafe = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// Database creates a uniquely named database on the server of the url
+// for the test, migrates it to the latest version and drops it when the
+// test and its subtests complete, so parallel tests each have their own
+// database. In SQLite, the database is a file in a temporary directory.
+// Failures are reported with t.Fatal.
+func Database(t testing.TB, url string, opts ...Option) *sql.DB {
+	t.Helper()
+
+	db, drop, err := NewDatabase(url, t.Name(), opts...)
+	if err != nil {
+		t.Fatalf("migratortest: %v", err)
+	}
+
+	t.Cleanup(func() {
+		err := drop()
+		if err != nil {
+			t.Errorf("migratortest: %v", err)
+		}
+	})
+
+	return db
+}
+
+// NewDatabase creates a uniquely named database prefixed by the name on
+// the server of the url, migrates it to the latest version and returns
+// it along with a function that closes and drops it. Unlike Database,
+// it can be used to create a database per package from TestMain.
+func NewDatabase(rawurl, name string, opts ...Option) (*sql.DB, func() error, error) {
+	db, _, drop, err := newDatabase(rawurl, name, opts...)
+	return db, drop, err
+}
+
+// newDatabase is NewDatabase that also returns the unique name of the
+// database.
+func newDatabase(rawurl, name string, opts ...Option) (*sql.DB, string, func() error, error) {
+	o := &options{table: "versions"}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	name = unique(name)
+	target, drop, err := migrator.CreateDatabase(rawurl, name)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("create database %s: %w", name, err)
+	}
+
+	db, d, err := migrator.Open(target)
+	if err != nil {
+		drop()
+		return nil, "", nil, err
+	}
+
+	o.migrate = append([]migrator.Option{migrator.WithDialect(d)}, o.migrate...)
+	err = migrator.Migrate(db, o.target, o.migrate...)
+	if err != nil {
+		db.Close()
+		drop()
+		return nil, "", nil, fmt.Errorf("migrate: %w", err)
+	}
+
+	return db, name, func() error {
+		err := db.Close()
+		if err != nil {
+			return err
+		}
+
+		err = drop()
+		if err != nil {
+			return fmt.Errorf("drop database %s: %w", name, err)
+		}
+
+		return nil
+	}, nil
+}
+
+// unique returns the name made safe for a database name with a random
+// suffix.
+func unique(name string) string {
+	b := make([]byte, 4)
+	rand.Read(b)
+
+	name = unsafe.ReplaceAllString(strings.ToLower(name), "_")
+	if len(name) > 40 {
+		name = name[:40]
+	}
+
+	return strings.Trim(name, "_") + "_" + hex.EncodeToString(b)
+}
+
+// rename returns the url of the named database on the server of u.
+func rename(u *url.URL, name string) string {
+	target := *u
+	target.Path = "/" + name
+	return target.String()
+}
+
+// admin executes the statement on the database of the url, such as to
+// create or drop another database on its server.
+func admin(rawurl, stmt string) error {
+	db, _, err := migrator.Open(rawurl)
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	_, err = db.Exec(stmt)
+	return err
+}
@@ -0,0 +1,137 @@
+package migratortest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pnelson/migrator"
+)
+
+// containerTimeout is how long to wait for a container to accept
+// connections.
+const containerTimeout = 60 * time.Second
+
+// A Container is a throwaway database server running in Docker.
+type Container struct {
+	ID  string // id of the container
+	URL string // url of the database in the container
+}
+
+// StartContainer runs the Postgres or MySQL image, such as "postgres:16"
+// or "mysql:8", with the docker command on a random port and waits for
+// the database to accept connections. MySQL is detected by an image name
+// containing mysql or mariadb. The container must be removed with Close.
+func StartContainer(image string) (*Container, error) {
+	port := "5432/tcp"
+	env := []string{"POSTGRES_PASSWORD=migrator", "POSTGRES_DB=migrator"}
+	format := "postgres://postgres:migrator@%s/migrator?sslmode=disable"
+	if strings.Contains(image, "mysql") || strings.Contains(image, "mariadb") {
+		port = "3306/tcp"
+		env = []string{"MYSQL_ROOT_PASSWORD=migrator", "MYSQL_DATABASE=migrator", "MARIADB_ROOT_PASSWORD=migrator", "MARIADB_DATABASE=migrator"}
+		format = "mysql://root:migrator@%s/migrator"
+	}
+
+	args := []string{"run", "--detach", "--rm", "--publish", "127.0.0.1::" + port}
+	for _, v := range env {
+		args = append(args, "--env", v)
+	}
+
+	id, err := docker(append(args, image)...)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Container{ID: id}
+	addr, err := docker("port", id, port)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	// The port may be published on more than one address.
+	addr = strings.SplitN(addr, "\n", 2)[0]
+	_, p, err := net.SplitHostPort(addr)
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("docker port %s: %w", id, err)
+	}
+
+	c.URL = fmt.Sprintf(format, net.JoinHostPort("127.0.0.1", p))
+	db, _, err := migrator.Open(c.URL)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	defer db.Close()
+
+	err = migrator.WaitForDB(context.Background(), db, containerTimeout)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Close stops and removes the container.
+func (c *Container) Close() error {
+	_, err := docker("rm", "--force", "--volumes", c.ID)
+	return err
+}
+
+// Docker runs the Postgres or MySQL image for the test as with
+// StartContainer, migrates its database to the latest version and
+// removes the container when the test and its subtests complete.
+// Failures are reported with t.Fatal.
+func Docker(t testing.TB, image string, opts ...Option) *sql.DB {
+	t.Helper()
+
+	o := &options{table: "versions"}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	c, err := StartContainer(image)
+	if err != nil {
+		t.Fatalf("migratortest: %v", err)
+	}
+
+	t.Cleanup(func() {
+		err := c.Close()
+		if err != nil {
+			t.Errorf("migratortest: %v", err)
+		}
+	})
+
+	db, d, err := migrator.Open(c.URL)
+	if err != nil {
+		t.Fatalf("migratortest: open: %v", err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	o.migrate = append([]migrator.Option{migrator.WithDialect(d)}, o.migrate...)
+	err = migrator.Migrate(db, o.target, o.migrate...)
+	if err != nil {
+		t.Fatalf("migratortest: migrate: %v", err)
+	}
+
+	return db
+}
+
+// docker runs the docker command and returns its trimmed output.
+func docker(args ...string) (string, error) {
+	out, err := exec.Command("docker", args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("docker %s: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
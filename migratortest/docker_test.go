@@ -0,0 +1,15 @@
+package migratortest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStartContainerWithoutDocker(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	_, err := StartContainer("postgres:16")
+	if err == nil || !strings.HasPrefix(err.Error(), "docker run: ") {
+		t.Errorf("StartContainer error = %v, want the docker run failure", err)
+	}
+}
@@ -0,0 +1,45 @@
+package migratortest
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/pnelson/migrator"
+)
+
+// Up runs the up migration of the version in a transaction on the
+// database that is rolled back when the test and its subtests complete,
+// after setup prepares the state it migrates, and returns the
+// transaction to assert the effects of the migration. The database is
+// usually migrated to the version before with WithTarget and setup may
+// be nil. Failures are reported with t.Fatal.
+func Up(t testing.TB, db *sql.DB, version string, setup func(tx *sql.Tx) error) *sql.Tx {
+	t.Helper()
+
+	tx := Tx(t, db)
+	if setup != nil {
+		err := setup(tx)
+		if err != nil {
+			t.Fatalf("migratortest: setup: %v", err)
+		}
+	}
+
+	err := migrator.Perform(tx, version, true)
+	if err != nil {
+		t.Fatalf("migratortest: up %s: %v", version, err)
+	}
+
+	return tx
+}
+
+// Down runs the down migration of the version on the transaction, such
+// as one returned by Up to assert that the down migration reverses it.
+// Failures are reported with t.Fatal.
+func Down(t testing.TB, tx *sql.Tx, version string) {
+	t.Helper()
+
+	err := migrator.Perform(tx, version, false)
+	if err != nil {
+		t.Fatalf("migratortest: down %s: %v", version, err)
+	}
+}
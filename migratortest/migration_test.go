@@ -0,0 +1,24 @@
+package migratortest
+
+import (
+	"testing"
+
+	"github.com/pnelson/migrator"
+)
+
+func TestUpDown(t *testing.T) {
+	db := Database(t, "sqlite://ignored.db", WithTarget(migrator.NilVersion))
+
+	tx := Up(t, db, "20240101T000000Z", nil)
+	_, err := tx.Exec("INSERT INTO users (id) VALUES (1);")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Down(t, tx, "20240101T000000Z")
+	var n int
+	err = tx.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE name = 'users';").Scan(&n)
+	if err != nil || n != 0 {
+		t.Errorf("users table after down: %d, %v", n, err)
+	}
+}
@@ -0,0 +1,180 @@
+// Package migratortest provides helpers for tests that need a migrated
+// database.
+//
+//	func TestAccounts(t *testing.T) {
+//		db, cleanup := migratortest.Open(t, os.Getenv("TEST_DATABASE_URL"))
+//		defer cleanup()
+//		// ...
+//	}
+//
+// The migrations must be registered, such as by importing the package
+// that registers them or calling migrator.LoadDir, and the driver of the
+// database url must be imported.
+package migratortest
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pnelson/migrator"
+)
+
+// An Option configures a test database.
+type Option func(*options)
+
+// options is the configuration of a test database.
+type options struct {
+	migrate  []migrator.Option
+	table    string
+	truncate bool
+	target   string
+}
+
+// WithMigrateOptions sets the options of the migration runs.
+func WithMigrateOptions(opts ...migrator.Option) Option {
+	return func(o *options) {
+		o.migrate = append(o.migrate, opts...)
+	}
+}
+
+// WithTable sets the versions table of the database, which is never
+// truncated. The default table is "versions".
+func WithTable(name string) Option {
+	return func(o *options) {
+		o.table = name
+		o.migrate = append(o.migrate, migrator.WithTable(name))
+	}
+}
+
+// WithTarget migrates the database to the target version instead of the
+// latest version, such as the version before a migration under test.
+func WithTarget(version string) Option {
+	return func(o *options) {
+		o.target = version
+	}
+}
+
+// WithTruncate empties every table except the versions table on cleanup
+// instead of reverting every migration, so the next test starts with an
+// empty but migrated database.
+func WithTruncate() Option {
+	return func(o *options) {
+		o.truncate = true
+	}
+}
+
+// Open opens the database at the url and migrates it to the latest
+// version. The returned function cleans up after the test by reverting
+// every migration, or emptying the tables with WithTruncate, and closing
+// the database. Failures are reported with t.Fatal.
+func Open(t testing.TB, url string, opts ...Option) (*sql.DB, func()) {
+	t.Helper()
+
+	o := &options{table: "versions"}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	db, d, err := migrator.Open(url)
+	if err != nil {
+		t.Fatalf("migratortest: open: %v", err)
+	}
+
+	o.migrate = append([]migrator.Option{migrator.WithDialect(d)}, o.migrate...)
+	err = migrator.Migrate(db, o.target, o.migrate...)
+	if err != nil {
+		db.Close()
+		t.Fatalf("migratortest: migrate: %v", err)
+	}
+
+	return db, func() {
+		t.Helper()
+		defer db.Close()
+
+		if o.truncate {
+			Truncate(t, db, d, o.table)
+			return
+		}
+
+		err := migrator.Migrate(db, migrator.NilVersion, o.migrate...)
+		if err != nil {
+			t.Fatalf("migratortest: revert: %v", err)
+		}
+	}
+}
+
+// Truncate empties every table of the database except those provided,
+// such as the versions table. Failures are reported with t.Fatal.
+func Truncate(t testing.TB, db *sql.DB, d migrator.Dialect, except ...string) {
+	t.Helper()
+
+	tables, err := migrator.Tables(db, d)
+	if err != nil {
+		t.Fatalf("migratortest: tables: %v", err)
+	}
+
+	skip := make(map[string]bool)
+	for _, name := range except {
+		skip[name] = true
+	}
+
+	var names []string
+	for _, name := range tables {
+		if !skip[name] {
+			names = append(names, name)
+		}
+	}
+
+	if len(names) == 0 {
+		return
+	}
+
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("migratortest: truncate: %v", err)
+	}
+
+	defer conn.Close()
+
+	for _, stmt := range truncate(d, names) {
+		_, err := conn.ExecContext(ctx, stmt)
+		if err != nil {
+			t.Fatalf("migratortest: truncate: %v", err)
+		}
+	}
+}
+
+// truncate returns the statements that empty the tables in the dialect
+// on a single connection.
+func truncate(d migrator.Dialect, names []string) []string {
+	switch d {
+	case migrator.Postgres:
+		quoted := make([]string, len(names))
+		for i, name := range names {
+			quoted[i] = ident(name, `"`)
+		}
+		return []string{fmt.Sprintf("TRUNCATE %s RESTART IDENTITY CASCADE;", strings.Join(quoted, ", "))}
+	case migrator.MySQL:
+		stmts := []string{"SET FOREIGN_KEY_CHECKS = 0;"}
+		for _, name := range names {
+			stmts = append(stmts, fmt.Sprintf("TRUNCATE TABLE %s;", ident(name, "`")))
+		}
+		return append(stmts, "SET FOREIGN_KEY_CHECKS = 1;")
+	}
+
+	stmts := []string{"PRAGMA foreign_keys = OFF;"}
+	for _, name := range names {
+		stmts = append(stmts, fmt.Sprintf("DELETE FROM %s;", ident(name, `"`)))
+	}
+
+	return append(stmts, "PRAGMA foreign_keys = ON;")
+}
+
+// ident returns the identifier quoted by q.
+func ident(s, q string) string {
+	return q + strings.ReplaceAll(s, q, q+q) + q
+}
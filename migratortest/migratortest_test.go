@@ -0,0 +1,107 @@
+package migratortest
+
+import (
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pnelson/migrator"
+)
+
+func init() {
+	migrator.Register("20240101T000000Z", "users", func(tx *sql.Tx) error {
+		_, err := tx.Exec("CREATE TABLE users (id INTEGER);")
+		return err
+	}, func(tx *sql.Tx) error {
+		_, err := tx.Exec("DROP TABLE users;")
+		return err
+	})
+}
+
+// count returns the number of rows of the table.
+func count(t *testing.T, db *sql.DB, table string) int {
+	t.Helper()
+
+	var n int
+	err := db.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return n
+}
+
+func TestOpen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, cleanup := Open(t, "sqlite://"+path)
+
+	_, err := db.Exec("INSERT INTO users (id) VALUES (1);")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cleanup()
+
+	db, err = sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	tables, err := migrator.Tables(db, migrator.SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(tables, []string{"versions"}) {
+		t.Errorf("tables after cleanup = %q, want only versions", tables)
+	}
+}
+
+func TestOpenTruncate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, cleanup := Open(t, "sqlite://"+path, WithTruncate(), WithTable("schema_versions"))
+
+	_, err := db.Exec("INSERT INTO users (id) VALUES (1);")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cleanup()
+
+	db, err = sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	if n := count(t, db, "users"); n != 0 {
+		t.Errorf("%d users after cleanup, want 0", n)
+	}
+
+	if n := count(t, db, "schema_versions"); n != 2 {
+		t.Errorf("%d versions after cleanup, want 2", n)
+	}
+}
+
+func TestTruncateStatements(t *testing.T) {
+	tests := []struct {
+		d    migrator.Dialect
+		want []string
+	}{
+		{migrator.Postgres, []string{`TRUNCATE "a", "b""c" RESTART IDENTITY CASCADE;`}},
+		{migrator.MySQL, []string{"SET FOREIGN_KEY_CHECKS = 0;", "TRUNCATE TABLE `a`;", "TRUNCATE TABLE `b\"c`;", "SET FOREIGN_KEY_CHECKS = 1;"}},
+		{migrator.SQLite, []string{"PRAGMA foreign_keys = OFF;", `DELETE FROM "a";`, `DELETE FROM "b""c";`, "PRAGMA foreign_keys = ON;"}},
+	}
+
+	for _, tt := range tests {
+		have := truncate(tt.d, []string{"a", `b"c`})
+		if !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("truncate in %s = %q, want %q", tt.d, have, tt.want)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package migratortest
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/pnelson/migrator"
+)
+
+// A Template is a migrated Postgres database that is cloned for each test
+// with CREATE DATABASE ... TEMPLATE, which copies the files of the
+// database instead of running every migration again.
+type Template struct {
+	rawurl string
+	u      *url.URL
+	name   string
+}
+
+// NewTemplate creates a uniquely named database prefixed by the name on
+// the Postgres server of the url and migrates it to the latest version
+// to be cloned by Database. The template must be dropped with Close,
+// such as at the end of TestMain.
+func NewTemplate(rawurl, name string, opts ...Option) (*Template, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.Scheme != "postgres" && u.Scheme != "postgresql" {
+		return nil, fmt.Errorf("template databases require postgres, not %q", u.Scheme)
+	}
+
+	db, name, drop, err := newDatabase(rawurl, name, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// The template cannot be cloned while it has connections.
+	err = db.Close()
+	if err != nil {
+		drop()
+		return nil, err
+	}
+
+	return &Template{rawurl: rawurl, u: u, name: name}, nil
+}
+
+// Database clones the template into a uniquely named database for the
+// test and drops it when the test and its subtests complete. Failures
+// are reported with t.Fatal.
+func (tmpl *Template) Database(t testing.TB) *sql.DB {
+	t.Helper()
+
+	db, drop, err := tmpl.Clone(t.Name())
+	if err != nil {
+		t.Fatalf("migratortest: %v", err)
+	}
+
+	t.Cleanup(func() {
+		err := drop()
+		if err != nil {
+			t.Errorf("migratortest: %v", err)
+		}
+	})
+
+	return db
+}
+
+// Clone clones the template into a uniquely named database prefixed by
+// the name and returns it along with a function that closes and drops
+// it.
+func (tmpl *Template) Clone(name string) (*sql.DB, func() error, error) {
+	name = unique(name)
+	err := admin(tmpl.rawurl, "CREATE DATABASE "+ident(name, `"`)+" TEMPLATE "+ident(tmpl.name, `"`))
+	if err != nil {
+		return nil, nil, fmt.Errorf("clone database %s: %w", tmpl.name, err)
+	}
+
+	drop := func() error {
+		return admin(tmpl.rawurl, "DROP DATABASE IF EXISTS "+ident(name, `"`))
+	}
+
+	db, _, err := migrator.Open(rename(tmpl.u, name))
+	if err != nil {
+		drop()
+		return nil, nil, err
+	}
+
+	return db, func() error {
+		err := db.Close()
+		if err != nil {
+			return err
+		}
+
+		err = drop()
+		if err != nil {
+			return fmt.Errorf("drop database %s: %w", name, err)
+		}
+
+		return nil
+	}, nil
+}
+
+// Close drops the template database. Databases cloned from it are not
+// affected.
+func (tmpl *Template) Close() error {
+	err := admin(tmpl.rawurl, "DROP DATABASE IF EXISTS "+ident(tmpl.name, `"`))
+	if err != nil {
+		return fmt.Errorf("drop database %s: %w", tmpl.name, err)
+	}
+
+	return nil
+}
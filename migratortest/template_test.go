@@ -0,0 +1,15 @@
+package migratortest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewTemplateDialect(t *testing.T) {
+	for _, rawurl := range []string{"sqlite://test.db", "mysql://user@localhost/app"} {
+		_, err := NewTemplate(rawurl, "template")
+		if err == nil || !strings.Contains(err.Error(), "require postgres") {
+			t.Errorf("NewTemplate(%q) error = %v, want postgres required", rawurl, err)
+		}
+	}
+}
@@ -0,0 +1,30 @@
+package migratortest
+
+import (
+	"database/sql"
+	"testing"
+)
+
+// Tx begins a transaction on the database for the test and rolls it back
+// when the test and its subtests complete, so a test isolates its changes
+// from other tests without truncating tables. Statements that commit
+// implicitly, such as DDL in MySQL, are not rolled back. The database is
+// usually migrated with Open, Database or a Template. Failures are
+// reported with t.Fatal.
+func Tx(t testing.TB, db *sql.DB) *sql.Tx {
+	t.Helper()
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatalf("migratortest: begin: %v", err)
+	}
+
+	t.Cleanup(func() {
+		err := tx.Rollback()
+		if err != nil && err != sql.ErrTxDone {
+			t.Errorf("migratortest: rollback: %v", err)
+		}
+	})
+
+	return tx
+}
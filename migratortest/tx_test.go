@@ -0,0 +1,27 @@
+package migratortest
+
+import "testing"
+
+func TestTx(t *testing.T) {
+	db := Database(t, "sqlite://ignored.db")
+
+	t.Run("insert", func(t *testing.T) {
+		tx := Tx(t, db)
+		_, err := tx.Exec("INSERT INTO users (id) VALUES (1);")
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("committed", func(t *testing.T) {
+		tx := Tx(t, db)
+		err := tx.Commit()
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if n := count(t, db, "users"); n != 0 {
+		t.Errorf("%d users after the test, want 0", n)
+	}
+}
@@ -0,0 +1,160 @@
+package migrator
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// A NotificationKind is the event of a run that is notified.
+type NotificationKind string
+
+// Kinds of notifications.
+const (
+	NotifyStarted   NotificationKind = "started"   // the run started
+	NotifyMigrating NotificationKind = "migrating" // a migration is about to be performed
+	NotifyMigrated  NotificationKind = "migrated"  // a migration was performed
+	NotifyFailed    NotificationKind = "failed"    // the run failed
+	NotifyFinished  NotificationKind = "finished"  // the run succeeded
+)
+
+// A Notification describes an event of a run.
+type Notification struct {
+	Kind      NotificationKind `json:"kind"`
+	Target    string           `json:"target"`
+	Version   string           `json:"version,omitempty"`
+	Name      string           `json:"name,omitempty"`
+	Up        bool             `json:"up,omitempty"`
+	Elapsed   time.Duration    `json:"elapsed,omitempty"`
+	Rows      int64            `json:"rows,omitempty"`      // rows affected by statements executed with Exec
+	Statement string           `json:"statement,omitempty"` // statement that failed the migration, if known
+	Error     string           `json:"error,omitempty"`     // error of the run, or of the driver for Statement
+	Time      time.Time        `json:"time"`
+}
+
+// A Notifier is notified when a run starts, before and after each
+// migration is performed and when a run fails or succeeds, such as to
+// alert chat or incident tooling when a schema changes.
+type Notifier interface {
+	Notify(n Notification) error
+}
+
+// WithNotifier adds a notifier to the run. Errors of the notifier are
+// logged and do not fail the run.
+func WithNotifier(n Notifier) Option {
+	return func(o *options) {
+		o.notifiers = append(o.notifiers, n)
+	}
+}
+
+// notify sends the notification to the notifiers of the run.
+func (o *options) notify(n Notification) {
+	n.Target = o.run.Target
+	n.Time = o.now()
+	for _, notifier := range o.notifiers {
+		err := notifier.Notify(n)
+		if err != nil {
+			o.logf(LevelError, "error notifying %s: %v", n.Kind, err)
+		}
+	}
+}
+
+// notifyFailed notifies that the run failed with the error. If the run
+// failed performing a migration, the notification describes the version,
+// the name and the elapsed time of the migration and the statement that
+// failed, if known.
+func (o *options) notifyFailed(err error) {
+	n := Notification{Kind: NotifyFailed, Error: err.Error()}
+	if o.failure != nil {
+		n = *o.failure
+	}
+
+	o.notify(n)
+}
+
+// A Webhook is a Notifier that posts each notification as JSON to a URL.
+type Webhook struct {
+	URL    string       // URL to post notifications to
+	Header http.Header  // headers of each request, such as Authorization
+	Client *http.Client // client of the requests, http.DefaultClient if nil
+}
+
+// Notify posts the notification as JSON to the URL of the webhook.
+func (w *Webhook) Notify(n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.URL, strings.NewReader(string(body)))
+	if err != nil {
+		return err
+	}
+
+	for k, v := range w.Header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("migrator: webhook responded %s", resp.Status)
+	}
+
+	return nil
+}
+
+// queryNotify notifies the listeners of a channel with a payload.
+var queryNotify = `SELECT pg_notify($1, $2);`
+
+// WithPGNotify sends a Postgres notification on the channel, "migrator"
+// if empty, after a run that performed migrations succeeds, so services
+// listening on the database can invalidate caches or reload prepared
+// statements. The payload is a JSON object with the current version and
+// the versions performed by the run.
+func WithPGNotify(channel string) Option {
+	return func(o *options) {
+		if channel == "" {
+			channel = "migrator"
+		}
+		o.channel = channel
+	}
+}
+
+// pgNotify sends the notification of WithPGNotify on the connection if
+// the run performed migrations.
+func (o *options) pgNotify(conn *sql.Conn) error {
+	if o.channel == "" || o.dialect != Postgres || len(o.run.Performed) == 0 {
+		return nil
+	}
+
+	current, err := currentVersion(conn, o)
+	if err != nil {
+		return err
+	}
+
+	payload, err := json.Marshal(struct {
+		Version   string   `json:"version"`
+		Performed []string `json:"performed"`
+	}{current, o.run.Performed})
+	if err != nil {
+		return err
+	}
+
+	_, err = conn.ExecContext(o.ctx, queryNotify, o.channel, string(payload))
+	return err
+}
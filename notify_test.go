@@ -0,0 +1,187 @@
+package migrator
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// notifications records the notifications of a run.
+type notifications []Notification
+
+func (ns *notifications) Notify(n Notification) error {
+	*ns = append(*ns, n)
+	return nil
+}
+
+func TestWithNotifier(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+	registerSQL("20240102T000000Z", "invalid", []string{"CREATE TABLE"}, nil, false, nil)
+
+	var ns notifications
+	db := openTestDB(t)
+	err := Migrate(db, "", WithDialect(SQLite), WithNotifier(&ns), WithLogger(&testLogger{}))
+	if err == nil {
+		t.Fatal("Migrate error = nil")
+	}
+
+	kinds := []NotificationKind{
+		NotifyStarted,
+		NotifyMigrating, NotifyMigrated,
+		NotifyMigrating, NotifyMigrated,
+		NotifyMigrating, NotifyFailed,
+	}
+	if len(ns) != len(kinds) {
+		t.Fatalf("%d notifications, want %d: %+v", len(ns), len(kinds), ns)
+	}
+
+	for i, n := range ns {
+		if n.Kind != kinds[i] || n.Target != "" || n.Time.IsZero() {
+			t.Errorf("notification %d = %+v, want %s", i, n, kinds[i])
+		}
+	}
+
+	for _, n := range ns[3:5] {
+		if n.Version != "20240101T000000Z" || n.Name != "create_users" || !n.Up {
+			t.Errorf("%s = %+v, want 20240101T000000Z up", n.Kind, n)
+		}
+	}
+
+	if n := ns[6]; n.Version != "20240102T000000Z" || n.Statement != "CREATE TABLE" || !strings.Contains(err.Error(), n.Error) {
+		t.Errorf("failed = %+v, want 20240102T000000Z with the failed statement", n)
+	}
+}
+
+func TestNotifyRows(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+	Register("20240102T000000Z", "seed", func(tx *sql.Tx) error {
+		_, err := Exec(tx, "INSERT INTO users (id) VALUES (1), (2), (3)")
+		return err
+	}, empty)
+
+	var ns notifications
+	db := openTestDB(t)
+	err := Migrate(db, "", WithDialect(SQLite), WithNotifier(&ns))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	n := ns[len(ns)-2]
+	if n.Kind != NotifyMigrated || n.Version != "20240102T000000Z" || n.Rows != 3 {
+		t.Errorf("migrated = %+v, want 3 rows affected by 20240102T000000Z", n)
+	}
+}
+
+func TestNotifyFailed(t *testing.T) {
+	isolate(t)
+
+	insert := "INSERT INTO missing (id) VALUES (1);"
+	Register("20240101T000000Z", "seed", func(tx *sql.Tx) error {
+		_, err := Exec(tx, insert)
+		if err != nil {
+			return fmt.Errorf("seed: %w", err)
+		}
+
+		return nil
+	}, empty)
+
+	var ns notifications
+	db := openTestDB(t)
+	err := Migrate(db, "", WithDialect(SQLite), WithNotifier(&ns), WithLogger(&testLogger{}))
+	if err == nil {
+		t.Fatal("Migrate error = nil")
+	}
+
+	n := ns[len(ns)-1]
+	if n.Kind != NotifyFailed || n.Version != "20240101T000000Z" || n.Name != "seed" || !n.Up || n.Statement != insert {
+		t.Errorf("failed = %+v, want the failed statement of 20240101T000000Z", n)
+	}
+
+	if n.Error == err.Error() || !strings.HasSuffix(err.Error(), n.Error) {
+		t.Errorf("failed error = %q, want the error of the statement", n.Error)
+	}
+
+	failed := errors.New("failed")
+	delete(migrations, "20240101T000000Z")
+	Register("20240101T000000Z", "seed", func(tx *sql.Tx) error { return failed }, empty)
+
+	ns = nil
+	err = Migrate(db, "", WithDialect(SQLite), WithNotifier(&ns), WithLogger(&testLogger{}))
+	if err == nil {
+		t.Fatal("Migrate error = nil")
+	}
+
+	n = ns[len(ns)-1]
+	if n.Kind != NotifyFailed || n.Version != "20240101T000000Z" || n.Statement != "" || n.Error != failed.Error() {
+		t.Errorf("failed = %+v, want the error of the migration without a statement", n)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var have Notification
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("content type %q", r.Header.Get("Content-Type"))
+		}
+
+		err := json.NewDecoder(r.Body).Decode(&have)
+		if err != nil {
+			t.Error(err)
+		}
+
+		if have.Kind == NotifyFailed {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	w := &Webhook{URL: srv.URL, Header: http.Header{"Authorization": {"Bearer token"}}}
+	err := w.Notify(Notification{Kind: NotifyMigrated, Version: "20240101T000000Z"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have.Kind != NotifyMigrated || have.Version != "20240101T000000Z" || auth != "Bearer token" {
+		t.Errorf("posted %+v with authorization %q", have, auth)
+	}
+
+	err = w.Notify(Notification{Kind: NotifyFailed, Error: "failed"})
+	if err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("Notify error = %v, want the response status", err)
+	}
+}
+
+func TestWithPGNotify(t *testing.T) {
+	tests := []struct {
+		channel string
+		want    string
+	}{
+		{"", "migrator"},
+		{"schema", "schema"},
+	}
+
+	for _, tt := range tests {
+		o := newOptions([]Option{WithPGNotify(tt.channel)})
+		if o.channel != tt.want {
+			t.Errorf("WithPGNotify(%q) channel = %q, want %q", tt.channel, o.channel, tt.want)
+		}
+	}
+
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+
+	db := openTestDB(t)
+	err := Migrate(db, "", WithDialect(SQLite), WithPGNotify(""))
+	if err != nil {
+		t.Errorf("Migrate on SQLite with WithPGNotify = %v, want nil", err)
+	}
+}
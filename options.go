@@ -0,0 +1,294 @@
+package migrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// An Option configures a migration run.
+type Option func(*options)
+
+// options is the configuration of a migration run.
+type options struct {
+	tags         []string
+	without      []string
+	phases       []Phase
+	exclude      []string
+	skipExcluded bool
+	only         []string
+	progress     func(Progress)
+	dialect      Dialect
+	preflight    func([]Warning) error
+	confirm      func([]Step) error
+	logger       Logger
+	slow         time.Duration
+	roles        map[string]string
+	echo         bool
+	redact       bool
+	timing       func(Timing)
+	before       []hook
+	after        []hook
+	table        string
+	ctx          context.Context
+	wait         time.Duration
+	lock         bool
+	run          *Run
+	result       *Run
+	notifiers    []Notifier
+	channel      string
+	rows         int64
+	events       chan<- Event
+	level        Level
+	expected     *Schema
+	clock        Clock
+	shadow       string
+	set          string
+	tableSet     bool
+	parallel     int
+	failFast     bool
+	primary      bool
+	coordinator  Coordinator
+	downSQL      bool
+	softDelete   bool
+	enter        func(ctx context.Context) error
+	exit         func(ctx context.Context) error
+	environment  string
+	force        bool
+	approve      func(Approval) error
+	failing      failing
+	failure      *Notification
+	runTimeout   time.Duration
+	deadline     time.Time
+	namespace    string
+	locker       Lock
+	windows      []window
+	canary       string
+	canaryBudget time.Duration
+	backup       func(ctx context.Context, s Step) (string, error)
+	recovery     bool
+}
+
+// newOptions returns the run configuration with opts applied.
+func newOptions(opts []Option) *options {
+	o := &options{
+		logger: log.New(os.Stderr, "", 0),
+		table:  "versions",
+		ctx:    context.Background(),
+		run:    &Run{},
+		clock:  SystemClock,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if o.set != "" && !o.tableSet {
+		o.table = o.set + "_versions"
+	}
+
+	return o
+}
+
+// A Logger records messages about a migration run. *log.Logger
+// satisfies the interface.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// WithLogger sets the logger of the run. By default, messages are
+// written to standard error.
+func WithLogger(l Logger) Option {
+	return func(o *options) {
+		o.logger = l
+	}
+}
+
+// WithContext sets the context of the run. Cancelling the context rolls
+// back the migration in progress, unless it has already committed, and
+// stops the run before the next migration. The after hooks still run.
+func WithContext(ctx context.Context) Option {
+	return func(o *options) {
+		o.ctx = ctx
+	}
+}
+
+// WithSlowThreshold logs a warning each time d elapses while a migration
+// is still running, including the statement currently executing for
+// SQL migrations.
+func WithSlowThreshold(d time.Duration) Option {
+	return func(o *options) {
+		o.slow = d
+	}
+}
+
+// WithEcho logs every statement executed by SQL migrations, helpers and
+// migrations using Exec, with its arguments unless redact is true, to
+// help diagnose migrations that behave differently between environments.
+func WithEcho(redact bool) Option {
+	return func(o *options) {
+		o.echo = true
+		o.redact = redact
+	}
+}
+
+// WithTimings sets fn to receive the elapsed time of each statement
+// executed by SQL migrations, helpers and migrations using Exec as it
+// completes, so slow steps of a multi-statement migration can be found.
+func WithTimings(fn func(Timing)) Option {
+	return func(o *options) {
+		o.timing = fn
+	}
+}
+
+// formatArgs returns the arguments of an echoed statement for logging.
+func (o *options) formatArgs(args []interface{}) string {
+	if len(args) == 0 {
+		return ""
+	}
+
+	if o.redact {
+		return fmt.Sprintf(" [%d redacted]", len(args))
+	}
+
+	return fmt.Sprintf(" %v", args)
+}
+
+// WithTable sets the name of the table that records the applied
+// migrations. The default table is versions. The name is interpolated
+// into queries and must be a trusted identifier.
+func WithTable(name string) Option {
+	return func(o *options) {
+		o.table = name
+		o.tableSet = true
+	}
+}
+
+// query returns the query of the versions table formatted with the table
+// name of the run and its parameters rebound for the dialect of the run.
+func (o *options) query(q string) string {
+	return o.dialect.rebind(fmt.Sprintf(q, o.table))
+}
+
+// WithTags restricts the run to migrations labeled with at least one of
+// the provided tags. Unselected migrations remain pending and are
+// performed by a later run that selects them.
+func WithTags(tags ...string) Option {
+	return func(o *options) {
+		o.tags = append(o.tags, tags...)
+	}
+}
+
+// WithoutTags excludes migrations labeled with any of the provided tags
+// from the run. Excluded migrations remain pending and are performed by
+// a later run that does not exclude them.
+func WithoutTags(tags ...string) Option {
+	return func(o *options) {
+		o.without = append(o.without, tags...)
+	}
+}
+
+// WithExclude bypasses the provided version timestamps during the run.
+// Excluded versions are logged and remain pending, or applied when
+// migrating down, so a later run that does not exclude them performs
+// them. See WithRecordExcluded to record them as skipped instead.
+func WithExclude(versions ...string) Option {
+	return func(o *options) {
+		o.exclude = append(o.exclude, versions...)
+	}
+}
+
+// WithRecordExcluded records the versions excluded by WithExclude as
+// skipped when migrating up, so that they are reported by Status and
+// later runs do not attempt them. Migrate down past an excluded version
+// to make it pending again.
+func WithRecordExcluded() Option {
+	return func(o *options) {
+		o.skipExcluded = true
+	}
+}
+
+// WithOnly restricts the run to the provided version timestamps within
+// the planned range, for surgical operations such as during incident
+// recovery. The other versions remain pending, or applied when migrating
+// down.
+func WithOnly(versions ...string) Option {
+	return func(o *options) {
+		o.only = append(o.only, versions...)
+	}
+}
+
+// excluded returns true if the version timestamp is bypassed by the run.
+func (o *options) excluded(version string) bool {
+	return contains(o.exclude, version)
+}
+
+// WithProgress sets fn to receive the progress reported by migrations
+// during the run. Migrations report progress with ReportProgress, and
+// Backfill reports its progress automatically.
+func WithProgress(fn func(Progress)) Option {
+	return func(o *options) {
+		o.progress = fn
+	}
+}
+
+// WithDialect sets the dialect of the database, which determines the
+// queries of the versions table and the statements of migrations
+// registered by helpers such as RegisterIndex. The default dialect is
+// Postgres.
+func WithDialect(d Dialect) Option {
+	return func(o *options) {
+		o.dialect = d
+	}
+}
+
+// WithPreflight sets fn to receive the warnings for the statements of
+// pending SQL migrations that lock or rewrite tables before any migration
+// is performed. If fn returns an error, the run is aborted with it. By
+// default, the warnings are printed and the run proceeds.
+func WithPreflight(fn func([]Warning) error) Option {
+	return func(o *options) {
+		o.preflight = fn
+	}
+}
+
+// WithConfirm sets a function that is called with the steps of the run
+// after it is planned and before any migration is performed. Returning
+// an error aborts the run, such as when an operator declines to revert
+// migrations or destroy data.
+func WithConfirm(fn func([]Step) error) Option {
+	return func(o *options) {
+		o.confirm = fn
+	}
+}
+
+// selected returns true if the migration of the version timestamp is
+// selected by the run.
+func (o *options) selected(version string) bool {
+	if len(o.only) > 0 && !contains(o.only, version) {
+		return false
+	}
+
+	m := migrations[version]
+	if len(o.tags) > 0 && !m.hasTag(o.tags) {
+		return false
+	}
+
+	if len(o.phases) > 0 && !m.inPhase(o.phases) {
+		return false
+	}
+
+	return !m.hasTag(o.without)
+}
+
+// contains returns true if the slice contains the string.
+func contains(ss []string, s string) bool {
+	for _, v := range ss {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
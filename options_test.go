@@ -0,0 +1,197 @@
+package migrator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSelectedTags(t *testing.T) {
+	isolate(t)
+
+	tests := []struct {
+		name string
+		tags []string
+		opts []Option
+		want bool
+	}{
+		{"no options", []string{"slow"}, nil, true},
+		{"untagged without options", nil, nil, true},
+		{"with matching tag", []string{"slow", "data"}, []Option{WithTags("data")}, true},
+		{"with other tag", []string{"slow"}, []Option{WithTags("data")}, false},
+		{"with tags untagged", nil, []Option{WithTags("data")}, false},
+		{"without matching tag", []string{"slow"}, []Option{WithoutTags("slow")}, false},
+		{"without other tag", []string{"slow"}, []Option{WithoutTags("data")}, true},
+		{"with and without", []string{"data", "slow"}, []Option{WithTags("data"), WithoutTags("slow")}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &migration{}
+			Tags(tt.tags...)(m)
+			migrations["20240101T000000Z"] = m
+			got := newOptions(tt.opts).selected("20240101T000000Z")
+			if got != tt.want {
+				t.Errorf("selected(%q) = %t, want %t", tt.tags, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExcluded(t *testing.T) {
+	o := newOptions([]Option{WithExclude("20240101T000000Z"), WithExclude("20240103T000000Z")})
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		{"20240101T000000Z", true},
+		{"20240102T000000Z", false},
+		{"20240103T000000Z", true},
+	}
+
+	for _, tt := range tests {
+		got := o.excluded(tt.version)
+		if got != tt.want {
+			t.Errorf("excluded(%q) = %t, want %t", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestWithExclude(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{
+		"20240101T000000Z": "users",
+		"20240102T000000Z": "posts",
+		"20240103T000000Z": "tags",
+	})
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+	err := Migrate(db, "", sqlite, WithExclude("20240102T000000Z"), WithLogger(&testLogger{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, pending, err := Check(db, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if state != Pending || strings.Join(pending, ",") != "20240102T000000Z" {
+		t.Errorf("Check = %v %q, want the excluded version pending", state, pending)
+	}
+
+	err = Migrate(db, "", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !tableExists(t, db, "posts") {
+		t.Error("excluded version not performed by a later run")
+	}
+}
+
+func TestWithRecordExcluded(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{
+		"20240101T000000Z": "users",
+		"20240102T000000Z": "posts",
+	})
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+	err := Migrate(db, "", sqlite, WithExclude("20240101T000000Z"), WithRecordExcluded(), WithLogger(&testLogger{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	infos, err := Inspect(db, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(infos) != 3 || !infos[1].Applied || infos[1].SkipReason != "excluded" {
+		t.Errorf("status = %+v, want the excluded version skipped", infos)
+	}
+
+	err = Migrate(db, "", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if tableExists(t, db, "users") {
+		t.Error("version recorded as excluded performed by a later run")
+	}
+}
+
+func TestWithOnly(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{
+		"20240101T000000Z": "users",
+		"20240102T000000Z": "posts",
+		"20240103T000000Z": "tags",
+	})
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+	err := Migrate(db, "", sqlite, WithOnly(NilVersion, "20240102T000000Z"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for table, want := range map[string]bool{"users": false, "posts": true, "tags": false} {
+		if tableExists(t, db, table) != want {
+			t.Errorf("table %s exists = %t, want %t", table, !want, want)
+		}
+	}
+
+	state, pending, err := Check(db, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if state != Pending || strings.Join(pending, ",") != "20240101T000000Z,20240103T000000Z" {
+		t.Errorf("Check = %v %q, want the other versions pending", state, pending)
+	}
+}
+
+func TestWithTable(t *testing.T) {
+	isolate(t)
+	Register("20240101T000000Z", "custom_table", empty, empty)
+
+	db := openTestDB(t)
+	_, err := db.Exec(strings.Replace(queryTestVersionsNew, "versions", "schema_versions", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	o := newOptions([]Option{WithTable("schema_versions")})
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = migrate(tx, "20240101T000000Z", true, nil, o)
+	if err != nil {
+		tx.Rollback()
+		t.Fatal(err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := currentVersion(db, o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if current != "20240101T000000Z" {
+		t.Errorf("current version = %q, want 20240101T000000Z recorded in schema_versions", current)
+	}
+
+	_, err = versions(db, newOptions(nil))
+	if err == nil {
+		t.Error("versions table queried without WithTable exists")
+	}
+}
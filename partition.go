@@ -0,0 +1,103 @@
+package migrator
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// A PartitionInterval is the range of time covered by each partition of
+// a table partitioned by time.
+type PartitionInterval int
+
+// Partition intervals. Weekly partitions begin on Monday.
+const (
+	PartitionDaily PartitionInterval = iota
+	PartitionWeekly
+	PartitionMonthly
+)
+
+// start returns the beginning of the partition containing t in UTC.
+func (p PartitionInterval) start(t time.Time) time.Time {
+	t = t.UTC()
+	y, m, d := t.Date()
+	switch p {
+	case PartitionWeekly:
+		return time.Date(y, m, d-(int(t.Weekday())+6)%7, 0, 0, 0, 0, time.UTC)
+	case PartitionMonthly:
+		return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// next returns the beginning of the partition after the one beginning
+// at t.
+func (p PartitionInterval) next(t time.Time) time.Time {
+	switch p {
+	case PartitionWeekly:
+		return t.AddDate(0, 0, 7)
+	case PartitionMonthly:
+		return t.AddDate(0, 1, 0)
+	}
+
+	return t.AddDate(0, 0, 1)
+}
+
+// suffix returns the name suffix of the partition beginning at t.
+func (p PartitionInterval) suffix(t time.Time) string {
+	if p == PartitionMonthly {
+		return t.Format("p200601")
+	}
+
+	return t.Format("p20060102")
+}
+
+// queryPartitionNew creates a partition of a Postgres table partitioned
+// by range if not already created.
+var queryPartitionNew = `
+CREATE TABLE IF NOT EXISTS %s
+  PARTITION OF %s
+  FOR VALUES FROM (%s) TO (%s);
+`
+
+// CreatePartition creates the partition of the Postgres parent table
+// covering the range [from, to) named <parent>_<suffix> if it does not
+// already exist. The parent is interpolated into the statement and must
+// be a trusted identifier.
+func CreatePartition(tx *sql.Tx, parent, suffix string, from, to time.Time) error {
+	const layout = "2006-01-02 15:04:05Z07:00"
+	_, err := tx.Exec(fmt.Sprintf(queryPartitionNew, parent+"_"+suffix, parent,
+		quote(from.Format(layout)), quote(to.Format(layout))))
+	return err
+}
+
+// EnsurePartitions creates the partitions of the Postgres parent table
+// for the interval containing now and the n intervals after it that do
+// not already exist.
+func EnsurePartitions(tx *sql.Tx, parent string, interval PartitionInterval, n int, now time.Time) error {
+	from := interval.start(now)
+	for i := 0; i <= n; i++ {
+		to := interval.next(from)
+		err := CreatePartition(tx, parent, interval.suffix(from), from, to)
+		if err != nil {
+			return err
+		}
+
+		from = to
+	}
+
+	return nil
+}
+
+// RegisterPartitions makes a repeatable migration available that ensures
+// the partitions of the Postgres parent table for the current interval,
+// by the clock of the run, and the next n intervals exist. It is
+// performed at the end of every run that migrates up, so partitions are
+// maintained by the same pipeline as the schema. If RegisterPartitions
+// is called twice for the same parent, it panics.
+func RegisterPartitions(parent string, interval PartitionInterval, n int) {
+	registerRepeatable("ensure_partitions_"+parent, func(tx *sql.Tx) error {
+		return EnsurePartitions(tx, parent, interval, n, optionsOf(tx).now())
+	}, "")
+}
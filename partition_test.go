@@ -0,0 +1,66 @@
+package migrator
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPartitionInterval(t *testing.T) {
+	// Thursday in local time, but Wednesday 2024-01-17 22:30 in UTC.
+	now := time.Date(2024, 1, 18, 0, 30, 0, 0, time.FixedZone("", 2*60*60))
+
+	tests := []struct {
+		interval PartitionInterval
+		start    time.Time
+		next     time.Time
+		suffix   string
+	}{
+		{PartitionDaily, time.Date(2024, 1, 17, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 18, 0, 0, 0, 0, time.UTC), "p20240117"},
+		{PartitionWeekly, time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 22, 0, 0, 0, 0, time.UTC), "p20240115"},
+		{PartitionMonthly, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), "p202401"},
+	}
+
+	for _, tt := range tests {
+		start := tt.interval.start(now)
+		if !start.Equal(tt.start) {
+			t.Errorf("%d: start = %v, want %v", tt.interval, start, tt.start)
+		}
+
+		next := tt.interval.next(start)
+		if !next.Equal(tt.next) {
+			t.Errorf("%d: next = %v, want %v", tt.interval, next, tt.next)
+		}
+
+		suffix := tt.interval.suffix(start)
+		if suffix != tt.suffix {
+			t.Errorf("%d: suffix = %q, want %q", tt.interval, suffix, tt.suffix)
+		}
+	}
+}
+
+func TestPartitionWeeklySunday(t *testing.T) {
+	sunday := time.Date(2024, 1, 21, 12, 0, 0, 0, time.UTC)
+	want := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	start := PartitionWeekly.start(sunday)
+	if !start.Equal(want) {
+		t.Errorf("start(%v) = %v, want Monday %v", sunday, start, want)
+	}
+}
+
+func TestRegisterPartitions(t *testing.T) {
+	isolateRepeatables(t)
+
+	RegisterPartitions("events", PartitionDaily, 7)
+	if _, ok := repeatables["ensure_partitions_events"]; !ok {
+		t.Fatal("partition maintenance not registered as a repeatable")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("RegisterPartitions did not panic for the same parent")
+		}
+	}()
+
+	RegisterPartitions("events", PartitionMonthly, 1)
+}
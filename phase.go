@@ -0,0 +1,62 @@
+package migrator
+
+import "database/sql"
+
+// A Phase is the stage of a deployment in which a migration runs.
+type Phase int
+
+// Phases of a deployment. Expanding changes that the new code depends on
+// run before the new code ships, and contracting changes that the old
+// code depends on run after the old code is gone.
+const (
+	PhasePre  Phase = iota // before the new code ships, the default
+	PhasePost              // after the new code ships
+)
+
+// String returns the name of the phase.
+func (p Phase) String() string {
+	switch p {
+	case PhasePre:
+		return "pre-deploy"
+	case PhasePost:
+		return "post-deploy"
+	}
+
+	return "unknown"
+}
+
+// InPhase declares the phase of the deployment in which the migration
+// runs. Migrations run in PhasePre unless declared otherwise.
+func InPhase(p Phase) MigrationOption {
+	return func(m *migration) {
+		m.phase = p
+	}
+}
+
+// WithPhase restricts the run to migrations declared in any of the
+// provided phases. Migrations in other phases remain pending and are
+// performed by a later run that selects their phase.
+func WithPhase(phases ...Phase) Option {
+	return func(o *options) {
+		o.phases = append(o.phases, phases...)
+	}
+}
+
+// MigratePhase performs the migrations of the phase up to the latest
+// version, such as the backward compatible changes before the new code
+// rolls out with PhasePre and the cleanup after it has with PhasePost.
+// Migrations of the other phase remain pending.
+func MigratePhase(db *sql.DB, p Phase, opts ...Option) error {
+	return Migrate(db, "", append(opts[:len(opts):len(opts)], WithPhase(p))...)
+}
+
+// inPhase returns true if the migration is declared in any of phases.
+func (m *migration) inPhase(phases []Phase) bool {
+	for _, p := range phases {
+		if m.phase == p {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,90 @@
+package migrator
+
+import "testing"
+
+func TestSelectedPhase(t *testing.T) {
+	isolate(t)
+
+	tests := []struct {
+		name  string
+		phase []MigrationOption
+		opts  []Option
+		want  bool
+	}{
+		{"default without phases", nil, nil, true},
+		{"post without phases", []MigrationOption{InPhase(PhasePost)}, nil, true},
+		{"default in pre", nil, []Option{WithPhase(PhasePre)}, true},
+		{"default in post", nil, []Option{WithPhase(PhasePost)}, false},
+		{"post in post", []MigrationOption{InPhase(PhasePost)}, []Option{WithPhase(PhasePost)}, true},
+		{"post in pre", []MigrationOption{InPhase(PhasePost)}, []Option{WithPhase(PhasePre)}, false},
+		{"post in both", []MigrationOption{InPhase(PhasePost)}, []Option{WithPhase(PhasePre, PhasePost)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &migration{}
+			for _, opt := range tt.phase {
+				opt(m)
+			}
+
+			migrations["20240101T000000Z"] = m
+			got := newOptions(tt.opts).selected("20240101T000000Z")
+			if got != tt.want {
+				t.Errorf("selected in %s = %t, want %t", m.phase, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPhaseString(t *testing.T) {
+	tests := []struct {
+		p    Phase
+		want string
+	}{
+		{PhasePre, "pre-deploy"},
+		{PhasePost, "post-deploy"},
+		{Phase(-1), "unknown"},
+	}
+
+	for _, tt := range tests {
+		got := tt.p.String()
+		if got != tt.want {
+			t.Errorf("Phase(%d).String() = %q, want %q", int(tt.p), got, tt.want)
+		}
+	}
+}
+
+func TestMigratePhase(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+	registerSQL("20240102T000000Z", "create_posts", []string{"CREATE TABLE posts (id INTEGER);"}, []string{"DROP TABLE posts;"}, false, []MigrationOption{InPhase(PhasePost)})
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+	err := MigratePhase(db, PhasePre, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !tableExists(t, db, "users") || tableExists(t, db, "posts") {
+		t.Fatal("pre phase did not migrate only the pre-deploy migrations")
+	}
+
+	infos, err := Inspect(db, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if infos[2].Phase != "post-deploy" || infos[2].Applied {
+		t.Errorf("Inspect = %+v, want the post-deploy migration pending", infos[2])
+	}
+
+	err = MigratePhase(db, PhasePost, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !tableExists(t, db, "posts") {
+		t.Error("post phase did not migrate the post-deploy migration")
+	}
+}
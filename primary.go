@@ -0,0 +1,100 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// primaryQueries are the queries by dialect that return true if the
+// database accepts writes. Postgres standbys are in recovery, and MySQL
+// replicas, including Aurora readers, are read only.
+var primaryQueries = map[Dialect]string{
+	Postgres: `SELECT NOT pg_is_in_recovery() AND current_setting('transaction_read_only') = 'off';`,
+	MySQL:    `SELECT @@global.read_only = 0 AND @@global.innodb_read_only = 0;`,
+	SQLite:   `SELECT NOT query_only FROM pragma_query_only;`,
+}
+
+// WithPrimary verifies that the database is the writable primary before
+// the run starts, failing the run against a read replica rather than
+// letting it fail part way or wait on a lock no writer will release.
+func WithPrimary() Option {
+	return func(o *options) {
+		o.primary = true
+	}
+}
+
+// IsPrimary returns true if the database of the dialect accepts writes.
+func IsPrimary(ctx context.Context, db *sql.DB, d Dialect) (bool, error) {
+	q, ok := primaryQueries[d]
+	if !ok {
+		return false, fmt.Errorf("migrator: primary detection is not supported by %s", d)
+	}
+
+	var rv bool
+	err := db.QueryRowContext(ctx, q).Scan(&rv)
+	if err != nil {
+		return false, err
+	}
+
+	return rv, nil
+}
+
+// ResolvePrimary opens the databases at the URLs in order and returns
+// the first that accepts writes, such as the writer among the regional
+// endpoints of a cluster. The other databases are closed. See Open for
+// the supported URLs.
+func ResolvePrimary(ctx context.Context, rawurls ...string) (*sql.DB, Dialect, error) {
+	var errs []string
+	for _, rawurl := range rawurls {
+		db, d, err := Open(rawurl)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		ok, err := IsPrimary(ctx, db, d)
+		if err == nil && ok {
+			return db, d, nil
+		}
+
+		db.Close()
+		if err == nil {
+			err = fmt.Errorf("read only")
+		}
+
+		errs = append(errs, fmt.Sprintf("%s: %v", redacted(rawurl), err))
+	}
+
+	return nil, 0, fmt.Errorf("migrator: no writable primary: %s", strings.Join(errs, "; "))
+}
+
+// redacted returns the URL with its password redacted for messages.
+func redacted(rawurl string) string {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return "invalid url"
+	}
+
+	return u.Redacted()
+}
+
+// checkPrimary returns an error if the run requires the primary with
+// WithPrimary and the database does not accept writes.
+func (o *options) checkPrimary(db *sql.DB) error {
+	if !o.primary {
+		return nil
+	}
+
+	ok, err := IsPrimary(o.ctx, db, o.dialect)
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return fmt.Errorf("migrator: database is a read-only replica, not the primary")
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package migrator
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIsPrimary(t *testing.T) {
+	ctx := context.Background()
+	db := openTestDB(t)
+	db.SetMaxOpenConns(1)
+
+	ok, err := IsPrimary(ctx, db, SQLite)
+	if err != nil || !ok {
+		t.Fatalf("IsPrimary = %t, %v, want true", ok, err)
+	}
+
+	_, err = db.Exec("PRAGMA query_only = 1;")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok, err = IsPrimary(ctx, db, SQLite)
+	if err != nil || ok {
+		t.Errorf("IsPrimary of a read-only database = %t, %v, want false", ok, err)
+	}
+
+	err = Migrate(db, "", WithDialect(SQLite), WithPrimary())
+	if err == nil || !strings.Contains(err.Error(), "read-only replica") {
+		t.Errorf("Migrate of a read-only database error = %v, want not the primary", err)
+	}
+
+	_, err = IsPrimary(ctx, db, Dialect(-1))
+	if err == nil {
+		t.Error("IsPrimary of an unknown dialect error = nil")
+	}
+}
+
+func TestResolvePrimary(t *testing.T) {
+	dir := t.TempDir()
+	replica := "sqlite://" + filepath.Join(dir, "replica.db") + "?_query_only=1"
+	primary := "sqlite://" + filepath.Join(dir, "primary.db")
+
+	db, d, err := ResolvePrimary(context.Background(), replica, primary)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer db.Close()
+
+	var file string
+	err = db.QueryRow("SELECT file FROM pragma_database_list WHERE name = 'main';").Scan(&file)
+	if err != nil || d != SQLite || filepath.Base(file) != "primary.db" {
+		t.Errorf("ResolvePrimary = %s %s, %v, want primary.db", d, file, err)
+	}
+
+	_, _, err = ResolvePrimary(context.Background(), replica)
+	if err == nil || !strings.Contains(err.Error(), "no writable primary: ") || !strings.Contains(err.Error(), "read only") {
+		t.Errorf("ResolvePrimary of only a replica error = %v", err)
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	have := redacted("postgres://user:secret@db/app")
+	if have != "postgres://user:xxxxx@db/app" {
+		t.Errorf("redacted = %q", have)
+	}
+}
@@ -0,0 +1,90 @@
+package migrator
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EnvironmentVariable is the environment variable that names the
+// environment of the database when WithEnvironment is not set.
+const EnvironmentVariable = "MIGRATOR_ENV"
+
+// queriesEnvironment are the queries by dialect that select the
+// environment recorded in the database. Postgres records it as the
+// custom setting migrator.environment, such as with:
+//
+//	ALTER DATABASE app SET migrator.environment = 'production';
+var queriesEnvironment = map[Dialect]string{
+	Postgres: `SELECT COALESCE(current_setting('migrator.environment', true), '');`,
+}
+
+// production are the environments in which down migrations are refused
+// unless the run is forced.
+var production = map[string]bool{
+	"production": true,
+	"prod":       true,
+}
+
+// WithEnvironment sets the name of the environment of the database. In
+// the production environment, runs that revert migrations are refused
+// unless WithForce is set. Without it, the environment is read from the
+// MIGRATOR_ENV environment variable and then, for Postgres, from the
+// migrator.environment setting of the database.
+func WithEnvironment(name string) Option {
+	return func(o *options) {
+		o.environment = name
+	}
+}
+
+// WithForce allows the run to revert migrations in the production
+// environment.
+func WithForce() Option {
+	return func(o *options) {
+		o.force = true
+	}
+}
+
+// environmentOf returns the environment of the database of the run.
+func (o *options) environmentOf(conn *sql.Conn) (string, error) {
+	if o.environment != "" {
+		return o.environment, nil
+	}
+
+	if v := os.Getenv(EnvironmentVariable); v != "" {
+		return v, nil
+	}
+
+	q, ok := queriesEnvironment[o.dialect]
+	if !ok {
+		return "", nil
+	}
+
+	var rv string
+	err := conn.QueryRowContext(o.ctx, q).Scan(&rv)
+	if err != nil {
+		return "", err
+	}
+
+	return rv, nil
+}
+
+// guardProduction returns an error if the run reverts migrations in the
+// production environment and is not forced.
+func (o *options) guardProduction(conn *sql.Conn, vs []string, up bool) error {
+	if up || len(vs) == 0 || o.force {
+		return nil
+	}
+
+	env, err := o.environmentOf(conn)
+	if err != nil {
+		return err
+	}
+
+	if production[strings.ToLower(env)] {
+		return fmt.Errorf("migrator: refusing to revert migrations in the %s environment without WithForce", env)
+	}
+
+	return nil
+}
@@ -0,0 +1,52 @@
+package migrator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGuardProduction(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+	t.Setenv(EnvironmentVariable, "")
+
+	tests := []struct {
+		name string
+		env  string
+		opts []Option
+		want bool
+	}{
+		{"development", "", []Option{WithEnvironment("development")}, true},
+		{"unnamed", "", nil, true},
+		{"production", "", []Option{WithEnvironment("production")}, false},
+		{"prod variable", "PROD", nil, false},
+		{"option over variable", "production", []Option{WithEnvironment("staging")}, true},
+		{"forced", "", []Option{WithEnvironment("production"), WithForce()}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(EnvironmentVariable, tt.env)
+
+			db := openTestDB(t)
+			opts := append([]Option{WithDialect(SQLite)}, tt.opts...)
+			err := Migrate(db, "", opts...)
+			if err != nil {
+				t.Fatalf("up error = %v, want up migrations allowed", err)
+			}
+
+			err = Migrate(db, NilVersion, opts...)
+			if tt.want && err != nil {
+				t.Errorf("down error = %v, want allowed", err)
+			}
+
+			if !tt.want && (err == nil || !strings.Contains(err.Error(), "refusing to revert")) {
+				t.Errorf("down error = %v, want refused", err)
+			}
+
+			if !tt.want && !tableExists(t, db, "users") {
+				t.Error("refused run reverted the migration")
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package migrator
+
+// Progress is a report of the work done by an executing migration.
+type Progress struct {
+	Version string // version timestamp of the migration, empty for repeatables
+	Name    string // name of the migration or repeatable
+	Done    int64  // units of work done, such as rows processed
+	Total   int64  // units of work in total, or zero if unknown
+}
+
+// Percent returns the percentage of the work done, or -1 if the total is
+// unknown.
+func (p Progress) Percent() float64 {
+	if p.Total <= 0 {
+		return -1
+	}
+
+	return float64(p.Done) / float64(p.Total) * 100
+}
+
+// ReportProgress reports the progress of the migration executing on the
+// handle, which is the *sql.Tx or *sql.Conn provided to the migration.
+// The progress is delivered to the function set by WithProgress. It is
+// a no-op if the run has no progress function or if the handle does not
+// belong to an executing migration.
+func ReportProgress(handle interface{}, done, total int64) {
+	e := lookup(handle)
+	if e == nil || e.o.progress == nil {
+		return
+	}
+
+	e.o.progress(Progress{
+		Version: e.version,
+		Name:    e.name,
+		Done:    done,
+		Total:   total,
+	})
+}
@@ -0,0 +1,81 @@
+package migrator
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+)
+
+func TestProgressPercent(t *testing.T) {
+	tests := []struct {
+		p    Progress
+		want float64
+	}{
+		{Progress{Done: 0, Total: 4}, 0},
+		{Progress{Done: 1, Total: 4}, 25},
+		{Progress{Done: 4, Total: 4}, 100},
+		{Progress{Done: 4}, -1},
+	}
+
+	for _, tt := range tests {
+		got := tt.p.Percent()
+		if got != tt.want {
+			t.Errorf("Percent(%d/%d) = %v, want %v", tt.p.Done, tt.p.Total, got, tt.want)
+		}
+	}
+}
+
+func TestReportProgress(t *testing.T) {
+	isolate(t)
+	Register("20240101T000000Z", "report", empty, empty)
+
+	var got []Progress
+	o := newOptions([]Option{WithProgress(func(p Progress) { got = append(got, p) })})
+
+	handle := new(sql.Tx)
+	ReportProgress(handle, 1, 2)
+
+	untrack := track(handle, "20240101T000000Z", o)
+	ReportProgress(handle, 1, 2)
+	untrack()
+	ReportProgress(handle, 2, 2)
+
+	want := []Progress{{Version: "20240101T000000Z", Name: "report", Done: 1, Total: 2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("progress = %+v, want %+v only while tracked", got, want)
+	}
+}
+
+func TestBackfillProgress(t *testing.T) {
+	isolate(t)
+
+	db := backfillTestDB(t, 10)
+	_, err := db.Exec(queryTestVersionsNew)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	up := func(conn *sql.Conn) error {
+		return Backfill(conn, "items", "id", 4, func(tx *sql.Tx, lo, hi int64) error { return nil })
+	}
+
+	RegisterNoTransaction("20240101T000000Z", "backfill", up, func(conn *sql.Conn) error { return nil })
+
+	var done []int64
+	o := newOptions([]Option{WithProgress(func(p Progress) {
+		if p.Total != 10 {
+			t.Errorf("total = %d, want 10", p.Total)
+		}
+		done = append(done, p.Done)
+	})})
+
+	conn := testConn(t, db)
+	err = migrateConn(conn, "20240101T000000Z", true, nil, o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(done, []int64{4, 8, 10}) {
+		t.Errorf("done = %v, want 4, 8, 10", done)
+	}
+}
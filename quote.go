@@ -0,0 +1,13 @@
+package migrator
+
+import "strings"
+
+// quote returns s as a SQL string literal.
+func quote(s string) string {
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// ident returns s as a quoted SQL identifier.
+func ident(s string) string {
+	return `"` + strings.Replace(s, `"`, `""`, -1) + `"`
+}
@@ -0,0 +1,38 @@
+package migrator
+
+import "testing"
+
+func TestQuote(t *testing.T) {
+	db := openTestDB(t)
+
+	tests := []string{"", "active", "it's", "''", "a;b"}
+	for _, s := range tests {
+		var got string
+		err := db.QueryRow("SELECT " + quote(s)).Scan(&got)
+		if err != nil {
+			t.Fatalf("SELECT %s: %v", quote(s), err)
+		}
+
+		if got != s {
+			t.Errorf("SELECT %s = %q, want %q", quote(s), got, s)
+		}
+	}
+}
+
+func TestIdent(t *testing.T) {
+	db := openTestDB(t)
+
+	tests := []string{"users", "User Accounts", `a"b`, "select"}
+	for _, s := range tests {
+		_, err := db.Exec("CREATE TABLE " + ident(s) + " (id INTEGER);")
+		if err != nil {
+			t.Fatalf("CREATE TABLE %s: %v", ident(s), err)
+		}
+
+		var got string
+		err = db.QueryRow("SELECT name FROM sqlite_master WHERE type = 'table' AND name = ?;", s).Scan(&got)
+		if err != nil {
+			t.Errorf("table %s not created as %q: %v", ident(s), s, err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package migrator
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// A RecoveryPoint marks the position of the database before a run
+// performed its first migration, for point-in-time recovery to just
+// before the run.
+type RecoveryPoint struct {
+	Name     string `json:"name"`     // name of the restore point, if one was created
+	Position string `json:"position"` // WAL location on Postgres or executed GTID set on MySQL
+	Version  string `json:"version"`  // version timestamp of the first migration of the run
+}
+
+// queriesRecoveryPoint are the queries by dialect that record a recovery
+// point and return its position. The Postgres query creates a named
+// restore point for recovery_target_name and requires the privilege to
+// call pg_create_restore_point.
+var queriesRecoveryPoint = map[Dialect]string{
+	Postgres: `SELECT pg_create_restore_point($1)::text;`,
+	MySQL:    `SELECT @@global.gtid_executed;`,
+}
+
+// WithRecoveryPoint records a recovery point before the run performs its
+// first migration, aborting the run if it cannot. On Postgres, a restore
+// point named migrator_<version> after the first migration is created.
+// On MySQL, the executed GTID set is recorded. The recovery point is
+// logged and recorded in the RecoveryPoint of the run.
+func WithRecoveryPoint() Option {
+	return func(o *options) {
+		o.recovery = true
+	}
+}
+
+// markRecoveryPoint records a recovery point on the connection if the run
+// has WithRecoveryPoint set and performs migrations.
+func (o *options) markRecoveryPoint(conn *sql.Conn, vs []string) error {
+	if !o.recovery || len(vs) == 0 {
+		return nil
+	}
+
+	q, ok := queriesRecoveryPoint[o.dialect]
+	if !ok {
+		return fmt.Errorf("migrator: recovery points are not supported by %s", o.dialect)
+	}
+
+	rp := &RecoveryPoint{Version: vs[0]}
+	var args []interface{}
+	if o.dialect == Postgres {
+		rp.Name = "migrator_" + vs[0]
+		args = append(args, rp.Name)
+	}
+
+	err := conn.QueryRowContext(o.ctx, q, args...).Scan(&rp.Position)
+	if err != nil {
+		return fmt.Errorf("migrator: recording recovery point: %w", err)
+	}
+
+	o.run.RecoveryPoint = rp
+	o.logf(LevelInfo, "recorded recovery point %q at %s before %q", rp.Name, rp.Position, rp.Version)
+	return nil
+}
@@ -0,0 +1,46 @@
+package migrator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWithRecoveryPoint(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+
+	db := openTestDB(t)
+	err := Migrate(db, NilVersion, WithDialect(SQLite))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Migrate(db, "", WithDialect(SQLite), WithRecoveryPoint())
+	if err == nil || !strings.Contains(err.Error(), "recovery points are not supported") {
+		t.Errorf("Migrate error = %v, want recovery points unsupported", err)
+	}
+
+	if tableExists(t, db, "users") {
+		t.Error("migration performed without a recovery point")
+	}
+
+	queriesRecoveryPoint[SQLite] = `SELECT '0/16B3748';`
+	defer delete(queriesRecoveryPoint, SQLite)
+
+	o := newOptions([]Option{WithDialect(SQLite), WithRecoveryPoint()})
+	err = migrateRun(db, "", o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rp := o.run.RecoveryPoint
+	if rp == nil || rp.Position != "0/16B3748" || rp.Version != "20240101T000000Z" || rp.Name != "" {
+		t.Errorf("recovery point = %+v, want the position before 20240101T000000Z", rp)
+	}
+
+	o = newOptions([]Option{WithDialect(SQLite), WithRecoveryPoint()})
+	err = migrateRun(db, "", o)
+	if err != nil || o.run.RecoveryPoint != nil {
+		t.Errorf("up to date run = %+v, %v, want no recovery point", o.run.RecoveryPoint, err)
+	}
+}
@@ -0,0 +1,115 @@
+package migrator
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// A ColumnRename is a column to rename without downtime. The identifiers
+// are interpolated into statements and must be trusted.
+type ColumnRename struct {
+	Table     string // table containing the column
+	From      string // current name of the column
+	To        string // new name of the column
+	Type      string // SQL type of the column
+	Key       string // integer key column to backfill by, "id" if empty
+	BatchSize int64  // keys per backfill batch, 10000 if zero
+}
+
+// queryRenameTrigger creates the Postgres trigger function and trigger
+// that keep the old and new columns of a rename in sync while both old
+// and new code write to the table.
+var queryRenameTrigger = `
+CREATE OR REPLACE FUNCTION %[4]s() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'INSERT' THEN
+    IF NEW.%[3]s IS NULL THEN
+      NEW.%[3]s := NEW.%[2]s;
+    ELSIF NEW.%[2]s IS NULL THEN
+      NEW.%[2]s := NEW.%[3]s;
+    END IF;
+  ELSIF NEW.%[3]s IS DISTINCT FROM OLD.%[3]s THEN
+    NEW.%[2]s := NEW.%[3]s;
+  ELSE
+    NEW.%[3]s := NEW.%[2]s;
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+DROP TRIGGER IF EXISTS %[4]s ON %[1]s;
+CREATE TRIGGER %[4]s BEFORE INSERT OR UPDATE ON %[1]s
+  FOR EACH ROW EXECUTE PROCEDURE %[4]s();
+`
+
+// queryRenameTriggerDrop drops the trigger and trigger function of a
+// rename.
+var queryRenameTriggerDrop = `
+DROP TRIGGER IF EXISTS %[2]s ON %[1]s;
+DROP FUNCTION IF EXISTS %[2]s();
+`
+
+// RegisterColumnRename makes the sequence of migrations that renames a
+// Postgres column without downtime available at the four provided
+// versions, in place of a single RENAME that breaks the running code:
+//
+//  1. rename_<table>_<from>_expand adds the new column and a trigger that
+//     copies writes to either column into the other.
+//  2. rename_<table>_<from>_backfill copies the old column into the new
+//     column in batches outside of a transaction.
+//  3. rename_<table>_<from>_swap drops the trigger once the code reads
+//     and writes only the new column. It runs in PhasePost.
+//  4. rename_<table>_<from>_contract drops the old column. It runs in
+//     PhasePost.
+//
+// The options apply to every migration. If the number of versions is
+// not four or a version is already registered, it panics.
+func RegisterColumnRename(versions []string, r ColumnRename, opts ...MigrationOption) {
+	if len(versions) != 4 {
+		panic("migrator: RegisterColumnRename requires four versions")
+	}
+
+	key, size := batchDefaults(r.Key, r.BatchSize)
+	name := fmt.Sprintf("rename_%s_%s_", r.Table, r.From)
+	trigger := fmt.Sprintf("%s_%s_to_%s", r.Table, r.From, r.To)
+	post := append(opts[:len(opts):len(opts)], InPhase(PhasePost))
+
+	createTrigger := fmt.Sprintf(queryRenameTrigger, r.Table, r.From, r.To, trigger)
+	dropTrigger := fmt.Sprintf(queryRenameTriggerDrop, r.Table, trigger)
+
+	registerSQL(versions[0], name+"expand",
+		[]string{
+			fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", r.Table, r.To, r.Type),
+			createTrigger,
+		},
+		[]string{
+			dropTrigger,
+			fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", r.Table, r.To),
+		},
+		false, opts)
+
+	update := fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s >= $1 AND %s < $2 AND %s IS DISTINCT FROM %s;",
+		r.Table, r.To, r.From, key, key, r.To, r.From)
+	RegisterNoTransaction(versions[1], name+"backfill",
+		func(conn *sql.Conn) error {
+			return Backfill(conn, r.Table, key, size, func(tx *sql.Tx, lo, hi int64) error {
+				_, err := tx.Exec(update, lo, hi)
+				return err
+			})
+		},
+		func(conn *sql.Conn) error {
+			return nil
+		},
+		opts...)
+
+	registerSQL(versions[2], name+"swap", []string{dropTrigger}, []string{createTrigger}, false, post)
+
+	registerSQL(versions[3], name+"contract",
+		[]string{
+			fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s;", r.Table, r.From),
+		},
+		[]string{
+			fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s;", r.Table, r.From, r.Type),
+			fmt.Sprintf("UPDATE %s SET %s = %s;", r.Table, r.From, r.To),
+		},
+		false, post)
+}
@@ -0,0 +1,50 @@
+package migrator
+
+import "testing"
+
+func TestRegisterColumnRename(t *testing.T) {
+	isolate(t)
+
+	versions := []string{"20240101T000000Z", "20240102T000000Z", "20240103T000000Z", "20240104T000000Z"}
+	r := ColumnRename{Table: "users", From: "login", To: "username", Type: "TEXT"}
+	RegisterColumnRename(versions, r, Tags("rename"))
+
+	tests := []struct {
+		version string
+		name    string
+		phase   Phase
+		noTx    bool
+	}{
+		{versions[0], "rename_users_login_expand", PhasePre, false},
+		{versions[1], "rename_users_login_backfill", PhasePre, true},
+		{versions[2], "rename_users_login_swap", PhasePost, false},
+		{versions[3], "rename_users_login_contract", PhasePost, false},
+	}
+
+	for _, tt := range tests {
+		m := migrations[tt.version]
+		if m == nil {
+			t.Fatalf("version %s not registered", tt.version)
+		}
+
+		if m.name != tt.name || m.phase != tt.phase || (m.upConn != nil) != tt.noTx {
+			t.Errorf("version %s = %s in %s, want %s in %s", tt.version, m.name, m.phase, tt.name, tt.phase)
+		}
+
+		if !m.hasTag([]string{"rename"}) {
+			t.Errorf("version %s is not tagged with the options", tt.version)
+		}
+	}
+}
+
+func TestRegisterColumnRenameVersions(t *testing.T) {
+	isolate(t)
+
+	defer func() {
+		if recover() == nil {
+			t.Error("RegisterColumnRename with three versions did not panic")
+		}
+	}()
+
+	RegisterColumnRename([]string{"20240101T000000Z", "20240102T000000Z", "20240103T000000Z"}, ColumnRename{})
+}
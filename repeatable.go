@@ -0,0 +1,189 @@
+package migrator
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"sort"
+)
+
+// A repeatable is a named migrationFunc that is performed at the end of
+// every run that migrates up, after the versioned migrations. Repeatables
+// with a checksum are only performed when the checksum differs from the
+// checksum recorded when they were last performed.
+type repeatable struct {
+	name     string
+	fn       migrationFunc
+	checksum string
+	source   string
+}
+
+// repeatables is a map of repeatable keyed by name.
+var repeatables = make(map[string]*repeatable)
+
+// queryRepeatablesNew creates the repeatables table if not already
+// created.
+var queryRepeatablesNew = `
+CREATE TABLE IF NOT EXISTS repeatables (
+  name       TEXT PRIMARY KEY,
+  checksum   TEXT NOT NULL,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+`
+
+// queryRepeatablesNewMySQL creates the repeatables table in MySQL if not
+// already created.
+var queryRepeatablesNewMySQL = `
+CREATE TABLE IF NOT EXISTS repeatables (
+  name       VARCHAR(255) PRIMARY KEY,
+  checksum   VARCHAR(64) NOT NULL,
+  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+`
+
+// queryRepeatablesChecksum selects the checksum of a repeatable when it
+// was last performed.
+var queryRepeatablesChecksum = `
+SELECT checksum
+  FROM repeatables
+  WHERE name = $1;
+`
+
+// queryRepeatablesSave inserts or updates the checksum of a repeatable.
+var queryRepeatablesSave = `
+INSERT INTO repeatables (name, checksum)
+  VALUES ($1, $2)
+  ON CONFLICT (name) DO UPDATE
+  SET checksum = EXCLUDED.checksum, updated_at = CURRENT_TIMESTAMP;
+`
+
+// queryRepeatablesSaveMySQL inserts or updates the checksum of a
+// repeatable in MySQL.
+var queryRepeatablesSaveMySQL = `
+INSERT INTO repeatables (name, checksum)
+  VALUES (?, ?)
+  ON DUPLICATE KEY UPDATE
+  checksum = VALUES(checksum), updated_at = CURRENT_TIMESTAMP;
+`
+
+// RegisterRepeatable makes a repeatable migration available by name that
+// executes the SQL, such as CREATE OR REPLACE VIEW or FUNCTION statements,
+// at the end of a run that migrates up whenever the checksum of the SQL
+// has changed since it was last executed. This keeps views, functions and
+// triggers in version-controlled files without a new versioned migration
+// for every edit. If RegisterRepeatable is called twice with the same
+// name, it panics.
+func RegisterRepeatable(name, query string) {
+	r := sqlRepeatable(name, query)
+	registerRepeatable(name, r.fn, r.checksum)
+}
+
+// sqlRepeatable returns a repeatable that executes the SQL whenever its
+// checksum changes.
+func sqlRepeatable(name, query string) *repeatable {
+	fn := func(tx *sql.Tx) error {
+		return execStatements(tx, []string{query})
+	}
+
+	return &repeatable{name: name, fn: fn, checksum: checksum(query)}
+}
+
+// registerRepeatable makes a repeatable available by name. If it is
+// called twice with the same name, it panics.
+func registerRepeatable(name string, fn migrationFunc, checksum string) {
+	if _, ok := repeatables[name]; ok {
+		panic("migrator: repeatable registered twice for " + name)
+	}
+
+	repeatables[name] = &repeatable{name: name, fn: fn, checksum: checksum}
+}
+
+// checksum returns the hex encoded SHA-256 checksum of the SQL.
+func checksum(query string) string {
+	sum := sha256.Sum256([]byte(query))
+	return hex.EncodeToString(sum[:])
+}
+
+// repeat performs the repeatables in order of name, each in its own
+// transaction.
+func repeat(conn Conn, o *options) error {
+	if len(repeatables) == 0 {
+		return nil
+	}
+
+	query := queryRepeatablesNew
+	if o.dialect == MySQL {
+		query = queryRepeatablesNewMySQL
+	}
+
+	_, err := conn.ExecContext(o.ctx, query)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for name := range repeatables {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		tx, err := conn.BeginTx(o.ctx, nil)
+		if err != nil {
+			return err
+		}
+
+		untrack := trackRepeatable(tx, name, o)
+		err = repeatables[name].perform(tx, o.dialect)
+		untrack()
+		if err != nil {
+			o.logf(LevelError, "error repeating %q: %v", name, err)
+			rollbackErr := tx.Rollback()
+			if rollbackErr != nil {
+				err = errors.Join(err, rollbackErr)
+			}
+			return &MigrationError{Name: name, Up: true, Err: err}
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			return &MigrationError{Name: name, Up: true, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// perform performs the repeatable within the transaction if it has no
+// checksum or its checksum has changed, recording the new checksum with
+// the queries of the dialect.
+func (r *repeatable) perform(tx *sql.Tx, d Dialect) error {
+	if r.checksum == "" {
+		return r.fn(tx)
+	}
+
+	var last string
+	err := tx.QueryRow(d.rebind(queryRepeatablesChecksum), r.name).Scan(&last)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	if last == r.checksum {
+		return nil
+	}
+
+	err = r.fn(tx)
+	if err != nil {
+		return err
+	}
+
+	query := queryRepeatablesSave
+	if d == MySQL {
+		query = queryRepeatablesSaveMySQL
+	}
+
+	_, err = tx.Exec(d.rebind(query), r.name, r.checksum)
+	return err
+}
@@ -0,0 +1,182 @@
+package migrator
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// isolateRepeatables removes the registered repeatables until the test
+// completes.
+func isolateRepeatables(t *testing.T) {
+	t.Helper()
+
+	saved := repeatables
+	repeatables = make(map[string]*repeatable)
+	t.Cleanup(func() { repeatables = saved })
+}
+
+func TestRepeat(t *testing.T) {
+	isolateRepeatables(t)
+
+	var calls []string
+	for _, name := range []string{"b", "a", "c"} {
+		name := name
+		registerRepeatable(name, func(tx *sql.Tx) error {
+			calls = append(calls, name)
+			_, err := tx.Exec("INSERT INTO calls (name) VALUES (?);", name)
+			return err
+		}, "")
+	}
+
+	db := openTestDB(t)
+	_, err := db.Exec("CREATE TABLE calls (name TEXT);")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = repeat(db, newOptions(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(calls, []string{"a", "b", "c"}) {
+		t.Errorf("calls = %q, want in order of name", calls)
+	}
+
+	var n int
+	err = db.QueryRow("SELECT COUNT(*) FROM calls;").Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 3 {
+		t.Errorf("%d calls committed, want 3", n)
+	}
+}
+
+func TestRepeatError(t *testing.T) {
+	isolateRepeatables(t)
+
+	fail := errors.New("fail")
+	registerRepeatable("a", func(tx *sql.Tx) error {
+		_, err := tx.Exec("INSERT INTO calls (name) VALUES ('a');")
+		if err != nil {
+			return err
+		}
+		return fail
+	}, "")
+
+	registerRepeatable("b", func(tx *sql.Tx) error {
+		t.Error("repeatable performed after a failure")
+		return nil
+	}, "")
+
+	db := openTestDB(t)
+	_, err := db.Exec("CREATE TABLE calls (name TEXT);")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = repeat(db, newOptions([]Option{WithLogger(&testLogger{})}))
+	if !errors.Is(err, fail) || err.Error() != "migrator: repeatable a: fail" {
+		t.Fatalf("repeat error = %v, want the MigrationError wrapping %v", err, fail)
+	}
+
+	var n int
+	err = db.QueryRow("SELECT COUNT(*) FROM calls;").Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 0 {
+		t.Errorf("%d calls committed, want the failed repeatable rolled back", n)
+	}
+}
+
+func TestRegisterRepeatableTwice(t *testing.T) {
+	isolateRepeatables(t)
+
+	registerRepeatable("a", empty, "")
+	defer func() {
+		if recover() == nil {
+			t.Error("registerRepeatable did not panic for the same name")
+		}
+	}()
+
+	registerRepeatable("a", empty, "")
+}
+
+func TestRegisterRepeatable(t *testing.T) {
+	isolateRepeatables(t)
+
+	db := openTestDB(t)
+	view := func() string {
+		var def string
+		err := db.QueryRow("SELECT sql FROM sqlite_master WHERE type = 'view' AND name = 'active_users';").Scan(&def)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return def
+	}
+
+	_, err := db.Exec("CREATE TABLE users (id INTEGER, active BOOLEAN);")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	RegisterRepeatable("active_users", "CREATE VIEW active_users AS SELECT id FROM users WHERE active;")
+	err = repeat(db, newOptions(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The view exists, so the statement fails if it is executed again
+	// while its checksum is unchanged.
+	err = repeat(db, newOptions(nil))
+	if err != nil {
+		t.Fatalf("unchanged repeatable performed again: %v", err)
+	}
+
+	repeatables = make(map[string]*repeatable)
+	RegisterRepeatable("active_users", "DROP VIEW active_users; CREATE VIEW active_users AS SELECT id, active FROM users WHERE active;")
+	err = repeat(db, newOptions(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have := view(); have != "CREATE VIEW active_users AS SELECT id, active FROM users WHERE active" {
+		t.Errorf("view = %q, want the changed repeatable performed", have)
+	}
+
+	var n int
+	err = db.QueryRow("SELECT COUNT(*) FROM repeatables;").Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 1 {
+		t.Errorf("%d checksums recorded, want 1", n)
+	}
+}
+
+func TestRepeatProgress(t *testing.T) {
+	isolateRepeatables(t)
+
+	registerRepeatable("report", func(tx *sql.Tx) error {
+		ReportProgress(tx, 1, 2)
+		return nil
+	}, "")
+
+	var have []Progress
+	err := repeat(openTestDB(t), newOptions([]Option{WithProgress(func(p Progress) { have = append(have, p) })}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Progress{{Name: "report", Done: 1, Total: 2}}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("progress = %+v, want %+v", have, want)
+	}
+}
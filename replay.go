@@ -0,0 +1,48 @@
+package migrator
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Replay migrates a temporary database created on the server of the url
+// from nothing to the current version of the database and returns the
+// differences from the schema the migrations produce to the schema of
+// the database, such as "column accounts.email changed ...". Differences
+// mean that applied migrations were edited or that the database was
+// changed outside of migrations. The temporary database is dropped
+// afterwards.
+func Replay(db *sql.DB, url string, opts ...Option) ([]string, error) {
+	o := newOptions(opts)
+
+	actual, err := Snapshot(db, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	var rv []string
+	err = o.scratch(url, "migrator_replay_", func(scratch *sql.DB, so *options) error {
+		if actual.Version != "" {
+			err := migrateDB(scratch, actual.Version, so)
+			if err != nil {
+				return fmt.Errorf("migrator: replay to %s: %w", actual.Version, err)
+			}
+		}
+
+		replayed, err := snapshot(scratch, so)
+		if err != nil {
+			return err
+		}
+
+		if replayed.Fingerprint != actual.Fingerprint {
+			rv = replayed.Diff(actual)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rv, nil
+}
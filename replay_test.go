@@ -0,0 +1,38 @@
+package migrator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReplay(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+	err := Migrate(db, "", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err := Replay(db, "sqlite://replay", sqlite)
+	if err != nil || len(diff) != 0 {
+		t.Fatalf("Replay of the migrated history = %q, %v", diff, err)
+	}
+
+	_, err = db.Exec("ALTER TABLE users ADD COLUMN email TEXT;")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diff, err = Replay(db, "sqlite://replay", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"column users.email added"}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("Replay after the change = %q, want %q", diff, want)
+	}
+}
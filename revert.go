@@ -0,0 +1,76 @@
+package migrator
+
+import (
+	"database/sql"
+	"time"
+)
+
+// queryVersionsHistory selects every recorded migration, including those
+// that were reverted, in the order they were applied.
+var queryVersionsHistory = `
+SELECT version, name, skip_reason, checksum, created_at, reverted_at
+  FROM %[1]s
+  ORDER BY id ASC;
+`
+
+// WithSoftDelete marks the versions the run reverts as reverted at the
+// time of the clock of the run rather than deleting them, preserving the
+// evidence that they were once applied. Reapplying a reverted version
+// records it again. See History.
+func WithSoftDelete() Option {
+	return func(o *options) {
+		o.softDelete = true
+	}
+}
+
+// unrecord removes the version timestamp from the applied versions,
+// marking it reverted if the run soft deletes versions.
+func (o *options) unrecord(e Execer, version string) error {
+	if o.softDelete {
+		_, err := e.ExecContext(o.ctx, o.query(queryVersionsRevert), o.now().UTC(), version)
+		return err
+	}
+
+	_, err := e.ExecContext(o.ctx, o.query(queryVersionsDelete), version)
+	return err
+}
+
+// History returns every migration recorded in the versions table in the
+// order they were applied, including the migrations that were reverted
+// by runs with WithSoftDelete, whose RevertedAt is set.
+func History(db *sql.DB, opts ...Option) ([]*Info, error) {
+	o := newOptions(opts)
+
+	var rv []*Info
+	rows, err := db.QueryContext(o.ctx, o.query(queryVersionsHistory))
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var appliedAt time.Time
+		var revertedAt sql.NullTime
+		info := &Info{}
+		err := rows.Scan(&info.Version, &info.Name, &info.SkipReason, &info.Checksum, &appliedAt, &revertedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		info.Applied = !revertedAt.Valid
+		info.AppliedAt = &appliedAt
+		if revertedAt.Valid {
+			info.RevertedAt = &revertedAt.Time
+		}
+
+		rv = append(rv, info)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return rv, err
+	}
+
+	return rv, nil
+}
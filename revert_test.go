@@ -0,0 +1,86 @@
+package migrator
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithSoftDelete(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+
+	db := openTestDB(t)
+	now := time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+	opts := []Option{WithDialect(SQLite), WithSoftDelete(), WithClock(fixedClock(now))}
+	err := Migrate(db, "", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Migrate(db, NilVersion, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v, err := Current(db, opts...)
+	if err != nil || v != NilVersion {
+		t.Errorf("Current = %q, %v, want the reverted version excluded", v, err)
+	}
+
+	err = Migrate(db, "", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	infos, err := History(db, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(infos) != 3 {
+		t.Fatalf("History returned %d migrations, want 3", len(infos))
+	}
+
+	reverted := infos[1]
+	if reverted.Version != "20240101T000000Z" || reverted.Applied || reverted.RevertedAt == nil || !reverted.RevertedAt.Equal(now) {
+		t.Errorf("History[1] = %+v, want the reverted migration", reverted)
+	}
+
+	if infos[2].Version != "20240101T000000Z" || !infos[2].Applied || infos[2].RevertedAt != nil {
+		t.Errorf("History[2] = %+v, want the reapplied migration", infos[2])
+	}
+
+	applied, err := Applied(db, opts...)
+	if err != nil || len(applied) != 2 {
+		t.Errorf("Applied returned %d migrations, %v, want 2", len(applied), err)
+	}
+}
+
+func TestAddColumns(t *testing.T) {
+	isolate(t)
+
+	// The versions table of releases before reverted_at was added.
+	db := openTestDB(t)
+	_, err := db.Exec(strings.Replace(queryTestVersionsNew, ",\n  reverted_at TIMESTAMP NULL", "", 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = Migrate(db, "", WithDialect(SQLite), WithDownSQL())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, col := range []string{"reverted_at", "down_sql"} {
+		var n int
+		err = db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('versions') WHERE name = ?;", col).Scan(&n)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if n != 1 {
+			t.Errorf("versions table of an earlier release missing the %s column", col)
+		}
+	}
+}
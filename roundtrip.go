@@ -0,0 +1,97 @@
+package migrator
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Roundtrip verifies that the down migration of each pending migration,
+// except those registered as Irreversible, reverses its up migration.
+// Each pending migration is applied, tested if it has a Test, reverted
+// and applied again while the schema before it is compared to the schema
+// after it is reverted, so the database should be a scratch database.
+// The tables of the library, such as the versions and repeatables
+// tables, are not compared. The error names the first migration whose
+// test fails or whose down migration leaves the schema changed, with the
+// differences.
+func Roundtrip(db *sql.DB, opts ...Option) error {
+	o := newOptions(opts)
+
+	current, err := Current(db, opts...)
+	if err != nil {
+		return err
+	}
+
+	prev := current
+	if prev == "" {
+		prev = NilVersion
+	}
+
+	for _, v := range o.sorted() {
+		if v <= prev {
+			continue
+		}
+
+		before, err := snapshot(db, o)
+		if err != nil {
+			return err
+		}
+
+		err = Migrate(db, v, opts...)
+		if err != nil {
+			return fmt.Errorf("migrator: roundtrip %s: up: %w", v, err)
+		}
+
+		err = migrations[v].runTest(db, o)
+		if err != nil {
+			return fmt.Errorf("migrator: roundtrip %s: test: %w", v, err)
+		}
+
+		if migrations[v].irreversible {
+			prev = v
+			continue
+		}
+
+		err = Migrate(db, prev, opts...)
+		if err != nil {
+			return fmt.Errorf("migrator: roundtrip %s: down: %w", v, err)
+		}
+
+		after, err := snapshot(db, o)
+		if err != nil {
+			return err
+		}
+
+		diff := before.Diff(after)
+		if len(diff) > 0 {
+			return fmt.Errorf("migrator: roundtrip %s: down does not reverse up:\n\t%s", v, strings.Join(diff, "\n\t"))
+		}
+
+		err = Migrate(db, v, opts...)
+		if err != nil {
+			return fmt.Errorf("migrator: roundtrip %s: up again: %w", v, err)
+		}
+
+		prev = v
+	}
+
+	return nil
+}
+
+// runTest runs the test of the migration, if any, in a transaction that
+// is rolled back.
+func (m *migration) runTest(db *sql.DB, o *options) error {
+	if m.test == nil {
+		return nil
+	}
+
+	tx, err := db.BeginTx(o.ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer tx.Rollback()
+
+	return m.test(tx)
+}
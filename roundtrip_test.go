@@ -0,0 +1,133 @@
+package migrator
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+func TestRoundtrip(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+	registerSQL("20240102T000000Z", "add_email", []string{"ALTER TABLE users ADD COLUMN email TEXT;"}, []string{"ALTER TABLE users DROP COLUMN email;"}, false, nil)
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+	err := Roundtrip(db, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current, err := Current(db, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if current != "20240102T000000Z" {
+		t.Errorf("version after roundtrip = %q, want 20240102T000000Z", current)
+	}
+}
+
+func TestRoundtripIrreversible(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+	registerSQL("20240102T000000Z", "add_email", []string{"ALTER TABLE users ADD COLUMN email TEXT;"}, []string{"SELECT 1;"}, false, nil)
+	registerTables(t, map[string]string{"20240103T000000Z": "posts"})
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+	err := Roundtrip(db, sqlite)
+	if err == nil {
+		t.Fatal("Roundtrip error = nil")
+	}
+
+	want := "migrator: roundtrip 20240102T000000Z: down does not reverse up:\n\tcolumn users.email added"
+	if err.Error() != want {
+		t.Errorf("Roundtrip error = %q, want %q", err, want)
+	}
+
+	if tableExists(t, db, "posts") {
+		t.Error("Roundtrip continued after the irreversible migration")
+	}
+}
+
+func TestRoundtripTest(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+
+	var ran bool
+	Register("20240102T000000Z", "check", empty, empty, Test(func(tx *sql.Tx) error {
+		ran = true
+		_, err := tx.Exec("INSERT INTO users (id) VALUES (1);")
+		return err
+	}))
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+	err := Roundtrip(db, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !ran {
+		t.Error("Roundtrip did not run the test")
+	}
+
+	var n int
+	err = db.QueryRow("SELECT COUNT(*) FROM users;").Scan(&n)
+	if err != nil || n != 0 {
+		t.Errorf("users after the test = %d, %v, want the test rolled back", n, err)
+	}
+}
+
+func TestRoundtripTestError(t *testing.T) {
+	isolate(t)
+	Register("20240101T000000Z", "check", empty, empty, Test(func(tx *sql.Tx) error {
+		return errors.New("not backfilled")
+	}))
+
+	db := openTestDB(t)
+	err := Roundtrip(db, WithDialect(SQLite))
+	want := "migrator: roundtrip 20240101T000000Z: test: not backfilled"
+	if err == nil || err.Error() != want {
+		t.Errorf("Roundtrip error = %v, want %q", err, want)
+	}
+}
+
+func TestRoundtripRepeatable(t *testing.T) {
+	isolate(t)
+	isolateRepeatables(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+	registerSQL("20240102T000000Z", "add_email", []string{"ALTER TABLE users ADD COLUMN email TEXT;"}, []string{"ALTER TABLE users DROP COLUMN email;"}, false, nil)
+	RegisterRepeatable("user_ids", "DROP VIEW IF EXISTS user_ids; CREATE VIEW user_ids AS SELECT id FROM users;")
+
+	db := openTestDB(t)
+	err := Roundtrip(db, WithDialect(SQLite))
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRoundtripTestRepeatable(t *testing.T) {
+	isolate(t)
+	isolateRepeatables(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+	RegisterRepeatable("user_ids", "DROP VIEW IF EXISTS user_ids; CREATE VIEW user_ids AS SELECT id FROM users;")
+
+	var ran bool
+	registerSQL("20240102T000000Z", "add_email", []string{"ALTER TABLE users ADD COLUMN email TEXT;"}, []string{"ALTER TABLE users DROP COLUMN email;"}, false, []MigrationOption{Test(func(tx *sql.Tx) error {
+		ran = true
+		_, err := tx.Exec("SELECT id FROM user_ids;")
+		return err
+	})})
+
+	db := openTestDB(t)
+	err := Roundtrip(db, WithDialect(SQLite))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !ran {
+		t.Error("Roundtrip did not run the test")
+	}
+}
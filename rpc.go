@@ -0,0 +1,81 @@
+package migrator
+
+import (
+	"database/sql"
+	"net"
+	"net/rpc"
+	"net/rpc/jsonrpc"
+)
+
+// A Service exposes the migrations of a database over JSON-RPC so a
+// deployment controller can drive the migrations of many services
+// uniformly. Its methods are registered under the name "Migrator".
+type Service struct {
+	db   *sql.DB
+	opts []Option
+}
+
+// Args are the arguments of the methods of a Service.
+type Args struct {
+	Target string // target version timestamp, empty for the latest
+}
+
+// NewService returns a service for the database. The options apply to
+// every call.
+func NewService(db *sql.DB, opts ...Option) *Service {
+	return &Service{db: db, opts: opts}
+}
+
+// Status replies with the state of the registered migrations.
+func (s *Service) Status(args Args, reply *[]*Info) error {
+	infos, err := Inspect(s.db, s.opts...)
+	if err != nil {
+		return err
+	}
+
+	*reply = infos
+	return nil
+}
+
+// Plan replies with the steps that migrating to the target would perform.
+func (s *Service) Plan(args Args, reply *[]Step) error {
+	steps, err := Plan(s.db, args.Target, s.opts...)
+	if err != nil {
+		return err
+	}
+
+	*reply = steps
+	return nil
+}
+
+// Migrate migrates to the target and replies with the run.
+func (s *Service) Migrate(args Args, reply *Run) error {
+	return Migrate(s.db, args.Target, append(s.opts[:len(s.opts):len(s.opts)], WithRun(reply))...)
+}
+
+// ForceUnlock releases the lock held by another session.
+func (s *Service) ForceUnlock(args Args, reply *bool) error {
+	err := ForceUnlock(s.db, s.opts...)
+	*reply = err == nil
+	return err
+}
+
+// Serve accepts connections on the listener and serves the service for
+// the database over JSON-RPC on each. It returns when the listener
+// fails, such as when it is closed.
+func Serve(l net.Listener, db *sql.DB, opts ...Option) error {
+	srv := rpc.NewServer()
+	err := srv.RegisterName("Migrator", NewService(db, opts...))
+	if err != nil {
+		return err
+	}
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+
+		go srv.ServeCodec(jsonrpc.NewServerCodec(conn))
+	}
+}
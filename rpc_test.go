@@ -0,0 +1,66 @@
+package migrator
+
+import (
+	"net"
+	"net/rpc/jsonrpc"
+	"reflect"
+	"testing"
+)
+
+func TestServe(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer l.Close()
+
+	db := openTestDB(t)
+	go Serve(l, db, WithDialect(SQLite))
+
+	c, err := jsonrpc.Dial("tcp", l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer c.Close()
+
+	var steps []Step
+	err = c.Call("Migrator.Plan", Args{}, &steps)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(steps) != 2 || steps[1].Version != "20240101T000000Z" || !steps[1].Up {
+		t.Errorf("plan = %+v, want the nil and users migrations up", steps)
+	}
+
+	var run Run
+	err = c.Call("Migrator.Migrate", Args{Target: "20240101T000000Z"}, &run)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(run.Performed, []string{NilVersion, "20240101T000000Z"}) || run.Target != "20240101T000000Z" {
+		t.Errorf("run = %+v, want both migrations performed", run)
+	}
+
+	var infos []*Info
+	err = c.Call("Migrator.Status", Args{}, &infos)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(infos) != 2 || !infos[1].Applied {
+		t.Errorf("status = %+v, want the users migration applied", infos)
+	}
+
+	var unlocked bool
+	err = c.Call("Migrator.ForceUnlock", Args{}, &unlocked)
+	if err != nil || !unlocked {
+		t.Errorf("force unlock = %t, %v", unlocked, err)
+	}
+}
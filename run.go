@@ -0,0 +1,164 @@
+package migrator
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// A Run is the outcome of a call to Migrate.
+type Run struct {
+	Target        string            `json:"target"`                   // target version timestamp, empty for the latest
+	Started       time.Time         `json:"started"`                  // time the run started
+	Finished      time.Time         `json:"finished"`                 // time the run finished
+	Performed     []string          `json:"performed,omitempty"`      // version timestamps performed in order
+	Deferred      []string          `json:"deferred,omitempty"`       // version timestamps deferred outside of their window
+	Failed        string            `json:"failed,omitempty"`         // version timestamp that failed, if any
+	Backups       map[string]string `json:"backups,omitempty"`        // locations of the backups taken by version timestamp
+	RecoveryPoint *RecoveryPoint    `json:"recovery_point,omitempty"` // recovery point recorded before the first migration, if any
+	Error         string            `json:"error,omitempty"`          // error of the run, if any
+}
+
+// last is the most recently finished run of the process.
+var last struct {
+	sync.Mutex
+	run *Run
+}
+
+// LastRun returns the most recently finished run of the process, or nil
+// if no run has finished.
+func LastRun() *Run {
+	last.Lock()
+	defer last.Unlock()
+	if last.run == nil {
+		return nil
+	}
+
+	r := *last.run
+	return &r
+}
+
+// WithRun stores the outcome of the run in r once it finishes, so that
+// concurrent callers each receive their own run rather than the last run
+// of the process. MigrateMany and MigrateAll ignore it, returning the
+// run of each database in their results instead.
+func WithRun(r *Run) Option {
+	return func(o *options) {
+		o.result = r
+	}
+}
+
+// finish records the run as finished at the time with the error.
+func (r *Run) finish(now time.Time, err error) {
+	r.Finished = now
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	last.Lock()
+	last.run = r
+	last.Unlock()
+}
+
+// migrating notifies that the version timestamp is about to be performed
+// in the direction of the run and returns the time it began.
+func (o *options) migrating(version string, up bool) time.Time {
+	atomic.StoreInt64(&o.rows, 0)
+	o.failing = failing{}
+	o.emit(MigrationStarted{Version: version, Name: migrations[version].name, Up: up})
+	o.notify(Notification{
+		Kind:    NotifyMigrating,
+		Version: version,
+		Name:    migrations[version].name,
+		Up:      up,
+	})
+
+	return o.now()
+}
+
+// migrated records the version timestamp as performed in the direction
+// of the run since the time it began.
+func (o *options) migrated(version string, up bool, began time.Time) {
+	elapsed, rows := o.since(began), atomic.LoadInt64(&o.rows)
+	o.run.Performed = append(o.run.Performed, version)
+	o.emit(MigrationApplied{
+		Version: version,
+		Name:    migrations[version].name,
+		Up:      up,
+		Elapsed: elapsed,
+		Rows:    rows,
+	})
+	o.notify(Notification{
+		Kind:    NotifyMigrated,
+		Version: version,
+		Name:    migrations[version].name,
+		Up:      up,
+		Elapsed: elapsed,
+		Rows:    rows,
+	})
+}
+
+// A MigrationError is the error of a migration or repeatable that failed
+// during a run. Err is the error of the migration, such as the error
+// returned by the driver for the statement that failed, joined with the
+// error of the rollback if the transaction could not be rolled back.
+type MigrationError struct {
+	Version string // version timestamp of the migration, empty for repeatables
+	Name    string // name of the migration or repeatable
+	Up      bool   // whether or not the migration was performed up
+	Err     error  // error of the migration
+}
+
+// Error returns the direction, version and name of the migration followed
+// by its error, such as migrator: up 20240101T000000Z add_users: ..., or
+// the name of the repeatable, such as migrator: repeatable views: ...
+func (e *MigrationError) Error() string {
+	msg := strings.TrimPrefix(e.Err.Error(), "migrator: ")
+	if e.Version == "" {
+		return fmt.Sprintf("migrator: repeatable %s: %s", e.Name, msg)
+	}
+
+	direction := "up"
+	if !e.Up {
+		direction = "down"
+	}
+
+	return fmt.Sprintf("migrator: %s %s %s: %s", direction, e.Version, e.Name, msg)
+}
+
+// Unwrap returns the error of the migration.
+func (e *MigrationError) Unwrap() error {
+	return e.Err
+}
+
+// A failing is the most recent statement executed by the migration in
+// progress and the error it returned, if any.
+type failing struct {
+	statement string
+	err       error
+}
+
+// failed records the version timestamp as failed in the direction of the
+// run since the time it began with the error, and the notification of the
+// failure with the statement that failed, if known.
+func (o *options) failed(version string, up bool, began time.Time, err error) {
+	o.logf(LevelError, "error migrating %q: %v", version, err)
+	o.run.Failed = version
+	o.emit(MigrationFailed{Version: version, Name: migrations[version].name, Up: up, Err: err})
+
+	o.failure = &Notification{
+		Kind:    NotifyFailed,
+		Version: version,
+		Name:    migrations[version].name,
+		Up:      up,
+		Elapsed: o.since(began),
+		Rows:    atomic.LoadInt64(&o.rows),
+		Error:   err.Error(),
+	}
+	if o.failing.err != nil {
+		o.failure.Statement = o.failing.statement
+		o.failure.Error = o.failing.err.Error()
+	}
+}
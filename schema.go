@@ -0,0 +1,373 @@
+package migrator
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// queriesTables select the names of the tables of the current schema or
+// database by dialect.
+var queriesTables = map[Dialect]string{
+	Postgres: `SELECT tablename FROM pg_tables WHERE schemaname = current_schema() ORDER BY tablename;`,
+	MySQL:    `SELECT table_name FROM information_schema.tables WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE' ORDER BY table_name;`,
+	SQLite:   `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name;`,
+}
+
+// Tables returns the names of the tables of the current schema, or
+// database in MySQL and SQLite, in order of name.
+func Tables(conn Conn, d Dialect) ([]string, error) {
+	var rv []string
+	rows, err := conn.QueryContext(optionsOf(conn).ctx, queriesTables[d])
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		err := rows.Scan(&name)
+		if err != nil {
+			return nil, err
+		}
+
+		rv = append(rv, name)
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return rv, err
+	}
+
+	return rv, nil
+}
+
+// A Schema describes the tables of a database. A snapshot of the schema
+// also records the version of the database and the fingerprint of the
+// tables.
+type Schema struct {
+	Version     string   `json:"version,omitempty"`
+	Fingerprint string   `json:"fingerprint,omitempty"`
+	Tables      []*Table `json:"tables"`
+}
+
+// A Table describes a table of a database.
+type Table struct {
+	Name        string        `json:"name"`
+	Comment     string        `json:"comment,omitempty"`
+	Columns     []*ColumnInfo `json:"columns"`
+	PrimaryKey  []string      `json:"primary_key,omitempty"`
+	Indexes     []string      `json:"indexes,omitempty"`
+	ForeignKeys []*ForeignKey `json:"foreign_keys,omitempty"`
+}
+
+// A ColumnInfo describes a column of a table.
+type ColumnInfo struct {
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+	Default  string `json:"default,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
+
+// A ForeignKey describes a foreign key constraint of a table.
+type ForeignKey struct {
+	Name       string   `json:"name"`
+	Columns    []string `json:"columns"`
+	Table      string   `json:"table"`
+	References []string `json:"references"`
+}
+
+// queriesComments select the name and comment of the tables of the
+// current schema or database by dialect. SQLite has no comments.
+var queriesComments = map[Dialect]string{
+	Postgres: `SELECT c.relname, COALESCE(obj_description(c.oid, 'pg_class'), '') FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace WHERE n.nspname = current_schema() AND c.relkind IN ('r', 'p');`,
+	MySQL:    `SELECT table_name, table_comment FROM information_schema.tables WHERE table_schema = DATABASE();`,
+}
+
+// queriesColumns select the table name, name, type, nullability, default
+// and comment of the columns of the current schema or database by
+// dialect. In SQLite, the columns are selected per table.
+var queriesColumns = map[Dialect]string{
+	Postgres: `SELECT c.relname, a.attname,
+  format_type(a.atttypid, a.atttypmod) || CASE a.attidentity WHEN 'a' THEN ' GENERATED ALWAYS AS IDENTITY' WHEN 'd' THEN ' GENERATED BY DEFAULT AS IDENTITY' ELSE '' END,
+  NOT a.attnotnull, COALESCE(pg_get_expr(d.adbin, d.adrelid), ''), COALESCE(col_description(c.oid, a.attnum), '')
+FROM pg_attribute a
+JOIN pg_class c ON c.oid = a.attrelid
+JOIN pg_namespace n ON n.oid = c.relnamespace
+LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+WHERE n.nspname = current_schema() AND c.relkind IN ('r', 'p') AND a.attnum > 0 AND NOT a.attisdropped
+ORDER BY c.relname, a.attnum;`,
+	MySQL:  `SELECT table_name, column_name, column_type, is_nullable = 'YES', COALESCE(column_default, ''), column_comment FROM information_schema.columns WHERE table_schema = DATABASE() ORDER BY table_name, ordinal_position;`,
+	SQLite: `SELECT ?, name, type, "notnull" = 0, COALESCE(dflt_value, ''), '' FROM pragma_table_info(?) ORDER BY cid;`,
+}
+
+// queriesPrimaryKeys select the table name and columns of the primary
+// keys of the current schema or database in order by dialect. In
+// SQLite, the primary keys are selected per table.
+var queriesPrimaryKeys = map[Dialect]string{
+	Postgres: `SELECT c.relname, a.attname FROM pg_index i JOIN pg_class c ON c.oid = i.indrelid JOIN pg_namespace n ON n.oid = c.relnamespace JOIN pg_attribute a ON a.attrelid = i.indrelid AND a.attnum = ANY(i.indkey) WHERE i.indisprimary AND n.nspname = current_schema() ORDER BY c.relname, array_position(i.indkey::int2[], a.attnum);`,
+	MySQL:    `SELECT table_name, column_name FROM information_schema.key_column_usage WHERE table_schema = DATABASE() AND constraint_name = 'PRIMARY' ORDER BY table_name, ordinal_position;`,
+	SQLite:   `SELECT ?, name FROM pragma_table_info(?) WHERE pk > 0 ORDER BY pk;`,
+}
+
+// queriesIndexes select the table name and definition of the indexes of
+// the current schema or database by dialect, except primary keys.
+var queriesIndexes = map[Dialect]string{
+	Postgres: `SELECT i.tablename, i.indexdef FROM pg_indexes i WHERE i.schemaname = current_schema() AND NOT EXISTS (SELECT 1 FROM pg_constraint c WHERE c.conname = i.indexname AND c.contype = 'p') ORDER BY i.tablename, i.indexname;`,
+	MySQL:    `SELECT table_name, CONCAT(IF(non_unique = 0, 'UNIQUE ', ''), 'INDEX ', index_name, ' (', GROUP_CONCAT(column_name ORDER BY seq_in_index), ')') FROM information_schema.statistics WHERE table_schema = DATABASE() AND index_name <> 'PRIMARY' GROUP BY table_name, index_name, non_unique ORDER BY table_name, index_name;`,
+	SQLite:   `SELECT tbl_name, COALESCE(sql, name) FROM sqlite_master WHERE type = 'index' ORDER BY tbl_name, name;`,
+}
+
+// queriesForeignKeys select the table name, constraint name, column,
+// referenced table and referenced column of each column of the foreign
+// keys of the current schema or database by dialect. In SQLite, the
+// foreign keys are selected per table.
+var queriesForeignKeys = map[Dialect]string{
+	Postgres: `SELECT cl.relname, c.conname, a.attname, rcl.relname, ra.attname
+FROM pg_constraint c
+JOIN pg_class cl ON cl.oid = c.conrelid
+JOIN pg_namespace n ON n.oid = cl.relnamespace
+JOIN pg_class rcl ON rcl.oid = c.confrelid
+CROSS JOIN LATERAL unnest(c.conkey, c.confkey) WITH ORDINALITY AS k(attnum, refnum, ord)
+JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum
+JOIN pg_attribute ra ON ra.attrelid = c.confrelid AND ra.attnum = k.refnum
+WHERE c.contype = 'f' AND n.nspname = current_schema()
+ORDER BY cl.relname, c.conname, k.ord;`,
+	MySQL:  `SELECT table_name, constraint_name, column_name, referenced_table_name, referenced_column_name FROM information_schema.key_column_usage WHERE table_schema = DATABASE() AND referenced_table_name IS NOT NULL ORDER BY table_name, constraint_name, ordinal_position;`,
+	SQLite: `SELECT ?, 'fk_' || id, "from", "table", COALESCE("to", '') FROM pragma_foreign_key_list(?) ORDER BY id, seq;`,
+}
+
+// Introspect returns the tables of the current schema, or database in
+// MySQL and SQLite, with their comments, columns, primary keys, indexes
+// and foreign keys.
+func Introspect(conn Conn, d Dialect) (*Schema, error) {
+	names, err := Tables(conn, d)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := &Schema{}
+	tables := make(map[string]*Table)
+	for _, name := range names {
+		t := &Table{Name: name}
+		tables[name] = t
+		rv.Tables = append(rv.Tables, t)
+	}
+
+	perTable := names
+	if d != SQLite {
+		perTable = nil
+	}
+
+	err = introspect(conn, queriesComments[d], nil, func(rows *sql.Rows) error {
+		var table, comment string
+		err := rows.Scan(&table, &comment)
+		if err != nil {
+			return err
+		}
+
+		if t, ok := tables[table]; ok {
+			t.Comment = comment
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = introspect(conn, queriesColumns[d], perTable, func(rows *sql.Rows) error {
+		var table string
+		c := &ColumnInfo{}
+		err := rows.Scan(&table, &c.Name, &c.Type, &c.Nullable, &c.Default, &c.Comment)
+		if err != nil {
+			return err
+		}
+
+		if t, ok := tables[table]; ok {
+			t.Columns = append(t.Columns, c)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = introspect(conn, queriesPrimaryKeys[d], perTable, func(rows *sql.Rows) error {
+		var table, column string
+		err := rows.Scan(&table, &column)
+		if err != nil {
+			return err
+		}
+
+		if t, ok := tables[table]; ok {
+			t.PrimaryKey = append(t.PrimaryKey, column)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = introspect(conn, queriesIndexes[d], nil, func(rows *sql.Rows) error {
+		var table, def string
+		err := rows.Scan(&table, &def)
+		if err != nil {
+			return err
+		}
+
+		if t, ok := tables[table]; ok {
+			t.Indexes = append(t.Indexes, def)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = introspect(conn, queriesForeignKeys[d], perTable, func(rows *sql.Rows) error {
+		var table, name, column, ref, refColumn string
+		err := rows.Scan(&table, &name, &column, &ref, &refColumn)
+		if err != nil {
+			return err
+		}
+
+		t, ok := tables[table]
+		if !ok {
+			return nil
+		}
+
+		n := len(t.ForeignKeys)
+		if n == 0 || t.ForeignKeys[n-1].Name != name {
+			t.ForeignKeys = append(t.ForeignKeys, &ForeignKey{Name: name, Table: ref})
+			n++
+		}
+
+		fk := t.ForeignKeys[n-1]
+		fk.Columns = append(fk.Columns, column)
+		fk.References = append(fk.References, refColumn)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rv, nil
+}
+
+// introspect calls fn with each row of the query, which is run once per
+// table with the table name as both arguments if tables are provided. An
+// empty query is ignored.
+func introspect(conn Conn, query string, tables []string, fn func(rows *sql.Rows) error) error {
+	if query == "" {
+		return nil
+	}
+
+	if tables == nil {
+		return introspectRows(conn, query, fn)
+	}
+
+	for _, name := range tables {
+		err := introspectRows(conn, query, fn, name, name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// introspectRows calls fn with each row of the query with the arguments.
+func introspectRows(conn Conn, query string, fn func(rows *sql.Rows) error, args ...interface{}) error {
+	rows, err := conn.QueryContext(optionsOf(conn).ctx, query, args...)
+	if err != nil {
+		return err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		err := fn(rows)
+		if err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// String returns the definition of the foreign key, such as
+// "(account_id) REFERENCES accounts (id)".
+func (fk *ForeignKey) String() string {
+	return fmt.Sprintf("(%s) REFERENCES %s (%s)", strings.Join(fk.Columns, ", "), fk.Table, strings.Join(fk.References, ", "))
+}
+
+// Diff returns the differences from the schema to the other schema in
+// order, such as "column accounts.email added".
+func (s *Schema) Diff(other *Schema) []string {
+	var rv []string
+	a, b := s.objects(), other.objects()
+	for _, key := range keys(a, b) {
+		before, inA := a[key]
+		after, inB := b[key]
+		switch {
+		case !inA:
+			rv = append(rv, key+" added")
+		case !inB:
+			rv = append(rv, key+" removed")
+		case before != after:
+			rv = append(rv, fmt.Sprintf("%s changed from %q to %q", key, before, after))
+		}
+	}
+
+	return rv
+}
+
+// objects returns the definitions of the tables, columns and indexes of
+// the schema by a description of the object.
+func (s *Schema) objects() map[string]string {
+	rv := make(map[string]string)
+	for _, t := range s.Tables {
+		rv["table "+t.Name] = ""
+		for _, c := range t.Columns {
+			def := c.Type
+			if !c.Nullable {
+				def += " NOT NULL"
+			}
+			if c.Default != "" {
+				def += " DEFAULT " + c.Default
+			}
+			rv["column "+t.Name+"."+c.Name] = def
+		}
+		if len(t.PrimaryKey) > 0 {
+			rv["primary key "+t.Name] = strings.Join(t.PrimaryKey, ", ")
+		}
+		for _, idx := range t.Indexes {
+			rv["index "+t.Name+" "+idx] = ""
+		}
+		for _, fk := range t.ForeignKeys {
+			rv["foreign key "+t.Name+"."+fk.Name] = fk.String()
+		}
+	}
+
+	return rv
+}
+
+// keys returns the keys of both maps in order.
+func keys(a, b map[string]string) []string {
+	var rv []string
+	for k := range a {
+		rv = append(rv, k)
+	}
+
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			rv = append(rv, k)
+		}
+	}
+
+	sort.Strings(rv)
+	return rv
+}
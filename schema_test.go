@@ -0,0 +1,156 @@
+package migrator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestTables(t *testing.T) {
+	db := openTestDB(t)
+	for _, stmt := range []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT);",
+		"CREATE TABLE accounts (id INTEGER);",
+		"CREATE VIEW active AS SELECT id FROM users;",
+	} {
+		_, err := db.Exec(stmt)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	have, err := Tables(db, SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"accounts", "users"}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("Tables = %q, want %q", have, want)
+	}
+}
+
+func TestTablesRunContext(t *testing.T) {
+	conn := testConn(t, openTestDB(t))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	untrack := track(conn, "20240101T000000Z", newOptions([]Option{WithContext(ctx)}))
+	defer untrack()
+
+	_, err := Tables(conn, SQLite)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Tables error = %v, want %v", err, context.Canceled)
+	}
+
+	_, err = Introspect(conn, SQLite)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Introspect error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestIntrospect(t *testing.T) {
+	db := openTestDB(t)
+	for _, stmt := range []string{
+		"CREATE TABLE users (id INTEGER NOT NULL PRIMARY KEY, email TEXT DEFAULT 'none');",
+		"CREATE UNIQUE INDEX users_email_idx ON users (email);",
+		"CREATE VIEW emails AS SELECT email FROM users;",
+	} {
+		_, err := db.Exec(stmt)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	have, err := Introspect(db, SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Schema{Tables: []*Table{{
+		Name: "users",
+		Columns: []*ColumnInfo{
+			{Name: "id", Type: "INTEGER"},
+			{Name: "email", Type: "TEXT", Nullable: true, Default: "'none'"},
+		},
+		PrimaryKey: []string{"id"},
+		Indexes:    []string{"CREATE UNIQUE INDEX users_email_idx ON users (email)"},
+	}}}
+
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("Introspect = %s, want %s", dump(have), dump(want))
+	}
+}
+
+// dump returns the schema as JSON for failure messages.
+func dump(s *Schema) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+func TestSchemaDiff(t *testing.T) {
+	users := func(cols ...*ColumnInfo) *Table {
+		return &Table{Name: "users", Columns: cols}
+	}
+
+	id := &ColumnInfo{Name: "id", Type: "integer"}
+	tests := []struct {
+		name string
+		a, b *Schema
+		want []string
+	}{
+		{"equal", &Schema{Tables: []*Table{users(id)}}, &Schema{Tables: []*Table{users(id)}}, nil},
+		{"table added", &Schema{}, &Schema{Tables: []*Table{users(id)}}, []string{"column users.id added", "table users added"}},
+		{"table removed", &Schema{Tables: []*Table{users()}}, &Schema{}, []string{"table users removed"}},
+		{
+			"primary key changed",
+			&Schema{Tables: []*Table{{Name: "users", PrimaryKey: []string{"id"}}}},
+			&Schema{Tables: []*Table{{Name: "users", PrimaryKey: []string{"id", "org"}}}},
+			[]string{`primary key users changed from "id" to "id, org"`},
+		},
+		{
+			"column changed",
+			&Schema{Tables: []*Table{users(id)}},
+			&Schema{Tables: []*Table{users(&ColumnInfo{Name: "id", Type: "bigint", Nullable: true, Default: "0"})}},
+			[]string{`column users.id changed from "integer NOT NULL" to "bigint DEFAULT 0"`},
+		},
+		{
+			"index added and removed",
+			&Schema{Tables: []*Table{{Name: "users", Indexes: []string{"CREATE INDEX a ON users (id)"}}}},
+			&Schema{Tables: []*Table{{Name: "users", Indexes: []string{"CREATE INDEX b ON users (id)"}}}},
+			[]string{"index users CREATE INDEX a ON users (id) removed", "index users CREATE INDEX b ON users (id) added"},
+		},
+	}
+
+	for _, tt := range tests {
+		have := tt.a.Diff(tt.b)
+		if !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("%s: Diff = %q, want %q", tt.name, have, tt.want)
+		}
+	}
+}
+
+func TestIntrospectForeignKeys(t *testing.T) {
+	db := openTestDB(t)
+	for _, stmt := range []string{
+		"CREATE TABLE users (id INTEGER PRIMARY KEY, org INTEGER);",
+		"CREATE TABLE orders (id INTEGER, user_id INTEGER, org INTEGER, FOREIGN KEY (user_id, org) REFERENCES users (id, org));",
+	} {
+		_, err := db.Exec(stmt)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	s, err := Introspect(db, SQLite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*ForeignKey{{Name: "fk_0", Columns: []string{"user_id", "org"}, Table: "users", References: []string{"id", "org"}}}
+	if len(s.Tables) != 2 || !reflect.DeepEqual(s.Tables[0].ForeignKeys, want) || s.Tables[1].ForeignKeys != nil {
+		t.Errorf("Introspect = %s, want the foreign key of orders", dump(s))
+	}
+}
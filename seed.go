@@ -0,0 +1,125 @@
+package migrator
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+)
+
+// seeds is a map of migrationFunc that load data keyed by name.
+var seeds = make(map[string]migrationFunc)
+
+// querySeedsNew creates the seeds table if not already created.
+var querySeedsNew = `
+CREATE TABLE IF NOT EXISTS seeds (
+  name       TEXT PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+`
+
+// querySeedsNewMySQL creates the seeds table in MySQL if not already
+// created.
+var querySeedsNewMySQL = `
+CREATE TABLE IF NOT EXISTS seeds (
+  name       VARCHAR(255) PRIMARY KEY,
+  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+`
+
+// queriesSeedsNew are the queries that create the seeds table in each
+// dialect.
+var queriesSeedsNew = map[Dialect]string{
+	Postgres: querySeedsNew,
+	MySQL:    querySeedsNewMySQL,
+	SQLite:   querySeedsNew,
+}
+
+// querySeedsExists selects whether a seed has been loaded.
+var querySeedsExists = `
+SELECT EXISTS (SELECT 1 FROM seeds WHERE name = $1);
+`
+
+// querySeedsInsert inserts a loaded seed.
+var querySeedsInsert = `
+INSERT INTO seeds (name)
+  VALUES ($1);
+`
+
+// RegisterSeed makes a seed that loads reference or demo data available
+// by the provided name. Seeds are tracked separately from migrations and
+// are only loaded by RunSeeds. If RegisterSeed is called twice with the
+// same name or if fn is nil, it panics.
+func RegisterSeed(name string, fn func(tx *sql.Tx) error) {
+	if fn == nil {
+		panic("migrator: RegisterSeed fn is required")
+	}
+
+	if _, ok := seeds[name]; ok {
+		panic("migrator: RegisterSeed called twice for seed " + name)
+	}
+
+	seeds[name] = fn
+}
+
+// RunSeeds loads the named seeds in order, or all seeds in order of name
+// if names is empty, on the database of the dialect of the options. Each
+// seed is loaded at most once per database, in its own transaction with
+// its record in the seeds table, so running seeds is idempotent. Choose
+// the names per environment to load, for example, demo data only in
+// development.
+func RunSeeds(db *sql.DB, names []string, opts ...Option) error {
+	o := newOptions(opts)
+	if len(names) == 0 {
+		for name := range seeds {
+			names = append(names, name)
+		}
+
+		sort.Strings(names)
+	}
+
+	for _, name := range names {
+		if _, ok := seeds[name]; !ok {
+			return fmt.Errorf("migrator: unknown seed %q", name)
+		}
+	}
+
+	_, err := db.ExecContext(o.ctx, queriesSeedsNew[o.dialect])
+	if err != nil {
+		return err
+	}
+
+	for _, name := range names {
+		err = seed(db, name, o)
+		if err != nil {
+			return fmt.Errorf("migrator: seed %q: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// seed loads the named seed in a transaction if it has not been loaded.
+func seed(db *sql.DB, name string, o *options) error {
+	tx, err := db.BeginTx(o.ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	var loaded bool
+	err = tx.QueryRowContext(o.ctx, o.dialect.rebind(querySeedsExists), name).Scan(&loaded)
+	if err == nil && !loaded {
+		err = seeds[name](tx)
+		if err == nil {
+			_, err = tx.ExecContext(o.ctx, o.dialect.rebind(querySeedsInsert), name)
+		}
+	}
+
+	if err != nil {
+		if err := tx.Rollback(); err != nil {
+			return err
+		}
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,131 @@
+package migrator
+
+import (
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// isolateSeeds removes the registered seeds until the test completes.
+func isolateSeeds(t *testing.T) {
+	t.Helper()
+
+	saved := seeds
+	seeds = make(map[string]migrationFunc)
+	t.Cleanup(func() { seeds = saved })
+}
+
+func TestRunSeeds(t *testing.T) {
+	isolateSeeds(t)
+
+	var calls []string
+	for _, name := range []string{"users", "countries", "demo"} {
+		name := name
+		RegisterSeed(name, func(tx *sql.Tx) error {
+			calls = append(calls, name)
+			return nil
+		})
+	}
+
+	db := openTestDB(t)
+	err := RunSeeds(db, []string{"users", "demo"}, WithDialect(SQLite))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = RunSeeds(db, nil, WithDialect(SQLite))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"users", "demo", "countries"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %q, want %q with each seed loaded once", calls, want)
+	}
+}
+
+func TestRunSeedsUnknown(t *testing.T) {
+	isolateSeeds(t)
+
+	RegisterSeed("users", func(tx *sql.Tx) error {
+		t.Error("seed loaded with an unknown seed requested")
+		return nil
+	})
+
+	err := RunSeeds(openTestDB(t), []string{"users", "missing"}, WithDialect(SQLite))
+	if err == nil || !strings.Contains(err.Error(), `unknown seed "missing"`) {
+		t.Errorf("RunSeeds error = %v, want unknown seed", err)
+	}
+}
+
+func TestRunSeedsError(t *testing.T) {
+	isolateSeeds(t)
+
+	fail := errors.New("fail")
+	failing := true
+	RegisterSeed("users", func(tx *sql.Tx) error {
+		if failing {
+			return fail
+		}
+		return nil
+	})
+
+	db := openTestDB(t)
+	err := RunSeeds(db, nil, WithDialect(SQLite))
+	if err == nil || !strings.Contains(err.Error(), `seed "users": fail`) {
+		t.Fatalf("RunSeeds error = %v, want the seed failure", err)
+	}
+
+	var n int
+	err = db.QueryRow("SELECT COUNT(*) FROM seeds;").Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 0 {
+		t.Fatalf("%d seeds recorded, want the failed seed rolled back", n)
+	}
+
+	failing = false
+	err = RunSeeds(db, nil, WithDialect(SQLite))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.QueryRow("SELECT COUNT(*) FROM seeds;").Scan(&n)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 1 {
+		t.Errorf("%d seeds recorded, want 1", n)
+	}
+}
+
+func TestRegisterSeedPanics(t *testing.T) {
+	isolateSeeds(t)
+
+	RegisterSeed("users", empty)
+
+	tests := []struct {
+		name string
+		fn   migrationFunc
+	}{
+		{"nil", nil},
+		{"users", empty},
+	}
+
+	for _, tt := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RegisterSeed(%q) did not panic", tt.name)
+				}
+			}()
+
+			RegisterSeed(tt.name, tt.fn)
+		}()
+	}
+}
@@ -0,0 +1,44 @@
+package migrator
+
+import "sort"
+
+// InSet assigns the migration to the named migration set. Each set is
+// run separately with WithSet and recorded in its own versions table, so
+// the modules of an application can own their slices of the schema. By
+// default, migrations belong to the unnamed set.
+func InSet(name string) MigrationOption {
+	return func(m *migration) {
+		m.set = name
+	}
+}
+
+// WithSet narrows the run to the migrations of the named set, recorded
+// in the table <name>_versions unless WithTable is set. The default is
+// the unnamed set recorded in the versions table.
+func WithSet(name string) Option {
+	return func(o *options) {
+		o.set = name
+	}
+}
+
+// inSet returns true if the version timestamp is registered in the set
+// of the run. NilVersion belongs to every set.
+func (o *options) inSet(version string) bool {
+	m, ok := migrations[version]
+	return ok && (m.set == o.set || version == NilVersion)
+}
+
+// sorted returns the version timestamps of the set of the run in
+// ascending order.
+func (o *options) sorted() []string {
+	var rv []string
+	for v := range migrations {
+		if o.inSet(v) {
+			rv = append(rv, v)
+		}
+	}
+
+	sort.Strings(rv)
+
+	return rv
+}
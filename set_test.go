@@ -0,0 +1,106 @@
+package migrator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSorted(t *testing.T) {
+	isolate(t)
+	Register("20240103T000000Z", "c", empty, empty, InSet("analytics"))
+	Register("20240102T000000Z", "b", empty, empty)
+	Register("20240101T000000Z", "a", empty, empty, InSet("analytics"))
+
+	tests := []struct {
+		set  string
+		want []string
+	}{
+		{"", []string{NilVersion, "20240102T000000Z"}},
+		{"analytics", []string{NilVersion, "20240101T000000Z", "20240103T000000Z"}},
+		{"billing", []string{NilVersion}},
+	}
+
+	for _, tt := range tests {
+		have := newOptions([]Option{WithSet(tt.set)}).sorted()
+		if !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("sorted of set %q = %q, want %q", tt.set, have, tt.want)
+		}
+	}
+}
+
+func TestWithSet(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+	registerSQL("20240102T000000Z", "create_events", []string{"CREATE TABLE events (id INTEGER);"}, []string{"DROP TABLE events;"}, false, []MigrationOption{InSet("analytics")})
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+	err := Migrate(db, "", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !tableExists(t, db, "users") || tableExists(t, db, "events") {
+		t.Fatal("the unnamed set migrated the analytics set")
+	}
+
+	analytics := []Option{sqlite, WithSet("analytics")}
+	state, pending, err := Check(db, analytics...)
+	if err != nil || state != Pending || !reflect.DeepEqual(pending, []string{NilVersion, "20240102T000000Z"}) {
+		t.Errorf("Check of analytics = %s %q, %v, want the analytics set pending", state, pending, err)
+	}
+
+	err = Migrate(db, "", analytics...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !tableExists(t, db, "analytics_versions") || !tableExists(t, db, "events") {
+		t.Error("analytics set not migrated and recorded in analytics_versions")
+	}
+
+	for _, opts := range [][]Option{{sqlite}, analytics} {
+		state, _, err := Check(db, opts...)
+		if err != nil || state != UpToDate {
+			t.Errorf("Check = %s, %v, want up to date", state, err)
+		}
+	}
+
+	infos, err := Inspect(db, analytics...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(infos) != 2 || infos[1].Set != "analytics" || !infos[1].Applied {
+		t.Errorf("Inspect of analytics = %+v", infos)
+	}
+
+	s, err := Snapshot(db, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, table := range s.Tables {
+		if table.Name == "analytics_versions" {
+			t.Error("snapshot includes the versions table of the analytics set")
+		}
+	}
+}
+
+func TestWithSetTable(t *testing.T) {
+	tests := []struct {
+		opts []Option
+		want string
+	}{
+		{nil, "versions"},
+		{[]Option{WithSet("analytics")}, "analytics_versions"},
+		{[]Option{WithTable("events_versions"), WithSet("analytics")}, "events_versions"},
+	}
+
+	for _, tt := range tests {
+		have := newOptions(tt.opts).table
+		if have != tt.want {
+			t.Errorf("table = %q, want %q", have, tt.want)
+		}
+	}
+}
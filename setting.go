@@ -0,0 +1,69 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+)
+
+// A setting is a session setting of a migration.
+type setting struct {
+	name  string
+	value string
+}
+
+// settings are the session settings of a migration in order.
+type settings []setting
+
+// Setting sets the Postgres session setting, such as search_path,
+// work_mem or synchronous_commit, to the value while the migration runs.
+// The setting is local to the transaction of the migration. For
+// migrations registered with RegisterNoTransaction, the previous value
+// is restored after the migration runs. Either way, the setting does not
+// leak into other migrations.
+func Setting(name, value string) MigrationOption {
+	return func(m *migration) {
+		m.settings = append(m.settings, setting{name: name, value: value})
+	}
+}
+
+// querySettingSet sets a session setting, locally to the transaction if
+// the third argument is true.
+var querySettingSet = `
+SELECT set_config($1, $2, $3);
+`
+
+// querySettingGet selects the current value of a session setting.
+var querySettingGet = `
+SELECT current_setting($1);
+`
+
+// configure applies the settings on the transaction or connection,
+// locally to the transaction if local is true.
+func (s settings) configure(ctx context.Context, e Execer, local bool) error {
+	for _, setting := range s {
+		_, err := e.ExecContext(ctx, querySettingSet, setting.name, setting.value, local)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// settingsOf returns the current values on the connection of the
+// settings of the migration, so that they can be restored after the
+// migration runs.
+func (m *migration) settingsOf(ctx context.Context, conn *sql.Conn) (settings, error) {
+	var rv settings
+	for _, s := range m.settings {
+		var value string
+		err := conn.QueryRowContext(ctx, querySettingGet, s.name).Scan(&value)
+		if err != nil {
+			return nil, err
+		}
+
+		rv = append(rv, setting{name: s.name, value: value})
+	}
+
+	return rv, nil
+}
@@ -0,0 +1,125 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// testSettings are the session settings of the databases opened by
+// openSettingsDB, emulating set_config and current_setting.
+var testSettings = struct {
+	sync.Mutex
+	m   map[string]string
+	set []setting
+}{m: make(map[string]string)}
+
+func init() {
+	sql.Register("sqlite3_settings", &sqlite3.SQLiteDriver{
+		ConnectHook: func(conn *sqlite3.SQLiteConn) error {
+			err := conn.RegisterFunc("set_config", func(name, value string, local bool) string {
+				testSettings.Lock()
+				defer testSettings.Unlock()
+				testSettings.m[name] = value
+				testSettings.set = append(testSettings.set, setting{name: name, value: value})
+				return value
+			}, false)
+			if err != nil {
+				return err
+			}
+
+			return conn.RegisterFunc("current_setting", func(name string) string {
+				testSettings.Lock()
+				defer testSettings.Unlock()
+				return testSettings.m[name]
+			}, false)
+		},
+	})
+}
+
+// openSettingsDB opens a SQLite database with emulated session settings
+// in a temporary directory that is closed when the test completes.
+func openSettingsDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	testSettings.Lock()
+	testSettings.m = map[string]string{"work_mem": "4MB"}
+	testSettings.set = nil
+	testSettings.Unlock()
+
+	db, err := sql.Open("sqlite3_settings", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	_, err = db.Exec(queryTestVersionsNew)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return db
+}
+
+func TestSettingTransaction(t *testing.T) {
+	isolate(t)
+
+	var have string
+	up := func(tx *sql.Tx) error {
+		return tx.QueryRow("SELECT current_setting('work_mem');").Scan(&have)
+	}
+
+	Register("20240101T000000Z", "settings", up, empty, Setting("work_mem", "1GB"))
+
+	db := openSettingsDB(t)
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer tx.Rollback()
+
+	err = migrate(tx, "20240101T000000Z", true, nil, newOptions(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have != "1GB" {
+		t.Errorf("work_mem = %q during the migration, want 1GB", have)
+	}
+}
+
+func TestSettingNoTransaction(t *testing.T) {
+	isolate(t)
+
+	var have string
+	up := func(conn *sql.Conn) error {
+		return conn.QueryRowContext(context.Background(), "SELECT current_setting('work_mem');").Scan(&have)
+	}
+
+	RegisterNoTransaction("20240101T000000Z", "settings", up, func(conn *sql.Conn) error { return nil }, Setting("work_mem", "1GB"))
+
+	db := openSettingsDB(t)
+	err := migrateConn(testConn(t, db), "20240101T000000Z", true, nil, newOptions(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if have != "1GB" {
+		t.Errorf("work_mem = %q during the migration, want 1GB", have)
+	}
+
+	testSettings.Lock()
+	defer testSettings.Unlock()
+
+	want := []setting{{"work_mem", "1GB"}, {"work_mem", "4MB"}}
+	if !reflect.DeepEqual(testSettings.set, want) {
+		t.Errorf("settings = %v, want %v restoring the previous value", testSettings.set, want)
+	}
+}
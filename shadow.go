@@ -0,0 +1,110 @@
+package migrator
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+)
+
+// WithShadow verifies the run in a temporary shadow database created on
+// the server of the url before touching the database of the run. The
+// shadow database is migrated from nothing to the current version of
+// the database and then to the target, and the run is aborted if either
+// fails. The shadow database is dropped afterwards. The url usually
+// names a maintenance database, such as postgres, on a server with the
+// same version as the database of the run.
+func WithShadow(url string) Option {
+	return func(o *options) {
+		o.shadow = url
+	}
+}
+
+// verifyShadow replays the applied migrations of the database and the
+// migrations to the target in a shadow database if the run has one.
+func (o *options) verifyShadow(db *sql.DB, target string) error {
+	if o.shadow == "" {
+		return nil
+	}
+
+	err := o.createVersions(db)
+	if err != nil {
+		return err
+	}
+
+	current, err := currentVersion(db, o)
+	if err != nil {
+		return err
+	}
+
+	return o.scratch(o.shadow, "migrator_shadow_", func(shadow *sql.DB, so *options) error {
+		so.run = &Run{Target: target}
+		if current != "" {
+			err := migrateDB(shadow, current, so)
+			if err != nil {
+				return fmt.Errorf("migrator: shadow: replaying history to %s: %w", current, err)
+			}
+		}
+
+		err := migrateDB(shadow, target, so)
+		if err != nil {
+			return fmt.Errorf("migrator: shadow: migrating to %q: %w", target, err)
+		}
+
+		return nil
+	})
+}
+
+// scratch creates a temporary database prefixed by the prefix on the
+// server of the url and calls fn with it and a quiet copy of the options
+// for its dialect that does not notify, confirm, run the preflight or the
+// before and after hooks, or lock. The database is dropped when fn
+// returns.
+func (o *options) scratch(rawurl, prefix string, fn func(db *sql.DB, so *options) error) error {
+	b := make([]byte, 4)
+	rand.Read(b)
+	name := prefix + hex.EncodeToString(b)
+
+	target, drop, err := CreateDatabase(rawurl, name)
+	if err != nil {
+		return fmt.Errorf("migrator: create database %s: %w", name, err)
+	}
+
+	defer func() {
+		err := drop()
+		if err != nil {
+			o.logf(LevelError, "error dropping database %s: %v", name, err)
+		}
+	}()
+
+	db, d, err := Open(target)
+	if err != nil {
+		return err
+	}
+
+	defer db.Close()
+
+	so := *o
+	so.shadow = ""
+	so.canary = ""
+	so.dialect = d
+	so.run = &Run{}
+	so.notifiers = nil
+	so.events = nil
+	so.confirm = nil
+	so.approve = nil
+	so.backup = nil
+	so.recovery = false
+	so.preflight = nil
+	so.lock = false
+	so.primary = false
+	so.enter, so.exit = nil, nil
+	so.before, so.after = nil, nil
+	so.force = true
+	so.wait = 0
+	so.channel = ""
+	so.level = LevelWarn
+
+	o.logf(LevelInfo, "using temporary database %s", name)
+	return fn(db, &so)
+}
@@ -0,0 +1,75 @@
+package migrator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWithShadow(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+
+	db := openTestDB(t)
+	opts := []Option{WithDialect(SQLite), WithShadow("sqlite://shadow")}
+	err := Migrate(db, "20240101T000000Z", opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The table created by hand exists only in the database of the run,
+	// so the shadow replay of the next migration fails.
+	_, err = db.Exec("CREATE TABLE legacy (id INTEGER);")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	registerSQL("20240102T000000Z", "copy", []string{"INSERT INTO users SELECT id FROM legacy;"}, nil, false, nil)
+	err = Migrate(db, "", opts...)
+	if err == nil || !strings.HasPrefix(err.Error(), `migrator: shadow: migrating to "": `) {
+		t.Fatalf("Migrate error = %v, want the shadow failure", err)
+	}
+
+	current, err := Current(db, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if current != "20240101T000000Z" {
+		t.Errorf("version after the shadow failure = %q, want 20240101T000000Z", current)
+	}
+}
+
+func TestCreateDatabase(t *testing.T) {
+	rawurl, drop, err := CreateDatabase("sqlite://unused", "scratch")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := strings.TrimPrefix(rawurl, "sqlite://")
+	if filepath.Base(path) != "scratch.db" {
+		t.Errorf("CreateDatabase url = %q, want a scratch.db file", rawurl)
+	}
+
+	db, _, err := Open(rawurl)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = db.Exec("CREATE TABLE users (id INTEGER);")
+	db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = drop()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = os.Stat(filepath.Dir(path))
+	if !os.IsNotExist(err) {
+		t.Errorf("directory of the database after drop: %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// A Startup is a run that migrates the database to the latest version in
+// the background while an application starts, so the application can
+// report ready only once the schema is current.
+type Startup struct {
+	done chan struct{}
+	mu   sync.Mutex
+	err  error
+}
+
+// Start migrates the database to the latest version in the background.
+// The run holds the lock of WithLock, waits up to 30 seconds for the
+// database as with WithWaitForDB and stops when the context is
+// cancelled. The options may override these defaults.
+func Start(ctx context.Context, db *sql.DB, opts ...Option) *Startup {
+	s := &Startup{done: make(chan struct{})}
+	opts = append([]Option{
+		WithLock(),
+		WithWaitForDB(30 * time.Second),
+		WithContext(ctx),
+	}, opts...)
+
+	go func() {
+		err := Migrate(db, "", opts...)
+		s.mu.Lock()
+		s.err = err
+		s.mu.Unlock()
+		close(s.done)
+	}()
+
+	return s
+}
+
+// Done returns a channel that is closed when the run finishes.
+func (s *Startup) Done() <-chan struct{} {
+	return s.done
+}
+
+// Ready returns true if the run finished without error.
+func (s *Startup) Ready() bool {
+	select {
+	case <-s.done:
+		return s.Err() == nil
+	default:
+		return false
+	}
+}
+
+// Err returns the error of the run, or nil if it succeeded or has not
+// finished.
+func (s *Startup) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Wait blocks until the run finishes or the context is cancelled and
+// returns the error of the run or the context.
+func (s *Startup) Wait(ctx context.Context) error {
+	select {
+	case <-s.done:
+		return s.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ServeHTTP responds with 200 OK once the run finished without error and
+// 503 Service Unavailable otherwise, for use as a readiness check.
+func (s *Startup) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	select {
+	case <-s.done:
+	default:
+		http.Error(w, "migrating", http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := s.Err(); err != nil {
+		http.Error(w, "migration failed: "+err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("ready\n"))
+}
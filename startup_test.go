@@ -0,0 +1,154 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStart(t *testing.T) {
+	isolate(t)
+
+	release := make(chan struct{})
+	Register("20240101T000000Z", "blocked", func(tx *sql.Tx) error {
+		<-release
+		return nil
+	}, empty)
+
+	db := openTestDB(t)
+	s := Start(context.Background(), db, WithDialect(SQLite))
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest("GET", "/ready", nil))
+	if rec.Code != http.StatusServiceUnavailable || s.Ready() {
+		t.Errorf("ready while migrating: %d", rec.Code)
+	}
+
+	close(release)
+	err := s.Wait(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec = httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest("GET", "/ready", nil))
+	if rec.Code != http.StatusOK || !s.Ready() {
+		t.Errorf("not ready after migrating: %d", rec.Code)
+	}
+}
+
+func TestStartError(t *testing.T) {
+	isolate(t)
+
+	failed := errors.New("failed")
+	Register("20240101T000000Z", "failing", func(tx *sql.Tx) error { return failed }, empty)
+
+	db := openTestDB(t)
+	s := Start(context.Background(), db, WithDialect(SQLite))
+	<-s.Done()
+
+	if s.Ready() || s.Err() == nil {
+		t.Errorf("Ready = %t, Err = %v, want the error of the run", s.Ready(), s.Err())
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest("GET", "/ready", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d after the run failed, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+}
+
+func TestStartWaitCancelled(t *testing.T) {
+	isolate(t)
+
+	release := make(chan struct{})
+	Register("20240101T000000Z", "blocked", func(tx *sql.Tx) error {
+		<-release
+		return nil
+	}, empty)
+
+	db := openTestDB(t)
+	s := Start(context.Background(), db, WithDialect(SQLite))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err := s.Wait(ctx)
+	if err != context.Canceled {
+		t.Errorf("Wait error = %v, want %v", err, context.Canceled)
+	}
+
+	close(release)
+	<-s.Done()
+}
+
+func TestLockKey(t *testing.T) {
+	if lockKey("migrator") != lockKey("migrator") || lockKey("migrator") == lockKey("other") {
+		t.Error("lock keys are not stable by name")
+	}
+}
+
+func TestLockName(t *testing.T) {
+	tests := []struct {
+		opts []Option
+		want string
+	}{
+		{nil, "migrator:versions"},
+		{[]Option{WithTable("schema_versions")}, "migrator:schema_versions"},
+		{[]Option{WithTable("schema_versions"), WithLockNamespace("billing")}, "migrator:billing"},
+	}
+
+	for _, tt := range tests {
+		have := newOptions(tt.opts).lockName()
+		if have != tt.want {
+			t.Errorf("lockName = %q, want %q", have, tt.want)
+		}
+	}
+}
+
+// testLock is a Lock that records whether it is held.
+type testLock struct {
+	held     bool
+	acquired int
+	err      error
+}
+
+func (l *testLock) Acquire(ctx context.Context) (func(), error) {
+	if l.err != nil {
+		return nil, l.err
+	}
+
+	l.held = true
+	l.acquired++
+	return func() { l.held = false }, nil
+}
+
+func TestWithExternalLock(t *testing.T) {
+	isolate(t)
+
+	l := &testLock{}
+	heldDuring := false
+	Register("20240101T000000Z", "locked", func(tx *sql.Tx) error {
+		heldDuring = l.held
+		return nil
+	}, empty)
+
+	db := openTestDB(t)
+	err := Migrate(db, "", WithDialect(SQLite), WithExternalLock(l))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if l.acquired != 1 || !heldDuring || l.held {
+		t.Errorf("lock acquired %d times, held during the run %t, held after %t", l.acquired, heldDuring, l.held)
+	}
+
+	l.err = errors.New("unavailable")
+	err = Migrate(db, "", WithDialect(SQLite), WithExternalLock(l))
+	if err == nil || !strings.Contains(err.Error(), "acquiring lock: unavailable") {
+		t.Errorf("Migrate error = %v, want the lock error", err)
+	}
+}
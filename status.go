@@ -0,0 +1,95 @@
+package migrator
+
+import (
+	"database/sql"
+	"time"
+)
+
+// Info is the state of a migration. The checksum of a SQL migration is
+// the checksum of its up statements, as recorded when it was applied if
+// it is applied.
+type Info struct {
+	Version    string     `json:"version" yaml:"version"`
+	Name       string     `json:"name" yaml:"name"`
+	Set        string     `json:"set,omitempty" yaml:"set,omitempty"`
+	Phase      string     `json:"phase,omitempty" yaml:"phase,omitempty"`
+	Change     string     `json:"change,omitempty" yaml:"change,omitempty"` // expand or contract, if declared
+	Applied    bool       `json:"applied" yaml:"applied"`
+	SkipReason string     `json:"skip_reason,omitempty" yaml:"skip_reason,omitempty"`
+	Checksum   string     `json:"checksum,omitempty" yaml:"checksum,omitempty"`
+	AppliedAt  *time.Time `json:"applied_at,omitempty" yaml:"applied_at,omitempty"`
+	RevertedAt *time.Time `json:"reverted_at,omitempty" yaml:"reverted_at,omitempty"`
+	Archived   bool       `json:"archived,omitempty" yaml:"archived,omitempty"` // moved to the archive table by Archive
+}
+
+// Inspect returns the state of the registered migrations of the set of
+// the run in ascending order by version timestamp. Skipped migrations
+// are applied with the reason they were skipped.
+func Inspect(db *sql.DB, opts ...Option) ([]*Info, error) {
+	o := newOptions(opts)
+
+	vs, err := versions(db, o)
+	if err != nil {
+		return nil, err
+	}
+
+	var rv []*Info
+	for _, v := range o.sorted() {
+		rv = append(rv, registered(v))
+	}
+
+	for _, info := range rv {
+		if applied := find(info.Version, vs); applied != nil {
+			info.Applied = true
+			info.SkipReason = applied.skipReason
+			info.Checksum = applied.checksum
+			info.AppliedAt = &applied.createdAt
+		}
+	}
+
+	return rv, nil
+}
+
+// Registered returns the registered migrations of every set in ascending
+// order by version timestamp without querying their state.
+func Registered() []*Info {
+	var rv []*Info
+	for _, v := range sorted() {
+		rv = append(rv, registered(v))
+	}
+
+	return rv
+}
+
+// registered returns the registered migration of the version timestamp.
+func registered(version string) *Info {
+	m := migrations[version]
+	rv := &Info{Version: version, Name: m.name, Set: m.set, Phase: m.phase.String(), Checksum: m.sum()}
+	switch {
+	case m.expand:
+		rv.Change = "expand"
+	case m.contracts != "":
+		rv.Change = "contract"
+	}
+
+	return rv
+}
+
+// Applied returns the migrations recorded in the versions table in
+// ascending order by version timestamp, including versions that are not
+// registered.
+func Applied(db *sql.DB, opts ...Option) ([]*Info, error) {
+	o := newOptions(opts)
+
+	vs, err := versions(db, o)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := make([]*Info, len(vs))
+	for i, v := range vs {
+		rv[i] = v.info()
+	}
+
+	return rv, nil
+}
@@ -0,0 +1,90 @@
+package migrator
+
+import (
+	"database/sql"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{
+		"20240101T000000Z": "users",
+		"20240103T000000Z": "tags",
+	})
+
+	skip := func(tx *sql.Tx) (bool, error) { return false, nil }
+	Register("20240102T000000Z", "skipped", empty, empty, ShouldRun(skip))
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+	err := Migrate(db, "20240102T000000Z", sqlite, WithLogger(&testLogger{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	infos, err := Inspect(db, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Info{
+		{Version: NilVersion, Name: "nil", Phase: "pre-deploy", Applied: true},
+		{Version: "20240101T000000Z", Name: "create_users", Phase: "pre-deploy", Applied: true, Checksum: checksum("CREATE TABLE users (id INTEGER);")},
+		{Version: "20240102T000000Z", Name: "skipped", Phase: "pre-deploy", Applied: true, SkipReason: "predicate"},
+		{Version: "20240103T000000Z", Name: "create_tags", Phase: "pre-deploy", Checksum: checksum("CREATE TABLE tags (id INTEGER);")},
+	}
+
+	if len(infos) != len(want) {
+		t.Fatalf("%d infos, want %d", len(infos), len(want))
+	}
+
+	for i, info := range infos {
+		if info.Applied != (info.AppliedAt != nil) {
+			t.Errorf("%s: applied %t with applied at %v", info.Version, info.Applied, info.AppliedAt)
+		}
+
+		have := *info
+		have.AppliedAt = nil
+		if have != want[i] {
+			t.Errorf("info %d = %+v, want %+v", i, have, want[i])
+		}
+	}
+}
+
+func TestApplied(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+	err := Migrate(db, "", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	delete(migrations, "20240101T000000Z")
+	registerTables(t, map[string]string{"20240101T000000Z": "accounts"})
+
+	infos, err := Applied(db, sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(infos) != 2 {
+		t.Fatalf("%d applied, want 2", len(infos))
+	}
+
+	have := infos[1]
+	if have.Version != "20240101T000000Z" || have.Name != "create_users" || !have.Applied || have.AppliedAt == nil {
+		t.Errorf("applied = %+v, want the recorded migration", have)
+	}
+
+	if have.Checksum != checksum("CREATE TABLE users (id INTEGER);") {
+		t.Errorf("checksum = %q, want the checksum recorded when applied", have.Checksum)
+	}
+
+	registered := Registered()
+	if registered[1].Checksum == have.Checksum {
+		t.Error("registered checksum matches after the statements changed")
+	}
+}
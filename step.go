@@ -0,0 +1,88 @@
+package migrator
+
+import "database/sql"
+
+// A Step is a migration to be performed by a run.
+type Step struct {
+	Version    string    // version timestamp of the migration
+	Name       string    // name of the migration
+	Up         bool      // whether or not the migration is performed up
+	Statements []string  // statements of SQL migrations in the direction
+	Warnings   []Warning // warnings for the statements of the migration
+}
+
+// Plan returns the steps that would be performed to bring the database
+// to the state of the target version timestamp in the order they would
+// be performed, without performing them.
+func Plan(db *sql.DB, target string, opts ...Option) ([]Step, error) {
+	o := newOptions(opts)
+
+	err := o.createVersions(db)
+	if err != nil {
+		return nil, err
+	}
+
+	vs, _, up, err := plan(db, target, o)
+	if err != nil {
+		return nil, err
+	}
+
+	return steps(db, vs, up, o)
+}
+
+// Destructive returns true if the step reverts a migration or has a
+// statement that destroys data.
+func (s Step) Destructive() bool {
+	if !s.Up {
+		return true
+	}
+
+	for _, w := range s.Warnings {
+		if w.Destroy {
+			return true
+		}
+	}
+
+	return false
+}
+
+// steps returns the steps of the version timestamps in the direction of
+// the run with the warnings for their statements.
+func steps(conn Conn, vs []string, up bool, o *options) ([]Step, error) {
+	ws, err := analyze(conn, vs, up, o)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := make([]Step, len(vs))
+	for i, v := range vs {
+		rv[i] = Step{
+			Version:    v,
+			Name:       migrations[v].name,
+			Up:         up,
+			Statements: Statements(v, up),
+		}
+		for _, w := range ws {
+			if w.Version == v {
+				rv[i].Warnings = append(rv[i].Warnings, w)
+			}
+		}
+	}
+
+	return rv, nil
+}
+
+// confirm passes the steps of the version timestamps to the function
+// set by WithConfirm, if any.
+func confirm(conn Conn, vs []string, up bool, o *options) error {
+	if o.confirm == nil || len(vs) == 0 {
+		return nil
+	}
+
+	s, err := steps(conn, vs, up, o)
+	if err != nil {
+		return err
+	}
+
+	return o.confirm(s)
+}
@@ -0,0 +1,111 @@
+package migrator
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestStepDestructive(t *testing.T) {
+	tests := []struct {
+		s    Step
+		want bool
+	}{
+		{Step{Up: true}, false},
+		{Step{Up: false}, true},
+		{Step{Up: true, Warnings: []Warning{{Rewrite: true}}}, false},
+		{Step{Up: true, Warnings: []Warning{{Rewrite: true}, {Destroy: true}}}, true},
+	}
+
+	for _, tt := range tests {
+		have := tt.s.Destructive()
+		if have != tt.want {
+			t.Errorf("%+v.Destructive() = %t, want %t", tt.s, have, tt.want)
+		}
+	}
+}
+
+func TestWithConfirm(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{
+		"20240101T000000Z": "users",
+		"20240102T000000Z": "posts",
+	})
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+
+	var have []Step
+	err := Migrate(db, "", sqlite, WithConfirm(func(steps []Step) error {
+		have = steps
+		return nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Step{
+		{Version: NilVersion, Name: "nil", Up: true},
+		{Version: "20240101T000000Z", Name: "create_users", Up: true, Statements: []string{"CREATE TABLE users (id INTEGER);"}},
+		{Version: "20240102T000000Z", Name: "create_posts", Up: true, Statements: []string{"CREATE TABLE posts (id INTEGER);"}},
+	}
+
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("steps = %+v, want %+v", have, want)
+	}
+
+	declined := errors.New("declined")
+	err = Migrate(db, "20240101T000000Z", sqlite, WithConfirm(func(steps []Step) error {
+		if len(steps) != 1 || steps[0].Version != "20240102T000000Z" || steps[0].Up {
+			t.Errorf("steps = %+v, want 20240102T000000Z down", steps)
+		}
+		return declined
+	}))
+	if err != declined {
+		t.Fatalf("Migrate error = %v, want %v", err, declined)
+	}
+
+	if !tableExists(t, db, "posts") {
+		t.Error("migration reverted after the run was declined")
+	}
+}
+
+func TestPlan(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{
+		"20240101T000000Z": "users",
+		"20240102T000000Z": "posts",
+	})
+
+	db := openTestDB(t)
+	sqlite := WithDialect(SQLite)
+
+	err := Migrate(db, "20240101T000000Z", sqlite)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		target string
+		want   []Step
+	}{
+		{"", []Step{{Version: "20240102T000000Z", Name: "create_posts", Up: true, Statements: []string{"CREATE TABLE posts (id INTEGER);"}}}},
+		{"20240101T000000Z", []Step{}},
+		{NilVersion, []Step{{Version: "20240101T000000Z", Name: "create_users", Up: false, Statements: []string{"DROP TABLE users;"}}}},
+	}
+
+	for _, tt := range tests {
+		have, err := Plan(db, tt.target, sqlite)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(have) != len(tt.want) || len(have) > 0 && !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("Plan(%q) = %+v, want %+v", tt.target, have, tt.want)
+		}
+	}
+
+	if !tableExists(t, db, "users") || tableExists(t, db, "posts") {
+		t.Error("Plan performed migrations")
+	}
+}
@@ -0,0 +1,65 @@
+package migrator
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// TenantErrors are the errors of the tenant schemas that failed to
+// migrate keyed by schema.
+type TenantErrors map[string]error
+
+// Error returns the errors of each schema in order of schema.
+func (e TenantErrors) Error() string {
+	var schemas []string
+	for schema := range e {
+		schemas = append(schemas, schema)
+	}
+
+	sort.Strings(schemas)
+
+	msgs := make([]string, len(schemas))
+	for i, schema := range schemas {
+		msgs[i] = fmt.Sprintf("%s: %v", schema, e[schema])
+	}
+
+	return fmt.Sprintf("migrator: %d tenants failed: %s", len(e), strings.Join(msgs, "; "))
+}
+
+// MigrateAll performs the database migrations once per tenant schema to
+// bring each schema to the state of the target version timestamp, for
+// databases with a schema per tenant. Each run sets the search_path to
+// the schema, creating the schema if it does not exist, so each tenant
+// has its own versions table. A failed tenant does not stop the others
+// from migrating. The result of every schema is returned in the order of
+// schemas, and the failures are also returned as TenantErrors.
+func MigrateAll(db *sql.DB, schemas []string, target string, opts ...Option) (Results, error) {
+	var rv Results
+	errs := make(TenantErrors)
+	for _, schema := range schemas {
+		tenant := append(opts[:len(opts):len(opts)],
+			WithBefore(
+				"CREATE SCHEMA IF NOT EXISTS "+ident(schema)+";",
+				"SET search_path TO "+ident(schema)+";",
+			),
+			WithAfter("RESET search_path;"),
+		)
+
+		o := newOptions(tenant)
+		o.result = nil
+		err := migrateRun(db, target, o)
+		rv = append(rv, result(schema, o.run, err))
+		if err != nil {
+			o.logf(LevelError, "error migrating tenant %q: %v", schema, err)
+			errs[schema] = err
+		}
+	}
+
+	if len(errs) > 0 {
+		return rv, errs
+	}
+
+	return rv, nil
+}
@@ -0,0 +1,18 @@
+package migrator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTenantErrors(t *testing.T) {
+	err := TenantErrors{
+		"tenant_b": errors.New("relation exists"),
+		"tenant_a": errors.New("timeout"),
+	}
+
+	want := "migrator: 2 tenants failed: tenant_a: timeout; tenant_b: relation exists"
+	if have := err.Error(); have != want {
+		t.Errorf("Error() = %q, want %q", have, want)
+	}
+}
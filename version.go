@@ -7,53 +7,198 @@ import (
 
 // A version is an applied migration.
 type version struct {
-	id        int64
-	version   string
-	name      string
-	createdAt time.Time
+	id         int64
+	version    string
+	name       string
+	skipReason string
+	checksum   string
+	createdAt  time.Time
+	archived   bool
 }
 
-// queryVersionsNew creates the versions table if not already created.
+// queryVersionsNew creates the versions table if not already created
+// and adds any columns missing from versions tables created by earlier
+// releases. The queries of the versions table are formatted with the
+// table name.
 var queryVersionsNew = `
-CREATE TABLE IF NOT EXISTS versions (
-  id         BIGSERIAL PRIMARY KEY,
-  version    TEXT NOT NULL,
-  name       TEXT NOT NULL,
-  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+CREATE TABLE IF NOT EXISTS %[1]s (
+  id          BIGSERIAL PRIMARY KEY,
+  version     TEXT NOT NULL,
+  name        TEXT NOT NULL,
+  skip_reason TEXT NOT NULL DEFAULT '',
+  checksum    TEXT NOT NULL DEFAULT '',
+  created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  reverted_at TIMESTAMP NULL
 );
+ALTER TABLE %[1]s ADD COLUMN IF NOT EXISTS skip_reason TEXT NOT NULL DEFAULT '';
+ALTER TABLE %[1]s ADD COLUMN IF NOT EXISTS checksum TEXT NOT NULL DEFAULT '';
+ALTER TABLE %[1]s ADD COLUMN IF NOT EXISTS reverted_at TIMESTAMP NULL;
 `
 
+// queryVersionsNewMySQL creates the versions table in MySQL if not
+// already created.
+var queryVersionsNewMySQL = `
+CREATE TABLE IF NOT EXISTS %[1]s (
+  id          BIGINT AUTO_INCREMENT PRIMARY KEY,
+  version     VARCHAR(255) NOT NULL,
+  name        VARCHAR(255) NOT NULL,
+  skip_reason VARCHAR(255) NOT NULL DEFAULT '',
+  checksum    VARCHAR(64) NOT NULL DEFAULT '',
+  created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  reverted_at TIMESTAMP NULL
+);
+`
+
+// queryVersionsNewSQLite creates the versions table in SQLite if not
+// already created.
+var queryVersionsNewSQLite = `
+CREATE TABLE IF NOT EXISTS %[1]s (
+  id          INTEGER PRIMARY KEY AUTOINCREMENT,
+  version     TEXT NOT NULL,
+  name        TEXT NOT NULL,
+  skip_reason TEXT NOT NULL DEFAULT '',
+  checksum    TEXT NOT NULL DEFAULT '',
+  created_at  TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  reverted_at TIMESTAMP NULL
+);
+`
+
+// queriesVersionsNew are the queries that create the versions table in
+// each dialect.
+var queriesVersionsNew = map[Dialect]string{
+	Postgres: queryVersionsNew,
+	MySQL:    queryVersionsNewMySQL,
+	SQLite:   queryVersionsNewSQLite,
+}
+
 // queryVersionsAll selects the applied migrations by ascending version.
 var queryVersionsAll = `
-SELECT id, version, name, created_at
-  FROM versions
+SELECT id, version, name, skip_reason, checksum, created_at
+  FROM %[1]s
+  WHERE reverted_at IS NULL
   ORDER BY version ASC;
 `
 
 // queryVersionsLast selects the version timestamp most recently applied.
 var queryVersionsLast = `
 SELECT version
-  FROM versions
+  FROM %[1]s
+  WHERE reverted_at IS NULL
   ORDER BY version DESC
   LIMIT 1;
 `
 
-// queryVersionsInsert inserts a new version.
+// queryVersionsInsert inserts a new version with the checksum of its
+// statements at the time of the clock of the run.
 var queryVersionsInsert = `
-INSERT INTO versions (version, name)
-  VALUES ($1, $2);
+INSERT INTO %[1]s (version, name, checksum, created_at)
+  VALUES ($1, $2, $3, $4);
+`
+
+// queryVersionsSkip inserts a new version that was skipped for a reason
+// at the time of the clock of the run.
+var queryVersionsSkip = `
+INSERT INTO %[1]s (version, name, skip_reason, created_at)
+  VALUES ($1, $2, $3, $4);
 `
 
 // queryVersionsDelete deletes the version by timestamp.
 var queryVersionsDelete = `
-DELETE FROM versions
+DELETE FROM %[1]s
   WHERE version = $1;
 `
 
-// versions returns a slice of versions applied.
-func versions(db *sql.DB) ([]*version, error) {
+// queryVersionsRevert marks the version reverted at the time of the clock
+// of the run rather than deleting it.
+var queryVersionsRevert = `
+UPDATE %[1]s
+  SET reverted_at = $1
+  WHERE version = $2 AND reverted_at IS NULL;
+`
+
+// Queries that select whether a table has a column by dialect.
+var queriesColumnExists = map[Dialect]string{
+	Postgres: `
+SELECT COUNT(*)
+  FROM information_schema.columns
+  WHERE table_schema = current_schema() AND table_name = $1 AND column_name = $2;
+`,
+	MySQL: `
+SELECT COUNT(*)
+  FROM information_schema.columns
+  WHERE table_schema = DATABASE() AND table_name = $1 AND column_name = $2;
+`,
+	SQLite: `
+SELECT COUNT(*)
+  FROM pragma_table_info($1)
+  WHERE name = $2;
+`,
+}
+
+// createVersions creates the versions table of the run if not already
+// created and adds any columns it is missing.
+func (o *options) createVersions(conn Conn) error {
+	_, err := conn.ExecContext(o.ctx, o.query(queriesVersionsNew[o.dialect]))
+	if err != nil {
+		return err
+	}
+
+	return o.addColumns(conn)
+}
+
+// addColumns adds the reverted_at column to MySQL and SQLite versions
+// tables created by earlier releases, which Postgres adds when creating
+// the table, and the down_sql column if the run stores down SQL.
+func (o *options) addColumns(conn Conn) error {
+	var cols [][2]string
+	if o.dialect != Postgres {
+		cols = append(cols, [2]string{"reverted_at", "TIMESTAMP NULL"})
+	}
+
+	if o.downSQL {
+		cols = append(cols, [2]string{"down_sql", downColumn[o.dialect]})
+	}
+
+	for _, col := range cols {
+		var n int
+		err := conn.QueryRowContext(o.ctx, o.dialect.rebind(queriesColumnExists[o.dialect]), o.table, col[0]).Scan(&n)
+		if err != nil {
+			return err
+		}
+
+		if n > 0 {
+			continue
+		}
+
+		_, err = conn.ExecContext(o.ctx, o.query("ALTER TABLE %s ADD COLUMN "+col[0]+" "+col[1]+";"))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// versions returns a slice of versions applied, including the versions
+// that were archived, in ascending order.
+func versions(conn Conn, o *options) ([]*version, error) {
+	rv, err := archived(conn, o)
+	if err != nil {
+		return nil, err
+	}
+
+	vs, err := scanVersions(conn, o.query(queryVersionsAll), o)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(rv, vs...), nil
+}
+
+// scanVersions returns the versions selected by the query.
+func scanVersions(conn Conn, query string, o *options) ([]*version, error) {
 	var rv []*version
-	rows, err := db.Query(queryVersionsAll)
+	rows, err := conn.QueryContext(o.ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -62,7 +207,7 @@ func versions(db *sql.DB) ([]*version, error) {
 
 	for rows.Next() {
 		v := new(version)
-		err := rows.Scan(&v.id, &v.version, &v.name, &v.createdAt)
+		err := rows.Scan(&v.id, &v.version, &v.name, &v.skipReason, &v.checksum, &v.createdAt)
 		if err != nil {
 			return nil, err
 		}
@@ -78,13 +223,33 @@ func versions(db *sql.DB) ([]*version, error) {
 	return rv, nil
 }
 
-// currentVersion returns the version timestamp most recently applied.
-func currentVersion(db *sql.DB) (string, error) {
+// info returns the applied migration of the version.
+func (v *version) info() *Info {
+	return &Info{
+		Version:    v.version,
+		Name:       v.name,
+		Applied:    true,
+		SkipReason: v.skipReason,
+		Checksum:   v.checksum,
+		AppliedAt:  &v.createdAt,
+		Archived:   v.archived,
+	}
+}
+
+// currentVersion returns the version timestamp most recently applied,
+// which is the most recently archived version if every version after it
+// was reverted.
+func currentVersion(conn Conn, o *options) (string, error) {
 	var v string
 
-	err := db.QueryRow(queryVersionsLast).Scan(&v)
+	err := conn.QueryRowContext(o.ctx, o.query(queryVersionsLast)).Scan(&v)
 	if err == sql.ErrNoRows {
-		return "", nil
+		vs, err := archived(conn, o)
+		if err != nil || len(vs) == 0 {
+			return "", err
+		}
+
+		return vs[len(vs)-1].version, nil
 	}
 
 	return v, err
@@ -0,0 +1,62 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Bounds of the delay between attempts to reach the database.
+const (
+	waitMin = 100 * time.Millisecond
+	waitMax = 5 * time.Second
+)
+
+// WithWaitForDB waits up to the timeout for the database to accept
+// connections before the run starts. See WaitForDB.
+func WithWaitForDB(timeout time.Duration) Option {
+	return func(o *options) {
+		o.wait = timeout
+	}
+}
+
+// WaitForDB pings the database until it responds, the timeout elapses
+// or the context is cancelled. The delay between attempts doubles from
+// 100ms up to 5s. The error of the last attempt is returned if the
+// database never responds.
+func WaitForDB(ctx context.Context, db *sql.DB, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	delay := waitMin
+	for {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+
+		t := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return fmt.Errorf("migrator: database unavailable after %s: %w", timeout, err)
+		case <-t.C:
+		}
+
+		delay *= 2
+		if delay > waitMax {
+			delay = waitMax
+		}
+	}
+}
+
+// waitForDB waits for the database if the run has a timeout set by
+// WithWaitForDB.
+func (o *options) waitForDB(db *sql.DB) error {
+	if o.wait <= 0 {
+		return nil
+	}
+
+	return WaitForDB(o.ctx, db, o.wait)
+}
@@ -0,0 +1,40 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWaitForDB(t *testing.T) {
+	db := openTestDB(t)
+	err := WaitForDB(context.Background(), db, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	down, err := sql.Open("sqlite3", "file:"+filepath.Join(t.TempDir(), "missing", "test.db")+"?mode=ro")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer down.Close()
+
+	began := time.Now()
+	err = WaitForDB(context.Background(), down, 250*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "database unavailable after 250ms") {
+		t.Errorf("WaitForDB error = %v, want unavailable", err)
+	}
+
+	if elapsed := time.Since(began); elapsed < 250*time.Millisecond || elapsed > 2*time.Second {
+		t.Errorf("WaitForDB returned after %s, want the timeout", elapsed)
+	}
+
+	err = Migrate(down, "", WithDialect(SQLite), WithWaitForDB(50*time.Millisecond))
+	if err == nil || !strings.Contains(err.Error(), "database unavailable") {
+		t.Errorf("Migrate error = %v, want unavailable", err)
+	}
+}
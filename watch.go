@@ -0,0 +1,141 @@
+package migrator
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// watchInterval is the delay between the scans of a watched directory.
+const watchInterval = 250 * time.Millisecond
+
+// Watch loads the SQL migrations in dir as with LoadDir and migrates the
+// database to the latest version, then does so again whenever a file in
+// dir changes and no other change follows within debounce, such as to
+// apply migrations as they are written during development. Migrations
+// previously loaded from dir are replaced, so pending migrations may be
+// edited in place. Errors are logged and the watch continues until the
+// context is done. The runs hold the lock of WithLock and stop when the
+// context is done. The options may override these defaults.
+func Watch(ctx context.Context, db *sql.DB, dir string, debounce time.Duration, opts ...Option) error {
+	opts = append([]Option{WithLock()}, opts...)
+	opts = append(opts, WithContext(ctx))
+	o := newOptions(opts)
+
+	applied := ""
+	seen, changed := "", time.Time{}
+	t := time.NewTicker(watchInterval)
+	defer t.Stop()
+
+	for {
+		s, err := scanDir(dir)
+		if err != nil {
+			o.logf(LevelError, "error watching %s: %v", dir, err)
+		}
+
+		if s != seen {
+			seen, changed = s, time.Now()
+		}
+
+		if err == nil && s != applied && time.Since(changed) >= debounce {
+			applied = s
+			err = reloadDir(dir)
+			if err == nil {
+				err = Migrate(db, "", opts...)
+			}
+
+			if err != nil && ctx.Err() == nil {
+				o.logf(LevelError, "error migrating %s: %v", dir, err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-t.C:
+		}
+	}
+}
+
+// scanDir returns the names, sizes and modification times of the SQL
+// files in dir, which differ whenever a file is added, removed or edited.
+func scanDir(dir string) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", err
+	}
+
+	var rv []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".sql") {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return "", err
+		}
+
+		rv = append(rv, fmt.Sprintf("%s %d %d", entry.Name(), info.Size(), info.ModTime().UnixNano()))
+	}
+
+	sort.Strings(rv)
+
+	return strings.Join(rv, "\n"), nil
+}
+
+// reloadDir replaces the migrations and repeatables loaded from dir with
+// the SQL migrations in dir. Nothing is replaced if dir fails to parse or
+// one of its migrations or repeatables collides with one registered
+// otherwise, such as in Go.
+func reloadDir(dir string) error {
+	dir = filepath.Clean(dir)
+	ms, rs, err := parseDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for v, m := range ms {
+		if prev, ok := migrations[v]; ok && !loadedFrom(prev.source, dir) {
+			return fmt.Errorf("migrator: version %s registered twice, by %s and %s", v, prev.registeredAt(), m.registeredAt())
+		}
+	}
+
+	for name, r := range rs {
+		if prev, ok := repeatables[name]; ok && !loadedFrom(prev.source, dir) {
+			return fmt.Errorf("migrator: %s: repeatable %s is already registered", r.source, name)
+		}
+	}
+
+	for v, m := range migrations {
+		if loadedFrom(m.source, dir) {
+			delete(migrations, v)
+		}
+	}
+
+	for name, r := range repeatables {
+		if loadedFrom(r.source, dir) {
+			delete(repeatables, name)
+		}
+	}
+
+	for v, m := range ms {
+		migrations[v] = m
+	}
+
+	for name, r := range rs {
+		repeatables[name] = r
+	}
+
+	return nil
+}
+
+// loadedFrom returns whether or not the source file is in dir.
+func loadedFrom(source, dir string) bool {
+	return source != "" && filepath.Dir(source) == dir
+}
@@ -0,0 +1,137 @@
+package migrator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeFile writes the file in dir or fails the test.
+func writeFile(t *testing.T, dir, name, data string) {
+	t.Helper()
+
+	err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestScanDir(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "20240101T000000Z_users.up.sql", "CREATE TABLE users (id INTEGER);")
+	writeFile(t, dir, "README.md", "ignored")
+
+	before, err := scanDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, dir, "README.md", "still ignored")
+	have, err := scanDir(dir)
+	if err != nil || have != before {
+		t.Errorf("scan after editing another file = %q, %v, want unchanged", have, err)
+	}
+
+	writeFile(t, dir, "20240101T000000Z_users.up.sql", "CREATE TABLE users (id INTEGER, name TEXT);")
+	have, err = scanDir(dir)
+	if err != nil || have == before {
+		t.Errorf("scan after editing a migration = %q, %v, want changed", have, err)
+	}
+
+	_, err = scanDir(filepath.Join(dir, "missing"))
+	if err == nil {
+		t.Error("scan of a missing directory error = nil")
+	}
+}
+
+func TestReloadDir(t *testing.T) {
+	isolate(t)
+	isolateRepeatables(t)
+	Register("20240102T000000Z", "registered", empty, empty)
+
+	dir := t.TempDir()
+	writeFile(t, dir, "20240101T000000Z_users.up.sql", "CREATE TABLE users (id INTEGER);")
+	writeFile(t, dir, "views.repeatable.sql", "CREATE VIEW v AS SELECT 1;")
+	err := LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, dir, "20240101T000000Z_users.up.sql", "CREATE TABLE users (id INTEGER, name TEXT);")
+	os.Remove(filepath.Join(dir, "views.repeatable.sql"))
+	err = reloadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := migrations["20240101T000000Z"]
+	if m == nil || m.upSQL[0] != "CREATE TABLE users (id INTEGER, name TEXT);" {
+		t.Errorf("reloaded migration = %+v, want the edited statement", m)
+	}
+
+	if migrations["20240102T000000Z"] == nil {
+		t.Error("reload unregistered a migration not loaded from the directory")
+	}
+
+	if _, ok := repeatables["views"]; ok {
+		t.Error("reload kept the removed repeatable")
+	}
+
+	writeFile(t, dir, "20240101T000000Z_accounts.down.sql", "DROP TABLE accounts;")
+	err = reloadDir(dir)
+	if err == nil {
+		t.Error("reload of an invalid directory error = nil")
+	}
+
+	os.Remove(filepath.Join(dir, "20240101T000000Z_accounts.down.sql"))
+	writeFile(t, dir, "20240102T000000Z_registered.up.sql", "CREATE TABLE registered (id INTEGER);")
+	err = reloadDir(dir)
+	if err == nil {
+		t.Error("reload of a version registered in Go error = nil")
+	}
+
+	if migrations["20240101T000000Z"] != m || migrations["20240102T000000Z"].sql {
+		t.Error("failed reload replaced the registered migrations")
+	}
+}
+
+func TestWatch(t *testing.T) {
+	isolate(t)
+	isolateRepeatables(t)
+
+	dir := t.TempDir()
+	writeFile(t, dir, "20240101T000000Z_users.up.sql", "CREATE TABLE users (id INTEGER);")
+
+	db := openTestDB(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Watch(ctx, db, dir, 0, WithDialect(SQLite), WithLogger(&testLogger{}))
+	}()
+
+	waitFor := func(table string) {
+		t.Helper()
+
+		deadline := time.Now().Add(5 * time.Second)
+		for !tableExists(t, db, table) {
+			if time.Now().After(deadline) {
+				cancel()
+				t.Fatalf("table %s not created by the watch", table)
+			}
+
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	waitFor("users")
+	writeFile(t, dir, "20240102T000000Z_posts.up.sql", "CREATE TABLE posts (id INTEGER);")
+	waitFor("posts")
+
+	cancel()
+	err := <-done
+	if err != nil {
+		t.Errorf("Watch error = %v, want nil when the context is done", err)
+	}
+}
@@ -0,0 +1,75 @@
+package migrator
+
+import (
+	"fmt"
+	"time"
+)
+
+// heavyTag is the tag of the migrations restricted by WithWindow when it
+// names no tags.
+const heavyTag = "heavy"
+
+// A window is a daily window of UTC in which the migrations labeled with
+// any of its tags may be performed.
+type window struct {
+	start, end time.Duration
+	tags       []string
+}
+
+// WithWindow restricts the migrations labeled with any of the tags, or
+// heavy if none are provided, to the daily window of UTC from start to
+// end, offsets from midnight such as 2*time.Hour and 5*time.Hour for
+// 02:00-05:00 UTC. The window wraps around midnight if end is before
+// start. Outside of the window, the migrations are deferred: they remain
+// pending, or applied when migrating down, and are listed as deferred by
+// the run to be performed by a later run within the window.
+func WithWindow(start, end time.Duration, tags ...string) Option {
+	if len(tags) == 0 {
+		tags = []string{heavyTag}
+	}
+
+	return func(o *options) {
+		o.windows = append(o.windows, window{start: start, end: end, tags: tags})
+	}
+}
+
+// open returns true if the time of day of t in UTC is within the window.
+func (w window) open(t time.Time) bool {
+	t = t.UTC()
+	d := t.Sub(time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC))
+	if w.start <= w.end {
+		return d >= w.start && d < w.end
+	}
+
+	return d >= w.start || d < w.end
+}
+
+// String returns the window formatted as 02:00-05:00 UTC.
+func (w window) String() string {
+	clock := func(d time.Duration) string {
+		return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+	}
+
+	return clock(w.start) + "-" + clock(w.end) + " UTC"
+}
+
+// deferred returns true and records the version timestamp as deferred by
+// the run if the migration is restricted to a window that is not open.
+func (o *options) deferred(version string) bool {
+	m := migrations[version]
+	now := o.now()
+	for _, w := range o.windows {
+		if !m.hasTag(w.tags) || w.open(now) {
+			continue
+		}
+
+		if !contains(o.run.Deferred, version) {
+			o.logf(LevelWarn, "deferring %q until %s", version, w)
+			o.run.Deferred = append(o.run.Deferred, version)
+		}
+
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,84 @@
+package migrator
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWindowOpen(t *testing.T) {
+	at := func(hour, min int) time.Time {
+		return time.Date(2024, 1, 1, hour, min, 0, 0, time.UTC)
+	}
+
+	night := window{start: 2 * time.Hour, end: 5 * time.Hour}
+	wrap := window{start: 22 * time.Hour, end: 3 * time.Hour}
+	tests := []struct {
+		w    window
+		t    time.Time
+		want bool
+	}{
+		{night, at(1, 59), false},
+		{night, at(2, 0), true},
+		{night, at(4, 59), true},
+		{night, at(5, 0), false},
+		{night, time.Date(2024, 1, 1, 21, 30, 0, 0, time.FixedZone("EST", -5*60*60)), true},
+		{wrap, at(23, 0), true},
+		{wrap, at(2, 59), true},
+		{wrap, at(3, 0), false},
+		{wrap, at(12, 0), false},
+	}
+
+	for _, tt := range tests {
+		have := tt.w.open(tt.t)
+		if have != tt.want {
+			t.Errorf("%s open at %s = %t, want %t", tt.w, tt.t, have, tt.want)
+		}
+	}
+}
+
+func TestWindowString(t *testing.T) {
+	w := window{start: 2*time.Hour + 30*time.Minute, end: 5 * time.Hour}
+	if have := w.String(); have != "02:30-05:00 UTC" {
+		t.Errorf("String = %q, want 02:30-05:00 UTC", have)
+	}
+}
+
+func TestWithWindow(t *testing.T) {
+	isolate(t)
+	registerTables(t, map[string]string{"20240101T000000Z": "users"})
+	registerSQL("20240102T000000Z", "create_index", []string{"CREATE INDEX users_id_idx ON users (id);"}, []string{"DROP INDEX users_id_idx;"}, false, []MigrationOption{Tags("heavy")})
+	registerTables(t, map[string]string{"20240103T000000Z": "posts"})
+
+	db := openTestDB(t)
+	noon := fixedClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	o := newOptions([]Option{WithDialect(SQLite), WithClock(noon), WithWindow(2*time.Hour, 5*time.Hour)})
+	err := migrateRun(db, "", o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(o.run.Deferred, []string{"20240102T000000Z"}) {
+		t.Errorf("deferred = %v, want the heavy migration", o.run.Deferred)
+	}
+
+	if !tableExists(t, db, "posts") {
+		t.Error("migration after the deferred migration not performed")
+	}
+
+	state, pending, err := Check(db, WithDialect(SQLite))
+	if err != nil || state != Pending || !reflect.DeepEqual(pending, []string{"20240102T000000Z"}) {
+		t.Errorf("Check = %s, %v, %v, want the deferred migration pending", state, pending, err)
+	}
+
+	night := fixedClock(time.Date(2024, 1, 2, 3, 0, 0, 0, time.UTC))
+	o = newOptions([]Option{WithDialect(SQLite), WithClock(night), WithWindow(2*time.Hour, 5*time.Hour)})
+	err = migrateRun(db, "", o)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(o.run.Deferred) != 0 || !reflect.DeepEqual(o.run.Performed, []string{"20240102T000000Z"}) {
+		t.Errorf("run = %+v, want the heavy migration performed within the window", o.run)
+	}
+}